@@ -2,24 +2,29 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/jmoiron/sqlx"
-	"github.com/joho/godotenv"
+	"github.com/zachczx/cubby/api/internal/auth"
+	"github.com/zachczx/cubby/api/internal/config"
 	"github.com/zachczx/cubby/api/internal/database"
+	"github.com/zachczx/cubby/api/internal/event"
 	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/mailer"
+	"github.com/zachczx/cubby/api/internal/migration"
 	"github.com/zachczx/cubby/api/internal/notifier"
 	"github.com/zachczx/cubby/api/internal/server"
+	"github.com/zachczx/cubby/api/internal/telemetry"
 	"github.com/zachczx/cubby/api/internal/tracker"
 	"github.com/zachczx/cubby/api/internal/user"
+	"github.com/zachczx/cubby/api/internal/webhook"
 )
 
 const (
@@ -28,20 +33,45 @@ const (
 )
 
 func main() {
-	var err error
+	printOpenAPI := flag.Bool("openapi", false, "print the OpenAPI document and exit")
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
 
-	// Try loading .env file, but don't fail if it doesn't exist (e.g. in Docker)
-	if err = godotenv.Load("../.env"); err != nil {
-		if !os.IsNotExist(err) {
-			log.Printf("Warning: Error loading .env file: %v", err)
+	if *printOpenAPI {
+		body, err := openAPIDocument()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := os.Stdout.Write(body); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if flags.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
 		}
-	} else {
-		slog.Info("App init", "env init", "ok")
+		return
 	}
 
-	logging.Init()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	logging.Init(cfg.Development())
+
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	db, err := sqlx.Connect("pgx", database.GetConnectionString())
+	db, err := database.Connect(cfg.DB.URL())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -50,49 +80,104 @@ func main() {
 	initCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
 	defer cancel()
 
-	fcm, err := notifier.NewFCMClient(initCtx)
+	// Replicas starting together queue on the migration lock, so only the first applies anything.
+	if err := migration.Up(context.Background(), db); err != nil {
+		log.Fatal(err)
+	}
+
+	fcm, err := notifier.NewFCMClient(initCtx, cfg.Firebase)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	origins := []string{os.Getenv("CORS_DEV"), os.Getenv("CORS_WEB"), os.Getenv("CORS_DEV_ALT"), os.Getenv("CORS_PROD_APP")}
+	m := mailer.FromConfig(cfg.SMTP)
+
+	authenticator, err := auth.FromConfig(cfg.Auth, m)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Webhook deliveries are queued from the bus and sent by the dispatcher below.
+	events := event.NewBus()
+	events.Subscribe(webhook.Subscriber(db))
 
 	s := server.NewService(
-		os.Getenv("STYTCH_PROJECT_ID"),
-		os.Getenv("STYTCH_SECRET"),
+		authenticator,
 		db,
 		tracker.DefaultService{},
 		user.UserManager{},
 		fcm,
-		server.NewCookieConfig(),
-		origins,
+		m,
+		events,
+		cfg,
 	)
 
-	mux := NewHTTPHandler(s)
-
-	rawPort := os.Getenv("API_LISTEN_ADDR")
-	// Satisfy gosec linter.
-	portInt, err := strconv.Atoi(rawPort)
+	rl, err := NewRateLimiter(db, cfg.RateLimit)
 	if err != nil {
-		portInt = 7002
+		log.Fatal(err)
 	}
-	port := ":" + strconv.Itoa(portInt)
-	slog.Info("server started", "port", port)
+
+	mux := NewHTTPHandler(s, rl)
+
+	slog.Info("server started", "addr", cfg.ListenAddr())
 	server := &http.Server{
-		Addr:              ":" + os.Getenv("API_LISTEN_ADDR"),
+		Addr:              cfg.ListenAddr(),
 		ReadHeaderTimeout: defaultTimeout,
-		Handler:           RequestIDMiddleware(CORSMiddleware(s, mux)),
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		Handler: RequestIDMiddleware(
+			CORSMiddleware(s,
+				AccessLogMiddleware(
+					RecoverMiddleware(
+						TimeoutMiddleware(requestTimeout, telemetry.Middleware(mux)),
+					),
+				),
+			),
+		),
 	}
 
 	osCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	go func() {
-		if err := tracker.StartNotifications(osCtx, s.DB, s.Notifier); err != nil {
+		if err := tracker.StartNotifications(osCtx, s.DB, s.Notifier, s.Events, s.Health.Notifications); err != nil {
 			slog.Error("notification failure", "error", err)
 		}
 	}()
 
+	go func() {
+		if err := webhook.StartDispatcher(osCtx, s.DB, cfg.Development()); err != nil {
+			slog.Error("webhook dispatcher failure", "error", err)
+		}
+	}()
+
+	go func() {
+		if err := user.StartAccountPurge(osCtx, s.DB, s.Auth.DeleteIdentity); err != nil {
+			slog.Error("account purge failure", "error", err)
+		}
+	}()
+
+	// Prometheus metrics and the detailed readiness report are served on a separate address so they stay off the
+	// public API.
+	if addr := cfg.MetricsAddr; addr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", telemetry.Handler())
+		metricsMux.HandleFunc("GET /readyz", s.ReadinessDetailsHandler)
+
+		metrics := &http.Server{
+			Addr:              addr,
+			ReadHeaderTimeout: defaultTimeout,
+			Handler:           metricsMux,
+		}
+
+		go func() {
+			if err := metrics.ListenAndServe(); err != nil {
+				slog.Error("metrics server failure", "error", err)
+			}
+		}()
+	}
+
 	go func() {
 		if err := server.ListenAndServe(); err != nil {
 			log.Fatal(err)
@@ -109,5 +194,9 @@ func main() {
 		log.Fatal("server forced shutdown: ", err)
 	}
 
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+
 	slog.Info("server exited", "status", "ok")
 }
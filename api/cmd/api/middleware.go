@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+const (
+	// requestTimeout bounds the context handlers pass to the auth provider, rate limiter and database.
+	requestTimeout = 30 * time.Second
+
+	// The server's own timeouts sit above requestTimeout so a handler that hits its deadline still gets to write
+	// the error response.
+	readTimeout  = 30 * time.Second
+	writeTimeout = requestTimeout + 5*time.Second
+	idleTimeout  = 2 * time.Minute
+)
+
+// AccessLogMiddleware writes one line per request once it has been served. The route and user ID are filled in by
+// routeMux and RequireAuthentication through logging.RequestInfo.
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, info := logging.WithRequestInfo(r.Context())
+		sw := &response.StatusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		logging.Info(ctx, "request",
+			"method", r.Method,
+			"route", info.Route,
+			"path", r.URL.Path,
+			"status", sw.Status(),
+			"durationMs", time.Since(start).Milliseconds(),
+			"userId", info.UserID,
+		)
+	})
+}
+
+// RecoverMiddleware turns a panicking handler into a logged 500 instead of a dropped connection. If the handler had
+// already started its response there's nothing left to send, so it's only logged.
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &response.StatusWriter{ResponseWriter: w}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// net/http uses this to abort a response on purpose and handles it quietly.
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			logging.Error(r.Context(), "handler panic", "panic", p, "stack", string(debug.Stack()))
+
+			if !sw.Written() {
+				response.WriteError(r.Context(), w, fmt.Errorf("panic: %v", p))
+			}
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// TimeoutMiddleware gives each request a deadline, which cancels the database and upstream calls made with its
+// context rather than letting them outlive the client.
+func TimeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
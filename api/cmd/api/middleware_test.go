@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+func TestRecoverMiddlewareWritesJSON(t *testing.T) {
+	h := RecoverMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+
+	var body response.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Status != http.StatusInternalServerError || strings.Contains(body.Message, "boom") {
+		t.Fatalf("body = %+v, want a generic 500", body)
+	}
+}
+
+func TestRouteMuxRecordsRouteAndLimitsBody(t *testing.T) {
+	mux := &routeMux{ServeMux: http.NewServeMux()}
+	mux.HandleFunc("POST /trackers/{trackerID}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			response.WriteError(r.Context(), w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, tt := range []struct {
+		size   int
+		status int
+	}{
+		{10, http.StatusNoContent},
+		{maxBodyBytes + 1, http.StatusRequestEntityTooLarge},
+	} {
+		ctx, info := logging.WithRequestInfo(context.Background())
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/trackers/abc", strings.NewReader(strings.Repeat("a", tt.size)))
+		rec := httptest.NewRecorder()
+
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("body of %d bytes: status = %d, want %d", tt.size, rec.Code, tt.status)
+		}
+		if info.Route != "POST /trackers/{trackerID}" {
+			t.Errorf("route = %q, want the matched pattern", info.Route)
+		}
+	}
+}
+
+func TestTimeoutMiddlewareSetsDeadline(t *testing.T) {
+	h := TimeoutMiddleware(time.Minute, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("request context has no deadline")
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
+	"github.com/zachczx/cubby/api/internal/apitoken"
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/calendar"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/gym"
+	"github.com/zachczx/cubby/api/internal/health"
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/market"
+	"github.com/zachczx/cubby/api/internal/openapi"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/server"
+	"github.com/zachczx/cubby/api/internal/timer"
+	"github.com/zachczx/cubby/api/internal/tracker"
+	"github.com/zachczx/cubby/api/internal/user"
+	"github.com/zachczx/cubby/api/internal/webhook"
+)
+
+// apiRoutes documents every route in NewHTTPHandler. TestOpenAPIMatchesMux fails when the two disagree, so add a
+// route here in the same change that registers it.
+var apiRoutes = []openapi.Route{
+	{Method: "GET", Path: "/{$}", Summary: "Service name", Tag: "meta", Public: true, ContentType: "text/plain"},
+	{Method: "GET", Path: "/health", Summary: "Bare liveness check", Tag: "meta", Public: true, Status: http.StatusOK},
+	{Method: "GET", Path: "/healthz", Summary: "Liveness check, 503 when the notification worker has stalled", Tag: "meta", Public: true, Response: health.Liveness{}},
+	{Method: "GET", Path: "/readyz", Summary: "Readiness check, 503 when the database, schema or push notifications aren't ready", Tag: "meta", Public: true, Response: health.ReadinessStatus{}},
+	{Method: "GET", Path: "/openapi.json", Summary: "This document", Tag: "meta", Public: true, Response: map[string]any{}},
+
+	{Method: "POST", Path: "/magic-link", Summary: "Email a sign-in link", Tag: "auth", Public: true, Form: []string{"email"}, Status: http.StatusAccepted},
+	{Method: "GET", Path: "/authenticate", Summary: "Sign in from a magic link", Tag: "auth", Public: true, ContentType: "text/html",
+		Query: []openapi.Param{{Name: "token"}, {Name: "stytch_token_type"}}},
+	{Method: "POST", Path: "/otp/send", Summary: "Email a one-time code", Tag: "auth", Public: true, Form: []string{"email"}, Response: server.OTPInput{}},
+	{Method: "POST", Path: "/otp/verify", Summary: "Sign in with a one-time code", Tag: "auth", Public: true, Request: server.OTPInput{}, Response: server.AuthenticateStatus{}},
+	{Method: "POST", Path: "/logout", Summary: "End the session", Tag: "auth", Public: true, Status: http.StatusOK},
+
+	{Method: "GET", Path: "/check", Summary: "Check the session", Tag: "users"},
+	{Method: "GET", Path: "/users", Summary: "Current user", Tag: "users", Response: user.User{}},
+	{Method: "GET", Path: "/users/me/families", Summary: "Families the user belongs to", Tag: "families", Response: []user.FamilyResponse{}},
+	{Method: "PATCH", Path: "/users/me/account", Summary: "Update account details", Tag: "users", Request: server.AccountInfoInput{}},
+	{Method: "PATCH", Path: "/users/me/sound", Summary: "Update timer sounds", Tag: "users", Request: server.SoundModeInput{}},
+	{Method: "PATCH", Path: "/users/me/task-lookahead", Summary: "Update task lookahead days", Tag: "users", Request: server.TaskDays{}},
+	{Method: "PATCH", Path: "/users/me/character", Summary: "Update preferred character", Tag: "users", Request: server.PreferredCharacter{}},
+	{Method: "PATCH", Path: "/users/me/active-family", Summary: "Set the default family", Tag: "families", Request: server.ActiveFamilyInput{}},
+	{Method: "GET", Path: "/users/me/calendar", Summary: "Calendar feed token status", Tag: "calendar", Response: calendar.TokenInfo{}},
+	{Method: "POST", Path: "/users/me/calendar", Summary: "Rotate the calendar feed token", Tag: "calendar", Response: calendar.NewToken{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/users/me/calendar", Summary: "Revoke the calendar feed token", Tag: "calendar"},
+	{Method: "DELETE", Path: "/users/me", Summary: "Schedule account deletion", Tag: "users", Request: user.AccountDeletionRequest{}, Response: user.AccountDeletion{}, Status: http.StatusAccepted},
+	{Method: "GET", Path: "/users/me/deletion", Summary: "Scheduled account deletion", Tag: "users", Response: user.AccountDeletion{}},
+	{Method: "DELETE", Path: "/users/me/deletion", Summary: "Cancel account deletion", Tag: "users"},
+	{Method: "GET", Path: "/users/me/export", Summary: "Export personal data", Tag: "users", Response: archive.Personal{}},
+	{Method: "GET", Path: "/users/me/tokens", Summary: "List access tokens", Tag: "tokens", Response: []apitoken.Token{}},
+	{Method: "POST", Path: "/users/me/tokens", Summary: "Create an access token", Tag: "tokens", Request: apitoken.Request{}, Response: apitoken.NewToken{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/users/me/tokens/{tokenID}", Summary: "Revoke an access token", Tag: "tokens"},
+
+	{Method: "GET", Path: "/calendar/{token}", Summary: "iCalendar feed of due trackers", Tag: "calendar", Public: true, ContentType: "text/calendar",
+		Query: []openapi.Param{{Name: "tz", Description: "IANA time zone for all-day events"}}},
+
+	{Method: "POST", Path: "/families", Summary: "Create a family", Tag: "families", Request: user.FamilyRequest{}, Response: uuid.UUID{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/families/invites", Summary: "Invites for the user", Tag: "invites", Response: []user.Invite{}},
+	{Method: "POST", Path: "/families/invites", Summary: "Invite someone by email", Tag: "invites", Request: user.InviteRequest{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/invites/{inviteID}", Summary: "Get an invite", Tag: "invites", Response: user.Invite{}},
+	{Method: "POST", Path: "/families/{familyID}/leave", Summary: "Leave a family", Tag: "families"},
+	{Method: "POST", Path: "/families/invites/{inviteID}/accept", Summary: "Accept an invite", Tag: "invites"},
+	{Method: "POST", Path: "/families/invites/{inviteID}/decline", Summary: "Decline an invite", Tag: "invites"},
+	{Method: "POST", Path: "/families/invites/{inviteID}/resend", Summary: "Resend an invite", Tag: "invites", Response: user.Invite{}},
+	{Method: "DELETE", Path: "/families/invites/{inviteID}", Summary: "Revoke an invite", Tag: "invites"},
+	{Method: "GET", Path: "/families/{familyID}/invites", Summary: "Invites sent from a family", Tag: "invites", Response: []user.Invite{}},
+	{Method: "GET", Path: "/families/invite-links", Summary: "List invite links", Tag: "invites", Response: []user.InviteLink{},
+		Query: []openapi.Param{{Name: "familyId", Description: "Defaults to the active family"}}},
+	{Method: "POST", Path: "/families/invite-links", Summary: "Create an invite link", Tag: "invites", Request: user.InviteLinkRequest{}, Response: user.NewInviteLink{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/families/invite-links/{linkID}", Summary: "Delete an invite link", Tag: "invites"},
+	{Method: "GET", Path: "/invite-links/{token}", Summary: "Preview an invite link", Tag: "invites", Public: true, Response: user.InviteLink{}},
+	{Method: "POST", Path: "/invite-links/{token}/accept", Summary: "Join through an invite link", Tag: "invites", Response: user.InviteLink{}},
+	{Method: "GET", Path: "/families/{familyID}/activity", Summary: "Family activity feed", Tag: "activity", Response: activity.Page{},
+		Query: []openapi.Param{{Name: "before", Description: "Cursor from the previous page"}, {Name: "limit", Type: "integer"}}},
+	{Method: "PATCH", Path: "/families/{familyID}", Summary: "Rename a family", Tag: "families", Request: user.FamilyRequest{}},
+	{Method: "POST", Path: "/families/{familyID}/transfer", Summary: "Transfer family ownership", Tag: "families", Request: user.TransferRequest{}},
+	{Method: "POST", Path: "/families/{familyID}/deletion", Summary: "Request a family deletion token", Tag: "families", Response: user.FamilyDeletion{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/families/{familyID}", Summary: "Delete a family", Tag: "families", Request: user.DeleteFamilyRequest{}},
+	{Method: "GET", Path: "/families/{familyID}/webhooks", Summary: "List webhooks", Tag: "webhooks", Response: []webhook.Webhook{}},
+	{Method: "POST", Path: "/families/{familyID}/webhooks", Summary: "Create a webhook", Tag: "webhooks", Request: webhook.Request{}, Response: webhook.NewWebhook{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/families/{familyID}/webhooks/{webhookID}", Summary: "Edit a webhook", Tag: "webhooks", Request: webhook.Update{}, Response: webhook.Webhook{}},
+	{Method: "DELETE", Path: "/families/{familyID}/webhooks/{webhookID}", Summary: "Delete a webhook", Tag: "webhooks"},
+	{Method: "GET", Path: "/families/{familyID}/webhooks/{webhookID}/deliveries", Summary: "Webhook delivery log", Tag: "webhooks", Response: webhook.DeliveryPage{},
+		Query: []openapi.Param{{Name: "before", Description: "Cursor from the previous page"}, {Name: "limit", Type: "integer"}}},
+	{Method: "DELETE", Path: "/families/{familyID}/{memberID}", Summary: "Remove a family member", Tag: "families"},
+
+	{Method: "GET", Path: "/vacations", Summary: "List vacations", Tag: "vacations", Response: []user.Vacation{}},
+	{Method: "POST", Path: "/vacations", Summary: "Add a vacation", Tag: "vacations", Request: user.VacationRequest{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/vacations/{vacationID}", Summary: "Delete a vacation", Tag: "vacations"},
+
+	{Method: "GET", Path: "/export", Summary: "Export trackers and entries", Tag: "archive", Response: archive.Archive{},
+		Query: []openapi.Param{{Name: "format", Description: "json (default) or csv"}, {Name: "domain", Description: "Which table to export as CSV"}}},
+	{Method: "POST", Path: "/import", Summary: "Import an export", Tag: "archive", Request: archive.Archive{}, Response: archive.ImportResult{}, Status: http.StatusCreated,
+		Query: []openapi.Param{{Name: "dryRun", Type: "boolean", Description: "Validate without writing; responds 200"}, {Name: "familyId"}}},
+
+	{Method: "GET", Path: "/trackers", Summary: "List trackers", Tag: "trackers", Response: []tracker.Tracker{}},
+	{Method: "GET", Path: "/trackers/{trackerID}", Summary: "Get a tracker", Tag: "trackers", Response: tracker.Tracker{}},
+	{Method: "POST", Path: "/trackers", Summary: "Create a tracker", Tag: "trackers", Request: tracker.Input{}, Response: uuid.UUID{}},
+	{Method: "POST", Path: "/trackers/{trackerID}/entries", Summary: "Log an entry", Tag: "entries", Request: entry.Input{}, Response: entry.Entry{}, Status: http.StatusCreated},
+	{Method: "POST", Path: "/trackers/{trackerID}/entries/bulk", Summary: "Log many entries", Tag: "entries", Request: entry.BulkInput{}, Response: entry.BulkResult{}, Status: http.StatusCreated,
+		Query: []openapi.Param{{Name: "interval", Type: "integer"}, {Name: "intervalUnit"}}},
+	{Method: "PATCH", Path: "/trackers/{trackerID}", Summary: "Edit a tracker", Tag: "trackers", Request: tracker.Input{}},
+	{Method: "DELETE", Path: "/trackers/{trackerID}", Summary: "Delete a tracker", Tag: "trackers"},
+	{Method: "PATCH", Path: "/trackers/{trackerID}/pinned", Summary: "Pin or unpin a tracker", Tag: "trackers", Request: server.TrackerToggle{}},
+	{Method: "PATCH", Path: "/trackers/{trackerID}/show", Summary: "Show or hide a tracker", Tag: "trackers", Request: server.TrackerToggle{}},
+	{Method: "POST", Path: "/trackers/{trackerID}/toggle-mute", Summary: "Mute a tracker's reminders", Tag: "trackers", Request: server.MutedInput{}},
+	{Method: "GET", Path: "/trackers/{trackerID}/checklist", Summary: "Tracker checklist", Tag: "trackers", Response: []tracker.ChecklistItem{}},
+	{Method: "POST", Path: "/trackers/{trackerID}/checklist", Summary: "Add a checklist item", Tag: "trackers", Request: tracker.ChecklistItemInput{}, Response: tracker.ChecklistItem{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/trackers/{trackerID}/checklist/{itemID}", Summary: "Edit a checklist item", Tag: "trackers", Request: tracker.ChecklistItemInput{}},
+	{Method: "DELETE", Path: "/trackers/{trackerID}/checklist/{itemID}", Summary: "Remove a checklist item", Tag: "trackers"},
+	{Method: "GET", Path: "/trackers/{trackerID}/comments", Summary: "Tracker comments", Tag: "activity", Response: []activity.Comment{}},
+	{Method: "POST", Path: "/trackers/{trackerID}/comments", Summary: "Comment on a tracker", Tag: "activity", Request: activity.CommentInput{}, Response: activity.Comment{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/trackers/{trackerID}/dependencies", Summary: "Trackers this one waits on", Tag: "trackers", Response: []tracker.Dependency{}},
+	{Method: "POST", Path: "/trackers/{trackerID}/dependencies", Summary: "Add a dependency", Tag: "trackers", Request: tracker.DependencyInput{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/trackers/{trackerID}/dependencies/{dependsOnID}", Summary: "Remove a dependency", Tag: "trackers"},
+
+	{Method: "DELETE", Path: "/comments/{commentID}", Summary: "Delete a comment", Tag: "activity"},
+
+	{Method: "GET", Path: "/entries", Summary: "List the user's entries", Tag: "entries", Response: []entry.Entry{}},
+	{Method: "DELETE", Path: "/entries/{entryID}", Summary: "Delete an entry", Tag: "entries"},
+	{Method: "PATCH", Path: "/entries/{entryID}", Summary: "Edit an entry", Tag: "entries", Request: entry.Input{}},
+
+	{Method: "GET", Path: "/notifications/generate", Summary: "Trackers with their next due dates", Tag: "notifications", Response: []tracker.LatestEntry{}},
+	{Method: "POST", Path: "/tokens", Summary: "Register a push token", Tag: "notifications", Request: server.PushTokenInput{}, Status: http.StatusOK},
+
+	{Method: "GET", Path: "/timer-profiles", Summary: "List timer profiles", Tag: "timer", Response: []timer.Profile{}},
+	{Method: "POST", Path: "/timer-profiles", Summary: "Create a timer profile", Tag: "timer", Request: timer.ProfileInput{}, Response: timer.Profile{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/timer-profiles/{profileID}", Summary: "Edit a timer profile", Tag: "timer", Request: timer.ProfileInput{}},
+	{Method: "DELETE", Path: "/timer-profiles/{profileID}", Summary: "Delete a timer profile", Tag: "timer"},
+
+	{Method: "GET", Path: "/gym/workouts", Summary: "List workouts", Tag: "gym", Response: []gym.Workout{}},
+	{Method: "POST", Path: "/gym/workouts", Summary: "Start an empty workout", Tag: "gym", Response: gym.Workout{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/gym/workouts/{workoutID}", Summary: "Edit a workout", Tag: "gym", Request: gym.WorkoutInput{}},
+	{Method: "DELETE", Path: "/gym/workouts/{workoutID}", Summary: "Delete a workout", Tag: "gym"},
+	{Method: "POST", Path: "/gym/workouts/{workoutID}/complete", Summary: "Complete a workout", Tag: "gym", Response: gym.CompletedWorkout{}},
+	{Method: "POST", Path: "/gym/workouts/{workoutID}/sets", Summary: "Add a set", Tag: "gym", Request: gym.SetInput{}, Response: gym.Set{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/gym/sets/{setID}", Summary: "Edit a set", Tag: "gym", Request: gym.SetInput{}},
+	{Method: "POST", Path: "/gym/sets/reorder", Summary: "Reorder sets", Tag: "gym", Request: gym.ReorderSetInput{}},
+	{Method: "DELETE", Path: "/gym/sets/{setID}", Summary: "Delete a set", Tag: "gym"},
+	{Method: "GET", Path: "/gym/favourites", Summary: "Favourite exercises", Tag: "gym", Response: server.Favourites{}},
+	{Method: "POST", Path: "/gym/favourites", Summary: "Toggle a favourite exercise", Tag: "gym", Request: server.FavouriteInput{}, Response: server.Favourites{}},
+	{Method: "GET", Path: "/gym/routines", Summary: "List routines", Tag: "gym", Response: []gym.Routine{}},
+	{Method: "POST", Path: "/gym/routines", Summary: "Create a routine", Tag: "gym", Request: gym.RoutineInput{}, Response: gym.Routine{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/gym/routines/{routineID}", Summary: "Edit a routine", Tag: "gym", Request: gym.RoutineInput{}},
+	{Method: "POST", Path: "/gym/routines/reorder", Summary: "Reorder routines", Tag: "gym", Request: gym.ReorderRoutineInput{}},
+	{Method: "DELETE", Path: "/gym/routines/{routineID}", Summary: "Delete a routine", Tag: "gym"},
+	{Method: "POST", Path: "/gym/routines/{routineID}/exercises", Summary: "Add an exercise to a routine", Tag: "gym", Request: gym.RoutineExerciseInput{}, Response: gym.RoutineExercise{}, Status: http.StatusCreated},
+	{Method: "PATCH", Path: "/gym/routines/{routineID}/exercises/{exerciseID}", Summary: "Edit a routine exercise", Tag: "gym", Request: gym.RoutineExerciseInput{}},
+	{Method: "DELETE", Path: "/gym/routines/{routineID}/exercises/{exerciseID}", Summary: "Remove a routine exercise", Tag: "gym"},
+	{Method: "POST", Path: "/gym/routines/{routineID}/exercises/reorder", Summary: "Reorder routine exercises", Tag: "gym", Request: gym.ReorderRoutineExerciseInput{}},
+	{Method: "POST", Path: "/gym/routines/{routineID}/start", Summary: "Start a workout from a routine", Tag: "gym", Response: gym.Workout{}, Status: http.StatusCreated},
+
+	{Method: "GET", Path: "/gym/stats/summary", Summary: "Workout summary", Tag: "gym", Response: gym.WorkoutSummary{}},
+	{Method: "GET", Path: "/gym/stats/calendar", Summary: "Workout calendar", Tag: "gym", Response: []gym.WorkoutCalendarEntry{}},
+	{Method: "GET", Path: "/gym/stats/muscles", Summary: "Sets to failure per muscle", Tag: "gym", Response: []gym.ExerciseFailureStats{},
+		Query: []openapi.Param{{Name: "weeks", Type: "integer"}}},
+	{Method: "GET", Path: "/gym/stats/exercises", Summary: "Exercises the user has done", Tag: "gym", Response: []gym.UserExercise{}},
+	{Method: "GET", Path: "/gym/stats/exercises/{exerciseID}", Summary: "History of one exercise", Tag: "gym", Response: []gym.ExerciseSetStats{}},
+
+	{Method: "GET", Path: "/market/prices", Summary: "List market prices", Tag: "market", Response: []market.MarketPrice{},
+		Query: []openapi.Param{{Name: "category"}, {Name: "item"}}},
+	{Method: "GET", Path: "/market/prices/{priceID}", Summary: "Get a market price", Tag: "market", Response: market.MarketPrice{}},
+	{Method: "POST", Path: "/market/prices", Summary: "Log a market price", Tag: "market", Request: market.Input{}, Response: market.UpsertResult{}},
+	{Method: "PATCH", Path: "/market/prices/{priceID}", Summary: "Edit a market price", Tag: "market", Request: market.Input{}},
+	{Method: "DELETE", Path: "/market/prices/{priceID}", Summary: "Delete a market price", Tag: "market"},
+	{Method: "GET", Path: "/market/insights", Summary: "Price insights", Tag: "market", Response: []market.MarketInsight{},
+		Query: []openapi.Param{{Name: "category"}}},
+}
+
+var openAPISpec = openapi.Spec{
+	Title:   "Cubby API",
+	Version: "1.0.0",
+	Error:   response.ErrorResponse{},
+	Auth: map[string]openapi.SecurityScheme{
+		"session":     {Type: "apiKey", In: "cookie", Name: "stytch_session_jwt"},
+		"accessToken": {Type: "http", Scheme: "bearer"},
+	},
+	Bare:   []string{"server", "response"},
+	Routes: apiRoutes,
+}
+
+// openAPIDocument is built once, on the first request for it.
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	return json.Marshal(openapi.Build(openAPISpec))
+})
+
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := openAPIDocument()
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		logging.Error(r.Context(), "write openapi document error", "error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/zachczx/cubby/api/internal/openapi"
+	"github.com/zachczx/cubby/api/internal/ratelimit"
+	"github.com/zachczx/cubby/api/internal/server"
+)
+
+func TestOpenAPIMatchesMux(t *testing.T) {
+	mux := NewHTTPHandler(&server.Service{}, &RateLimiter{store: ratelimit.NewMemory()}).(*routeMux)
+
+	documented := map[string]bool{}
+	for _, r := range apiRoutes {
+		key := r.Method + " " + r.Path
+		if documented[key] {
+			t.Errorf("%s is documented twice", key)
+		}
+		documented[key] = true
+	}
+
+	registered := map[string]bool{}
+	for _, p := range mux.patterns {
+		method, path, ok := strings.Cut(p, " ")
+		if !ok {
+			// Registered without a method: any documented method for the path covers it.
+			path, method = p, ""
+		}
+
+		found := false
+		for _, r := range apiRoutes {
+			if r.Path == path && (method == "" || r.Method == method) {
+				registered[r.Method+" "+r.Path] = true
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("%s is registered but not in apiRoutes", p)
+		}
+	}
+
+	for _, r := range apiRoutes {
+		key := r.Method + " " + r.Path
+		if !registered[key] {
+			t.Errorf("%s is in apiRoutes but not registered", key)
+			continue
+		}
+
+		// Fill in the wildcards and check the mux sends the request to the route, not a more specific one.
+		url := pathWildcard.ReplaceAllString(strings.TrimSuffix(r.Path, "{$}"), "x")
+		req := httptest.NewRequest(r.Method, url, nil)
+		if _, pattern := mux.Handler(req); pattern != key && pattern != r.Path {
+			t.Errorf("%s %s is served by %q", r.Method, url, pattern)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := openapi.Build(openAPISpec)
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every $ref must point at a component.
+	for _, ref := range refPattern.FindAllStringSubmatch(string(body), -1) {
+		if _, ok := doc.Components.Schemas[ref[1]]; !ok {
+			t.Errorf("$ref to missing schema %s", ref[1])
+		}
+	}
+
+	ids := map[string]string{}
+	for path, item := range doc.Paths {
+		for _, op := range []*openapi.Operation{item.Get, item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+			if other, ok := ids[op.OperationID]; ok {
+				t.Errorf("operationId %s used by %s and %s", op.OperationID, other, path)
+			}
+			ids[op.OperationID] = path
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	OpenAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK || !json.Valid(rec.Body.Bytes()) {
+		t.Fatalf("GET /openapi.json: status %d", rec.Code)
+	}
+}
+
+// TestOpenAPISchemasMatchJSON marshals each documented body type and checks the schema has the same fields, so a
+// type the generator misreads shows up here rather than in the generated client.
+func TestOpenAPISchemasMatchJSON(t *testing.T) {
+	doc := openapi.Build(openAPISpec)
+
+	for _, r := range apiRoutes {
+		for _, v := range []any{r.Request, r.Response} {
+			typ := reflect.TypeOf(v)
+			if typ == nil {
+				continue
+			}
+			if typ.Kind() == reflect.Slice {
+				typ = typ.Elem()
+			}
+			if typ.Kind() != reflect.Struct || typ.Name() == "" || typ.PkgPath() == "github.com/google/uuid" {
+				continue
+			}
+
+			schema := componentFor(doc, typ)
+			if schema == nil {
+				t.Errorf("%s %s: no component for %s", r.Method, r.Path, typ)
+				continue
+			}
+
+			b, err := json.Marshal(reflect.New(typ).Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(b, &fields); err != nil {
+				t.Fatalf("%s: %v", typ, err)
+			}
+
+			for name := range fields {
+				if _, ok := schema.Properties[name]; !ok {
+					t.Errorf("%s: %q is in the JSON but not the schema", typ, name)
+				}
+			}
+
+			for _, name := range schema.Required {
+				if _, ok := fields[name]; !ok {
+					t.Errorf("%s: %q is required but missing from the zero value's JSON", typ, name)
+				}
+			}
+		}
+	}
+}
+
+var (
+	pathWildcard = regexp.MustCompile(`\{[A-Za-z0-9_]+(\.\.\.)?\}`)
+	refPattern   = regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`)
+)
+
+// componentFor looks the type up under its package-prefixed name, then its bare one.
+func componentFor(doc *openapi.Document, typ reflect.Type) *openapi.Schema {
+	pkg := typ.PkgPath()[strings.LastIndex(typ.PkgPath(), "/")+1:]
+
+	if s, ok := doc.Components.Schemas[strings.ToUpper(pkg[:1])+pkg[1:]+typ.Name()]; ok {
+		return s
+	}
+
+	return doc.Components.Schemas[typ.Name()]
+}
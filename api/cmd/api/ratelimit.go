@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/ratelimit"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+// maxLimitedBody matches what the auth handlers accept, so peeking at the body can't be used to send more.
+const maxLimitedBody = 10240
+
+// RateLimiter throttles the unauthenticated auth endpoints: sending links and codes (which costs us and spams the
+// recipient) and verifying codes (which can be brute forced).
+type RateLimiter struct {
+	store          ratelimit.Store
+	trustForwarded bool
+	sendIP         ratelimit.Limit
+	email          ratelimit.Limit
+	verifyIP       ratelimit.Limit
+	verify         ratelimit.Limit
+}
+
+// limitRule counts requests against the key it extracts. An empty key (say, no email in the form) skips the rule
+// and leaves the handler to reject the request.
+type limitRule struct {
+	name  string
+	limit ratelimit.Limit
+	key   func(w http.ResponseWriter, r *http.Request) string
+}
+
+// NewRateLimiter uses the in-memory store unless the store is "postgres", which is needed once the API runs as more
+// than one replica. TrustForwarded makes the client IP come from X-Forwarded-For and should only be set behind a
+// proxy that overwrites that header.
+func NewRateLimiter(db *sqlx.DB, c config.RateLimit) (*RateLimiter, error) {
+	rl := &RateLimiter{
+		trustForwarded: c.TrustForwarded,
+		sendIP:         c.AuthIP,
+		email:          c.AuthEmail,
+		verifyIP:       c.VerifyIP,
+		verify:         c.OTPVerify,
+	}
+
+	switch c.Store {
+	case "", "memory":
+		rl.store = ratelimit.NewMemory()
+	case "postgres":
+		rl.store = ratelimit.NewPostgres(db)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", c.Store)
+	}
+
+	return rl, nil
+}
+
+// SendLimit guards endpoints that email a link or code, per client IP and per recipient.
+func (rl *RateLimiter) SendLimit(next http.HandlerFunc) http.HandlerFunc {
+	return rl.limit(next,
+		limitRule{name: "auth-ip", limit: rl.sendIP, key: rl.clientIP},
+		limitRule{name: "auth-email", limit: rl.email, key: formEmail},
+	)
+}
+
+// VerifyLimit guards code verification, per client IP and per OTP method from that IP so one code can't be guessed
+// at. It has its own budgets so failed guesses don't use up sending, and the method is never locked for everyone: a
+// Stytch method ID stays the same for an email, so anyone sending bad codes could otherwise lock its owner out.
+func (rl *RateLimiter) VerifyLimit(next http.HandlerFunc) http.HandlerFunc {
+	return rl.limit(next,
+		limitRule{name: "verify-ip", limit: rl.verifyIP, key: rl.clientIP},
+		limitRule{name: "otp-verify", limit: rl.verify, key: rl.otpAttempt},
+	)
+}
+
+func (rl *RateLimiter) limit(next http.HandlerFunc, rules ...limitRule) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, rule := range rules {
+			key := rule.key(w, r)
+			if key == "" {
+				continue
+			}
+
+			res, err := rl.store.Allow(r.Context(), rule.name+":"+key, rule.limit)
+			if err != nil {
+				// Failing open keeps sign-in working through a database blip; the provider has its own limits.
+				logging.Error(r.Context(), "rate limit check failed", "rule", rule.name, "error", err)
+				continue
+			}
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				response.RespondWithError(w, http.StatusTooManyRequests, "too many requests, try again later")
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func (rl *RateLimiter) clientIP(_ http.ResponseWriter, r *http.Request) string {
+	if rl.trustForwarded {
+		// The nearest proxy appends the address it saw, so the last entry is the only one a client can't forge.
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			parts := strings.Split(xff, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// formEmail parses the form early; the handler's own ParseForm then reuses the result.
+func formEmail(w http.ResponseWriter, r *http.Request) string {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLimitedBody)
+
+	if err := r.ParseForm(); err != nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(r.Form.Get("email")))
+}
+
+// otpAttempt keys guesses at one OTP method by the client making them.
+func (rl *RateLimiter) otpAttempt(w http.ResponseWriter, r *http.Request) string {
+	methodID := otpMethodID(w, r)
+	if methodID == "" {
+		return ""
+	}
+
+	return methodID + ":" + rl.clientIP(w, r)
+}
+
+// otpMethodID reads the JSON body and puts it back for the handler to decode.
+func otpMethodID(w http.ResponseWriter, r *http.Request) string {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLimitedBody))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var input struct {
+		MethodID string `json:"methodId"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ""
+	}
+
+	return input.MethodID
+}
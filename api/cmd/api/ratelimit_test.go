@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/ratelimit"
+)
+
+func TestVerifyLimitIsolation(t *testing.T) {
+	one := ratelimit.Limit{Requests: 1, Window: time.Hour}
+	many := ratelimit.Limit{Requests: 100, Window: time.Hour}
+
+	rl, err := NewRateLimiter(nil, config.RateLimit{AuthIP: one, AuthEmail: many, VerifyIP: many, OTPVerify: one})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	verify, send := rl.VerifyLimit(ok), rl.SendLimit(ok)
+
+	do := func(h http.HandlerFunc, ip, body, contentType string) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.RemoteAddr = ip + ":1234"
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w.Code
+	}
+	guess := func(ip string) int {
+		return do(verify, ip, `{"methodId":"email-victim","code":"000000"}`, "application/json")
+	}
+
+	if code := guess("192.0.2.1"); code != http.StatusOK {
+		t.Fatalf("first guess = %d, want 200", code)
+	}
+	if code := guess("192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("second guess from the same IP = %d, want 429", code)
+	}
+
+	// The guesses above must not lock the method for its owner or spend the sending budget.
+	if code := guess("198.51.100.7"); code != http.StatusOK {
+		t.Fatalf("guess from another IP = %d, want 200", code)
+	}
+	if code := do(send, "192.0.2.1", "email=a@example.com", "application/x-www-form-urlencoded"); code != http.StatusOK {
+		t.Fatalf("send after guesses = %d, want 200", code)
+	}
+}
@@ -11,15 +11,62 @@ import (
 	"github.com/zachczx/cubby/api/internal/server"
 )
 
-func NewHTTPHandler(s *server.Service) http.Handler {
-	mux := http.NewServeMux()
+// routeMux records the patterns registered on it so the OpenAPI test can compare them with apiRoutes, caps request
+// bodies per route, and reports the matched pattern to the access log.
+type routeMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *routeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.Handle(pattern, http.HandlerFunc(handler))
+}
+
+func (m *routeMux) Handle(pattern string, handler http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.Handle(pattern, limitBody(pattern, handler))
+}
+
+// ServeHTTP runs the mux directly on r because that's the request the mux sets Pattern on.
+func (m *routeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.ServeMux.ServeHTTP(w, r)
+
+	if info := logging.RequestInfoFrom(r.Context()); info != nil {
+		info.Route = r.Pattern
+	}
+}
+
+const maxBodyBytes = 1 << 20
+
+// uncappedBodies are routes whose handlers set a larger MaxBytesReader themselves, which an outer default cap would
+// undercut.
+var uncappedBodies = []string{"POST /import"}
+
+// limitBody caps every request body at maxBodyBytes so a client can't stream an unbounded JSON document into a
+// decoder. Reading past the cap fails with *http.MaxBytesError, which response.WriteError turns into a 413.
+func limitBody(pattern string, next http.Handler) http.Handler {
+	if slices.Contains(uncappedBodies, pattern) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func NewHTTPHandler(s *server.Service, rl *RateLimiter) http.Handler {
+	mux := &routeMux{ServeMux: http.NewServeMux()}
 
 	mux.HandleFunc("GET /{$}", Index)
 	mux.HandleFunc("GET /health", Healthcheck)
-	mux.HandleFunc("/magic-link", s.SendMagicLinkHandler)
+	mux.HandleFunc("GET /healthz", s.LivenessHandler)
+	mux.HandleFunc("GET /readyz", s.ReadinessHandler)
+	mux.HandleFunc("GET /openapi.json", OpenAPIHandler)
+	mux.HandleFunc("/magic-link", rl.SendLimit(s.SendMagicLinkHandler))
 	mux.HandleFunc("/authenticate", s.MagicLinkHandler)
-	mux.HandleFunc("/otp/send", s.SendOTPHandler)
-	mux.HandleFunc("/otp/verify", s.VerifyOTPHandler)
+	mux.HandleFunc("/otp/send", rl.SendLimit(s.SendOTPHandler))
+	mux.HandleFunc("/otp/verify", rl.VerifyLimit(s.VerifyOTPHandler))
 	mux.Handle("POST /logout", http.HandlerFunc(s.Logout))
 
 	mux.HandleFunc("GET /check", s.CheckHandler)
@@ -29,28 +76,76 @@ func NewHTTPHandler(s *server.Service) http.Handler {
 	mux.HandleFunc("PATCH /users/me/sound", s.RequireAuthentication(s.UpdateSoundModeHandler))
 	mux.HandleFunc("PATCH /users/me/task-lookahead", s.RequireAuthentication(s.ChangeTaskLookaheadDaysHandler))
 	mux.HandleFunc("PATCH /users/me/character", s.RequireAuthentication(s.ChangePreferredCharacterHandler))
-
+	mux.HandleFunc("PATCH /users/me/active-family", s.RequireAuthentication(s.SetActiveFamilyHandler))
+	mux.HandleFunc("GET /users/me/calendar", s.RequireAuthentication(s.GetCalendarTokenHandler))
+	mux.HandleFunc("POST /users/me/calendar", s.RequireAuthentication(s.RotateCalendarTokenHandler))
+	mux.HandleFunc("DELETE /users/me/calendar", s.RequireAuthentication(s.RevokeCalendarTokenHandler))
+	mux.HandleFunc("DELETE /users/me", s.RequireAuthentication(s.DeleteAccountHandler))
+	mux.HandleFunc("GET /users/me/deletion", s.RequireAuthentication(s.GetAccountDeletionHandler))
+	mux.HandleFunc("DELETE /users/me/deletion", s.RequireAuthentication(s.CancelAccountDeletionHandler))
+	mux.HandleFunc("GET /users/me/export", s.RequireAuthentication(s.PersonalExportHandler))
+	mux.HandleFunc("GET /users/me/tokens", s.RequireAuthentication(s.GetAccessTokensHandler))
+	mux.HandleFunc("POST /users/me/tokens", s.RequireAuthentication(s.CreateAccessTokenHandler))
+	mux.HandleFunc("DELETE /users/me/tokens/{tokenID}", s.RequireAuthentication(s.RevokeAccessTokenHandler))
+
+	mux.HandleFunc("GET /calendar/{token}", s.CalendarFeedHandler)
+
+	mux.HandleFunc("POST /families", s.RequireAuthentication(s.CreateFamilyHandler))
 	mux.HandleFunc("GET /families/invites", s.RequireAuthentication(s.GetFamilyInvitesHandler))
-	mux.HandleFunc("GET /families/invites/{inviteID}", s.RequireAuthentication(s.GetFamilyInviteHandler))
 	mux.HandleFunc("POST /families/invites", s.RequireAuthentication(s.CreateFamilyInviteHandler))
+	// Kept outside /families/ so it can't clash with GET /families/{familyID}/... routes.
+	mux.HandleFunc("GET /invites/{inviteID}", s.RequireAuthentication(s.GetFamilyInviteHandler))
 	mux.HandleFunc("POST /families/{familyID}/leave", s.RequireAuthentication(s.LeaveFamilyHandler))
 	mux.HandleFunc("POST /families/invites/{inviteID}/accept", s.RequireAuthentication(s.AcceptFamilyInviteHandler))
 	mux.HandleFunc("POST /families/invites/{inviteID}/decline", s.RequireAuthentication(s.DeclineFamilyInviteHandler))
+	mux.HandleFunc("POST /families/invites/{inviteID}/resend", s.RequireAuthentication(s.ResendFamilyInviteHandler))
+	mux.HandleFunc("DELETE /families/invites/{inviteID}", s.RequireAuthentication(s.RevokeFamilyInviteHandler))
+	mux.HandleFunc("GET /families/{familyID}/invites", s.RequireAuthentication(s.GetSentInvitesHandler))
+	mux.HandleFunc("GET /families/invite-links", s.RequireAuthentication(s.GetInviteLinksHandler))
+	mux.HandleFunc("POST /families/invite-links", s.RequireAuthentication(s.CreateInviteLinkHandler))
+	mux.HandleFunc("DELETE /families/invite-links/{linkID}", s.RequireAuthentication(s.DeleteInviteLinkHandler))
+	mux.HandleFunc("GET /invite-links/{token}", s.GetInviteLinkHandler)
+	mux.HandleFunc("POST /invite-links/{token}/accept", s.RequireAuthentication(s.AcceptInviteLinkHandler))
+	mux.HandleFunc("GET /families/{familyID}/activity", s.RequireAuthentication(s.GetFamilyActivityHandler))
+	mux.HandleFunc("PATCH /families/{familyID}", s.RequireAuthentication(s.UpdateFamilyHandler))
+	mux.HandleFunc("POST /families/{familyID}/transfer", s.RequireAuthentication(s.TransferFamilyHandler))
+	mux.HandleFunc("POST /families/{familyID}/deletion", s.RequireAuthentication(s.RequestFamilyDeletionHandler))
+	mux.HandleFunc("DELETE /families/{familyID}", s.RequireAuthentication(s.DeleteFamilyHandler))
+	mux.HandleFunc("GET /families/{familyID}/webhooks", s.RequireAuthentication(s.GetWebhooksHandler))
+	mux.HandleFunc("POST /families/{familyID}/webhooks", s.RequireAuthentication(s.CreateWebhookHandler))
+	mux.HandleFunc("PATCH /families/{familyID}/webhooks/{webhookID}", s.RequireAuthentication(s.EditWebhookHandler))
+	mux.HandleFunc("DELETE /families/{familyID}/webhooks/{webhookID}", s.RequireAuthentication(s.DeleteWebhookHandler))
+	mux.HandleFunc("GET /families/{familyID}/webhooks/{webhookID}/deliveries", s.RequireAuthentication(s.GetWebhookDeliveriesHandler))
 	mux.HandleFunc("DELETE /families/{familyID}/{memberID}", s.RequireAuthentication(s.DeleteFamilyMemberHandler))
 
 	mux.HandleFunc("GET /vacations", s.RequireAuthentication(s.GetVacationsHandler))
 	mux.HandleFunc("POST /vacations", s.RequireAuthentication(s.CreateVacationHandler))
 	mux.HandleFunc("DELETE /vacations/{vacationID}", s.RequireAuthentication(s.DeleteVacationHandler))
 
+	mux.HandleFunc("GET /export", s.RequireAuthentication(s.ExportHandler))
+	mux.HandleFunc("POST /import", s.RequireAuthentication(s.ImportHandler))
+
 	mux.HandleFunc("GET /trackers", s.RequireAuthentication(s.GetAllHandler))
 	mux.HandleFunc("GET /trackers/{trackerID}", s.RequireAuthentication(s.GetHandler))
 	mux.HandleFunc("POST /trackers", s.RequireAuthentication(s.NewHandler))
 	mux.HandleFunc("POST /trackers/{trackerID}/entries", s.RequireAuthentication(s.CreateEntryHandler))
+	mux.HandleFunc("POST /trackers/{trackerID}/entries/bulk", s.RequireAuthentication(s.CreateBulkEntriesHandler))
 	mux.HandleFunc("PATCH /trackers/{trackerID}", s.RequireAuthentication(s.EditHandler))
 	mux.HandleFunc("DELETE /trackers/{trackerID}", s.RequireAuthentication(s.DeleteHandler))
 	mux.HandleFunc("PATCH /trackers/{trackerID}/pinned", s.RequireAuthentication(s.TogglePinHandler))
 	mux.HandleFunc("PATCH /trackers/{trackerID}/show", s.RequireAuthentication(s.ToggleShowHandler))
 	mux.HandleFunc("POST /trackers/{trackerID}/toggle-mute", s.RequireAuthentication(s.ToggleMuteHandler))
+	mux.HandleFunc("GET /trackers/{trackerID}/checklist", s.RequireAuthentication(s.GetChecklistHandler))
+	mux.HandleFunc("POST /trackers/{trackerID}/checklist", s.RequireAuthentication(s.AddChecklistItemHandler))
+	mux.HandleFunc("PATCH /trackers/{trackerID}/checklist/{itemID}", s.RequireAuthentication(s.EditChecklistItemHandler))
+	mux.HandleFunc("DELETE /trackers/{trackerID}/checklist/{itemID}", s.RequireAuthentication(s.RemoveChecklistItemHandler))
+	mux.HandleFunc("GET /trackers/{trackerID}/comments", s.RequireAuthentication(s.GetCommentsHandler))
+	mux.HandleFunc("POST /trackers/{trackerID}/comments", s.RequireAuthentication(s.NewCommentHandler))
+	mux.HandleFunc("GET /trackers/{trackerID}/dependencies", s.RequireAuthentication(s.GetDependenciesHandler))
+	mux.HandleFunc("POST /trackers/{trackerID}/dependencies", s.RequireAuthentication(s.AddDependencyHandler))
+	mux.HandleFunc("DELETE /trackers/{trackerID}/dependencies/{dependsOnID}", s.RequireAuthentication(s.RemoveDependencyHandler))
+
+	mux.HandleFunc("DELETE /comments/{commentID}", s.RequireAuthentication(s.DeleteCommentHandler))
 
 	mux.HandleFunc("GET /entries", s.RequireAuthentication(s.GetAllEntriesHandler))
 	mux.HandleFunc("DELETE /entries/{entryID}", s.RequireAuthentication(s.DeleteEntryHandler))
@@ -68,6 +163,7 @@ func NewHTTPHandler(s *server.Service) http.Handler {
 	mux.HandleFunc("POST /gym/workouts", s.RequireAuthentication(s.NewWorkoutHandler))
 	mux.HandleFunc("PATCH /gym/workouts/{workoutID}", s.RequireAuthentication(s.EditWorkoutHandler))
 	mux.HandleFunc("DELETE /gym/workouts/{workoutID}", s.RequireAuthentication(s.DeleteWorkoutHandler))
+	mux.HandleFunc("POST /gym/workouts/{workoutID}/complete", s.RequireAuthentication(s.CompleteWorkoutHandler))
 	mux.HandleFunc("POST /gym/workouts/{workoutID}/sets", s.RequireAuthentication(s.NewSetHandler))
 	mux.HandleFunc("PATCH /gym/sets/{setID}", s.RequireAuthentication(s.EditSetHandler))
 	mux.HandleFunc("POST /gym/sets/reorder", s.RequireAuthentication(s.ReorderSetHandler))
@@ -121,7 +217,7 @@ func CORSMiddleware(s *server.Service, next http.Handler) http.Handler {
 		}
 
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Type, Retry-After")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 
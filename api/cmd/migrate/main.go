@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/migration"
+)
+
+const usage = `usage: migrate [flags] <command>
+
+  up             apply every pending migration
+  down [n]       roll back the last n migrations (default 1)
+  status         list migrations and whether they are applied
+  create <name>  add an empty up/down pair to ` + migration.Dir
+
+func main() {
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	args := flag.Args()
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if flags.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// create only touches files, so it shouldn't need a database.
+	if args[0] == "create" {
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		up, down, err := migration.NewFiles(migration.Dir, args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println("created", up)
+		fmt.Println("created", down)
+		return
+	}
+
+	if err := cfg.DB.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	db, err := sqlx.Connect("pgx", cfg.DB.URL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		if err := migration.Up(ctx, db); err != nil {
+			log.Fatal(err)
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("down: %q is not a positive number of steps", args[1])
+			}
+		}
+
+		if err := migration.Down(ctx, db, steps); err != nil {
+			log.Fatal(err)
+		}
+
+	case "status":
+		statuses, err := migration.GetStatus(ctx, db)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state += " (file modified since)"
+			}
+			if s.Missing {
+				state += " (not in this build)"
+			}
+
+			fmt.Printf("%04d  %-40s  %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -1,25 +1,33 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"log"
 	"os"
 
-	"github.com/joho/godotenv"
+	"github.com/zachczx/cubby/api/internal/config"
 	"github.com/zachczx/cubby/api/internal/migration"
 )
 
 func main() {
-	var err error
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
 
-	// Try loading .env file, but don't fail if it doesn't exist (e.g. in Docker)
-	if err = godotenv.Load("../.env"); err != nil {
-		if !os.IsNotExist(err) {
-			log.Printf("Warning: Error loading .env file: %v", err)
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if flags.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
 		}
-	} else {
-		fmt.Println("env init: ok")
+		return
+	}
+
+	if err := cfg.DB.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
 	}
 
-	migration.Migrate()
+	migration.Migrate(cfg.DB.URL())
 }
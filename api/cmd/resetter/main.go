@@ -1,32 +1,40 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"log"
 	"os"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/joho/godotenv"
-	"github.com/zachczx/cubby/api/internal/database"
+	"github.com/zachczx/cubby/api/internal/config"
 	"github.com/zachczx/cubby/api/internal/logging"
 	"github.com/zachczx/cubby/api/internal/migration"
 )
 
 func main() {
-	var err error
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
 
-	// Try loading .env file, but don't fail if it doesn't exist (e.g. in Docker)
-	if err = godotenv.Load("../.env"); err != nil {
-		if !os.IsNotExist(err) {
-			log.Printf("Warning: Error loading .env file: %v", err)
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if flags.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
 		}
-	} else {
-		fmt.Println("env init: ok")
+		return
 	}
 
-	logging.Init()
+	if err := cfg.DB.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	logging.Init(cfg.Development())
 
-	db, err := sqlx.Connect("pgx", database.GetConnectionString())
+	db, err := sqlx.Connect("pgx", cfg.DB.URL())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -34,5 +42,7 @@ func main() {
 
 	migration.WipeData(db)
 
-	migration.Create(db)
+	if err := migration.Up(context.Background(), db); err != nil {
+		log.Fatal(err)
+	}
 }
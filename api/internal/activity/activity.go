@@ -0,0 +1,148 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Kind string
+
+const (
+	TrackerCreated    Kind = "tracker.created"
+	TrackerUpdated    Kind = "tracker.updated"
+	TrackerDeleted    Kind = "tracker.deleted"
+	EntryCreated      Kind = "entry.created"
+	EntryBulkCreated  Kind = "entry.bulk_created"
+	EntryDeleted      Kind = "entry.deleted"
+	CommentCreated    Kind = "comment.created"
+	InviteCreated     Kind = "invite.created"
+	InviteLinkCreated Kind = "invite_link.created"
+	InviteAccepted    Kind = "invite.accepted"
+	InviteDeclined    Kind = "invite.declined"
+	InviteRevoked     Kind = "invite.revoked"
+	InviteResent      Kind = "invite.resent"
+	MemberLeft        Kind = "member.left"
+	MemberRemoved     Kind = "member.removed"
+	FamilyRenamed     Kind = "family.renamed"
+	FamilyTransferred Kind = "family.transferred"
+	VacationCreated   Kind = "vacation.created"
+	VacationDeleted   Kind = "vacation.deleted"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+type Activity struct {
+	ID        uuid.UUID       `db:"id"         json:"id"`
+	FamilyID  uuid.UUID       `db:"family_id"  json:"familyId"`
+	ActorID   *uuid.UUID      `db:"actor_id"   json:"actorId"`
+	ActorName *string         `db:"actor_name" json:"actorName"`
+	TrackerID *uuid.UUID      `db:"tracker_id" json:"trackerId"`
+	Kind      Kind            `db:"kind"       json:"kind"`
+	Data      json.RawMessage `db:"data"       json:"data"`
+	CreatedAt time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// Event is what callers record; Data is stored as JSONB and returned verbatim in the feed.
+type Event struct {
+	FamilyID  uuid.UUID
+	ActorID   uuid.UUID
+	TrackerID *uuid.UUID
+	Kind      Kind
+	Data      map[string]any
+}
+
+type Page struct {
+	Items      []Activity `json:"items"`
+	NextCursor *uuid.UUID `json:"nextCursor"`
+}
+
+func Record(ctx context.Context, db *sqlx.DB, e Event) error {
+	data, err := marshalData(e.Data)
+	if err != nil {
+		return err
+	}
+
+	q := `INSERT INTO activities (family_id, actor_id, tracker_id, kind, data)
+			VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := db.ExecContext(ctx, q, e.FamilyID, e.ActorID, e.TrackerID, e.Kind, data); err != nil {
+		return fmt.Errorf("record activity: %w", err)
+	}
+
+	return nil
+}
+
+// RecordForTracker records an event against the family that currently holds the tracker and returns that family.
+func RecordForTracker(ctx context.Context, db *sqlx.DB, actorID uuid.UUID, trackerID uuid.UUID, kind Kind, data map[string]any) (uuid.UUID, error) {
+	d, err := marshalData(data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	q := `INSERT INTO activities (family_id, actor_id, tracker_id, kind, data)
+			SELECT family_id, $2, id, $3, $4 FROM trackers WHERE id = $1
+			RETURNING family_id`
+
+	var familyID uuid.UUID
+	if err := db.GetContext(ctx, &familyID, q, trackerID, actorID, kind, d); err != nil {
+		return uuid.Nil, fmt.Errorf("record tracker activity: %w", err)
+	}
+
+	return familyID, nil
+}
+
+func marshalData(data map[string]any) ([]byte, error) {
+	if data == nil {
+		data = map[string]any{}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal activity data: %w", err)
+	}
+
+	return b, nil
+}
+
+// GetFeed returns a family's activity newest first. IDs are uuidv7, so the previous page's last ID is a stable cursor.
+func GetFeed(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, before *uuid.UUID, limit int) (Page, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	q := `SELECT a.id, a.family_id, a.actor_id, a.tracker_id, a.kind, a.data, a.created_at,
+				COALESCE(u.name, split_part(u.email, '@', 1)) AS actor_name
+			FROM activities a
+			LEFT JOIN users u ON a.actor_id = u.id
+			WHERE a.family_id = $1
+			AND ($2::uuid IS NULL OR a.id < $2)
+			ORDER BY a.id DESC
+			LIMIT $3`
+
+	// Fetch one extra row to know whether another page exists.
+	items := []Activity{}
+	if err := db.SelectContext(ctx, &items, q, familyID, before, limit+1); err != nil {
+		return Page{}, fmt.Errorf("get activity feed: %w", err)
+	}
+
+	page := Page{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+		next := page.Items[limit-1].ID
+		page.NextCursor = &next
+	}
+
+	return page, nil
+}
@@ -0,0 +1,97 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/database"
+)
+
+type Comment struct {
+	ID         uuid.UUID  `db:"id"          json:"id"`
+	TrackerID  uuid.UUID  `db:"tracker_id"  json:"trackerId"`
+	AuthorID   *uuid.UUID `db:"author_id"   json:"authorId"`
+	AuthorName *string    `db:"author_name" json:"authorName"`
+	Body       string     `db:"body"        json:"body"`
+	CreatedAt  time.Time  `db:"created_at"  json:"createdAt"`
+	UpdatedAt  time.Time  `db:"updated_at"  json:"updatedAt"`
+
+	// FamilyID is only filled in by NewComment, for publishing the event.
+	FamilyID uuid.UUID `db:"-" json:"-"`
+}
+
+type CommentInput struct {
+	Body string `json:"body"`
+}
+
+func GetComments(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID) ([]Comment, error) {
+	q := `SELECT c.id, c.tracker_id, c.author_id, c.body, c.created_at, c.updated_at,
+				COALESCE(u.name, split_part(u.email, '@', 1)) AS author_name
+			FROM tracker_comments c
+			JOIN trackers t ON c.tracker_id = t.id
+			LEFT JOIN users u ON c.author_id = u.id
+			WHERE t.id = $1
+			AND (t.owner_id = $2 OR t.family_id IN (` + database.VisibleFamilies("$2") + `))
+			ORDER BY c.created_at DESC`
+
+	comments := []Comment{}
+	if err := db.SelectContext(ctx, &comments, q, trackerID, userID); err != nil {
+		return nil, fmt.Errorf("get comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// NewComment adds a comment to a tracker visible to the user and records it in the family feed.
+func NewComment(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, input CommentInput) (Comment, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Comment{}, fmt.Errorf("new comment begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	q := `INSERT INTO tracker_comments (tracker_id, author_id, body)
+			SELECT id, $2, $3 FROM trackers
+			WHERE id = $1
+			AND (owner_id = $2 OR family_id IN (` + database.VisibleFamilies("$2") + `))
+			RETURNING id, tracker_id, author_id, body, created_at, updated_at`
+
+	var c Comment
+	if err := tx.GetContext(ctx, &c, q, trackerID, userID, input.Body); err != nil {
+		return Comment{}, fmt.Errorf("new comment: %w", err)
+	}
+
+	data, err := marshalData(map[string]any{"commentId": c.ID, "body": c.Body})
+	if err != nil {
+		return Comment{}, err
+	}
+
+	aQ := `INSERT INTO activities (family_id, actor_id, tracker_id, kind, data)
+			SELECT family_id, $2, id, $3, $4 FROM trackers WHERE id = $1
+			RETURNING family_id`
+
+	if err := tx.GetContext(ctx, &c.FamilyID, aQ, trackerID, userID, CommentCreated, data); err != nil {
+		return Comment{}, fmt.Errorf("new comment activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Comment{}, fmt.Errorf("new comment commit tx: %w", err)
+	}
+
+	return c, nil
+}
+
+func DeleteComment(ctx context.Context, db *sqlx.DB, userID uuid.UUID, commentID uuid.UUID) error {
+	q := `DELETE FROM tracker_comments WHERE id = $1 AND author_id = $2`
+
+	res, err := db.ExecContext(ctx, q, commentID, userID)
+	if err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+
+	return apperr.Affected(res, "comment")
+}
@@ -0,0 +1,41 @@
+package activity
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs activity feed and comment queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) DeleteComment(ctx context.Context, userID uuid.UUID, commentID uuid.UUID) error {
+	return DeleteComment(ctx, r.db, userID, commentID)
+}
+
+func (r *Repository) GetComments(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID) ([]Comment, error) {
+	return GetComments(ctx, r.db, userID, trackerID)
+}
+
+func (r *Repository) GetFeed(ctx context.Context, familyID uuid.UUID, before *uuid.UUID, limit int) (Page, error) {
+	return GetFeed(ctx, r.db, familyID, before, limit)
+}
+
+func (r *Repository) NewComment(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, input CommentInput) (Comment, error) {
+	return NewComment(ctx, r.db, userID, trackerID, input)
+}
+
+func (r *Repository) Record(ctx context.Context, e Event) error {
+	return Record(ctx, r.db, e)
+}
+
+func (r *Repository) RecordForTracker(ctx context.Context, actorID uuid.UUID, trackerID uuid.UUID, kind Kind, data map[string]any) (uuid.UUID, error) {
+	return RecordForTracker(ctx, r.db, actorID, trackerID, kind, data)
+}
@@ -0,0 +1,210 @@
+// Package apitoken manages personal access tokens, which let scripts and automations call the API with a Bearer
+// header instead of a browser session. Each token carries the scopes its owner granted and nothing more.
+package apitoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/secret"
+)
+
+// Prefix marks a bearer value as a personal access token and makes leaked tokens easy to spot in code and logs.
+const Prefix = "cubby_pat_"
+
+const (
+	MaxPerUser     = 20
+	MaxExpiryDays  = 365
+	lastUsedWindow = time.Minute
+)
+
+const (
+	TrackersRead   = "trackers:read"
+	TrackersWrite  = "trackers:write"
+	EntriesRead    = "entries:read"
+	EntriesWrite   = "entries:write"
+	VacationsRead  = "vacations:read"
+	VacationsWrite = "vacations:write"
+	TimersRead     = "timers:read"
+	TimersWrite    = "timers:write"
+	GymRead        = "gym:read"
+	GymWrite       = "gym:write"
+	MarketRead     = "market:read"
+	MarketWrite    = "market:write"
+	FamiliesRead   = "families:read"
+)
+
+// Scopes lists every scope a token can be granted.
+var Scopes = []string{
+	TrackersRead, TrackersWrite, EntriesRead, EntriesWrite, VacationsRead, VacationsWrite,
+	TimersRead, TimersWrite, GymRead, GymWrite, MarketRead, MarketWrite, FamiliesRead,
+}
+
+var (
+	ErrInvalidToken  = errors.New("invalid or expired access token")
+	ErrTooManyTokens = apperr.Conflict("too many access tokens")
+	ErrUnknownScope  = errors.New("unknown scope")
+	ErrTokenNotFound = apperr.NotFound("access token not found")
+)
+
+type Token struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Hint       string         `json:"hint" db:"hint"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	LastUsedAt *time.Time     `json:"lastUsedAt" db:"last_used_at"`
+	ExpiresAt  *time.Time     `json:"expiresAt" db:"expires_at"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+}
+
+// NewToken is returned once on creation; the secret can't be recovered afterwards.
+type NewToken struct {
+	Token
+	Secret string `json:"token"`
+}
+
+type Request struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+// Principal is who a valid token authenticates as.
+type Principal struct {
+	TokenID uuid.UUID      `db:"id"`
+	UserID  uuid.UUID      `db:"user_id"`
+	Email   string         `db:"email"`
+	Scopes  pq.StringArray `db:"scopes"`
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// ValidateScopes rejects anything not in Scopes and drops duplicates.
+func ValidateScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+
+	for _, s := range scopes {
+		if !slices.Contains(Scopes, s) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, s)
+		}
+
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+
+	return out, nil
+}
+
+func Create(ctx context.Context, db *sqlx.DB, userID uuid.UUID, name string, scopes []string, expiresInDays int) (NewToken, error) {
+	var count int
+
+	if err := db.GetContext(ctx, &count, `SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = $1`, userID); err != nil {
+		return NewToken{}, fmt.Errorf("count access tokens: %w", err)
+	}
+
+	if count >= MaxPerUser {
+		return NewToken{}, ErrTooManyTokens
+	}
+
+	raw, err := secret.New()
+	if err != nil {
+		return NewToken{}, fmt.Errorf("access token: %w", err)
+	}
+
+	value := Prefix + raw
+
+	var expiresAt *time.Time
+	if expiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, expiresInDays)
+		expiresAt = &t
+	}
+
+	q := `INSERT INTO personal_access_tokens (user_id, name, token_hash, hint, scopes, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, name, hint, scopes, last_used_at, expires_at, created_at`
+
+	t := NewToken{Secret: value}
+	if err := db.GetContext(ctx, &t.Token, q, userID, name, secret.Hash(value), value[len(value)-4:], pq.StringArray(scopes), expiresAt); err != nil {
+		return NewToken{}, fmt.Errorf("create access token: %w", err)
+	}
+
+	return t, nil
+}
+
+func List(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Token, error) {
+	q := `SELECT id, name, hint, scopes, last_used_at, expires_at, created_at
+			FROM personal_access_tokens
+			WHERE user_id = $1
+			ORDER BY created_at DESC`
+
+	tokens := []Token{}
+	if err := db.SelectContext(ctx, &tokens, q, userID); err != nil {
+		return nil, fmt.Errorf("list access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func Revoke(ctx context.Context, db *sqlx.DB, userID uuid.UUID, tokenID uuid.UUID) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("revoke access token: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revoke access token rows: %w", err)
+	}
+
+	if n == 0 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// Authenticate resolves a bearer value to its owner and records the use. last_used_at is only written once a
+// minute per token so busy automations don't turn every read into a write. Tokens stop working while the account is
+// scheduled for deletion, and work again if the owner signs in and cancels it.
+func Authenticate(ctx context.Context, db *sqlx.DB, value string) (Principal, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var p Principal
+
+	q := `SELECT t.id, t.user_id, u.email, t.scopes
+			FROM personal_access_tokens t
+			JOIN users u ON t.user_id = u.id
+			WHERE t.token_hash = $1 AND (t.expires_at IS NULL OR t.expires_at > NOW())
+			AND NOT EXISTS (SELECT 1 FROM account_deletions d WHERE d.user_id = t.user_id)`
+
+	if err := db.GetContext(ctx, &p, q, secret.Hash(value)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Principal{}, ErrInvalidToken
+		}
+
+		return Principal{}, fmt.Errorf("authenticate access token: %w", err)
+	}
+
+	uQ := `UPDATE personal_access_tokens SET last_used_at = NOW()
+			WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)`
+
+	if _, err := db.ExecContext(ctx, uQ, p.TokenID, time.Now().Add(-lastUsedWindow)); err != nil {
+		return Principal{}, fmt.Errorf("touch access token: %w", err)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,46 @@
+package apitoken_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zachczx/cubby/api/internal/apitoken"
+	"github.com/zachczx/cubby/api/internal/testdb"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestAuthenticateRefusedDuringAccountDeletion(t *testing.T) {
+	db := testdb.New(t)
+
+	userID := testdb.User(t, db)
+	testdb.Family(t, db, userID)
+
+	tok, err := apitoken.Create(t.Context(), db, userID, "script", []string{apitoken.TrackersRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := apitoken.Authenticate(t.Context(), db, tok.Secret); err != nil {
+		t.Fatalf("before deletion: %v", err)
+	}
+
+	if _, err := user.ScheduleAccountDeletion(t.Context(), db, userID, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := apitoken.Authenticate(t.Context(), db, tok.Secret); !errors.Is(err, apitoken.ErrInvalidToken) {
+		t.Fatalf("pending deletion: err = %v, want ErrInvalidToken", err)
+	}
+
+	if err := user.CancelAccountDeletion(t.Context(), db, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := apitoken.Authenticate(t.Context(), db, tok.Secret); err != nil {
+		t.Fatalf("after cancelling: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package apitoken
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs API token queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Authenticate(ctx context.Context, value string) (Principal, error) {
+	return Authenticate(ctx, r.db, value)
+}
+
+func (r *Repository) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresInDays int) (NewToken, error) {
+	return Create(ctx, r.db, userID, name, scopes, expiresInDays)
+}
+
+func (r *Repository) List(ctx context.Context, userID uuid.UUID) ([]Token, error) {
+	return List(ctx, r.db, userID)
+}
+
+func (r *Repository) Revoke(ctx context.Context, userID uuid.UUID, tokenID uuid.UUID) error {
+	return Revoke(ctx, r.db, userID, tokenID)
+}
@@ -0,0 +1,61 @@
+// Package apperr holds the error kinds shared by the domain packages, which response.WriteError turns into HTTP
+// statuses.
+//
+// A row that exists but is hidden from the caller is reported as ErrNotFound, the same as a missing one, so IDs
+// can't be probed. ErrForbidden is for callers who can see a thing but lack the role to change it, like a family
+// member editing a tracker someone else owns.
+package apperr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+	ErrConflict  = errors.New("conflict")
+)
+
+// Error is one of the kinds above with a message meant for the client. errors.Is matches both the Error itself, so
+// it can be a package's sentinel, and its kind.
+type Error struct {
+	kind error
+	msg  string
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+func NotFound(msg string) error {
+	return &Error{kind: ErrNotFound, msg: msg}
+}
+
+func Forbidden(msg string) error {
+	return &Error{kind: ErrForbidden, msg: msg}
+}
+
+func Conflict(msg string) error {
+	return &Error{kind: ErrConflict, msg: msg}
+}
+
+// Affected checks that an UPDATE or DELETE matched at least one row. Mutations filter on the caller in their WHERE
+// clause, so zero rows means the target is gone or isn't theirs; what names it in the error, e.g. "tracker".
+func Affected(res sql.Result, what string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s rows affected: %w", what, err)
+	}
+
+	if n == 0 {
+		return NotFound(what + " not found")
+	}
+
+	return nil
+}
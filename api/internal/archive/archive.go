@@ -0,0 +1,357 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/database"
+)
+
+// Version is bumped whenever the archive layout changes in a way older importers can't read.
+const Version = 1
+
+var ErrUnsupportedVersion = errors.New("unsupported archive version")
+
+// Archive is a portable copy of a user's data. IDs are the exporter's and are only used to link records
+// within the archive; Import assigns fresh IDs.
+type Archive struct {
+	Version       int            `json:"version"`
+	ExportedAt    time.Time      `json:"exportedAt"`
+	Trackers      []Tracker      `json:"trackers"`
+	Entries       []Entry        `json:"entries"`
+	Vacations     []Vacation     `json:"vacations"`
+	TimerProfiles []TimerProfile `json:"timerProfiles"`
+	Gym           Gym            `json:"gym"`
+	MarketPrices  []MarketPrice  `json:"marketPrices"`
+}
+
+type Tracker struct {
+	ID           uuid.UUID       `db:"id"            json:"id"`
+	Name         string          `db:"name"          json:"name"`
+	Display      *string         `db:"display"       json:"display"`
+	Interval     int             `db:"interval"      json:"interval"`
+	IntervalUnit string          `db:"interval_unit" json:"intervalUnit"`
+	Category     *string         `db:"category"      json:"category"`
+	Kind         *string         `db:"kind"          json:"kind"`
+	ActionLabel  *string         `db:"action_label"  json:"actionLabel"`
+	Icon         *string         `db:"icon"          json:"icon"`
+	Pinned       bool            `db:"pinned"        json:"pinned"`
+	Show         bool            `db:"show"          json:"show"`
+	StartDate    *time.Time      `db:"start_date"    json:"startDate"`
+	Cost         *float64        `db:"cost"          json:"cost"`
+	CreatedAt    time.Time       `db:"created_at"    json:"createdAt"`
+	Checklist    []ChecklistItem `db:"-"             json:"checklist"`
+	DependsOn    []uuid.UUID     `db:"-"             json:"dependsOn"`
+}
+
+type ChecklistItem struct {
+	ID        uuid.UUID `db:"id"         json:"id"`
+	TrackerID uuid.UUID `db:"tracker_id" json:"-"`
+	Label     string    `db:"label"      json:"label"`
+	Position  int16     `db:"position"   json:"position"`
+}
+
+type Entry struct {
+	ID             uuid.UUID   `db:"id"            json:"id"`
+	TrackerID      uuid.UUID   `db:"tracker_id"    json:"trackerId"`
+	Interval       int         `db:"interval"      json:"interval"`
+	IntervalUnit   string      `db:"interval_unit" json:"intervalUnit"`
+	PerformedBy    *uuid.UUID  `db:"performed_by"  json:"performedBy"`
+	PerformedAt    time.Time   `db:"performed_at"  json:"performedAt"`
+	Remark         *string     `db:"remark"        json:"remark"`
+	CompletedItems []uuid.UUID `db:"-"             json:"completedItems"`
+}
+
+type Vacation struct {
+	StartDateTime time.Time `db:"start_date_time" json:"startDateTime"`
+	EndDateTime   time.Time `db:"end_date_time"   json:"endDateTime"`
+	Label         *string   `db:"label"           json:"label"`
+}
+
+type TimerProfile struct {
+	Name      string          `db:"name"       json:"name"`
+	Segments  json.RawMessage `db:"segments"   json:"segments"`
+	IsDefault bool            `db:"is_default" json:"isDefault"`
+}
+
+type Gym struct {
+	Workouts   []Workout `json:"workouts"`
+	Routines   []Routine `json:"routines"`
+	Favourites []string  `json:"favourites"`
+}
+
+type Workout struct {
+	ID        uuid.UUID `db:"id"         json:"id"`
+	StartTime time.Time `db:"start_time" json:"startTime"`
+	Notes     *string   `db:"notes"      json:"notes"`
+	Sets      []Set     `db:"-"          json:"sets"`
+}
+
+type Set struct {
+	WorkoutID   uuid.UUID `db:"workout_id"   json:"-"`
+	ExerciseID  string    `db:"exercise_id"  json:"exerciseId"`
+	WeightKg    *float64  `db:"weight_kg"    json:"weightKg"`
+	Reps        *int16    `db:"reps"         json:"reps"`
+	SetType     string    `db:"set_type"     json:"setType"`
+	IsCompleted bool      `db:"is_completed" json:"isCompleted"`
+	Position    int16     `db:"position"     json:"position"`
+}
+
+type Routine struct {
+	ID        uuid.UUID         `db:"id"       json:"id"`
+	Name      string            `db:"name"     json:"name"`
+	Position  int16             `db:"position" json:"position"`
+	Exercises []RoutineExercise `db:"-"        json:"exercises"`
+}
+
+type RoutineExercise struct {
+	RoutineID  uuid.UUID `db:"routine_id"  json:"-"`
+	ExerciseID string    `db:"exercise_id" json:"exerciseId"`
+	Sets       int16     `db:"sets"        json:"sets"`
+	Position   int16     `db:"position"    json:"position"`
+}
+
+type MarketPrice struct {
+	ItemName  string     `db:"item_name"  json:"itemName"`
+	Category  *string    `db:"category"   json:"category"`
+	Country   *string    `db:"country"    json:"country"`
+	Store     *string    `db:"store"      json:"store"`
+	Unit      *string    `db:"unit"       json:"unit"`
+	Quantity  *float64   `db:"quantity"   json:"quantity"`
+	Price     float64    `db:"price"      json:"price"`
+	IsPromo   bool       `db:"is_promo"   json:"isPromo"`
+	Remarks   *string    `db:"remarks"    json:"remarks"`
+	CreatedAt *time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Export gathers everything the user can see: trackers, entries, vacations and prices from every family
+// they belong to, plus their own timer profiles and gym history.
+func Export(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (Archive, error) {
+	a := Archive{
+		Version:       Version,
+		ExportedAt:    time.Now().UTC(),
+		Trackers:      []Tracker{},
+		Entries:       []Entry{},
+		Vacations:     []Vacation{},
+		TimerProfiles: []TimerProfile{},
+		Gym:           Gym{Workouts: []Workout{}, Routines: []Routine{}, Favourites: []string{}},
+		MarketPrices:  []MarketPrice{},
+	}
+
+	var err error
+
+	if a.Trackers, err = exportTrackers(ctx, db, userID); err != nil {
+		return Archive{}, err
+	}
+
+	if a.Entries, err = exportEntries(ctx, db, userID); err != nil {
+		return Archive{}, err
+	}
+
+	vQ := `SELECT start_date_time, end_date_time, label FROM vacations
+			WHERE family_id IN (` + database.VisibleFamilies("$1") + `)
+			ORDER BY start_date_time ASC`
+
+	if err := db.SelectContext(ctx, &a.Vacations, vQ, userID); err != nil {
+		return Archive{}, fmt.Errorf("export vacations: %w", err)
+	}
+
+	pQ := `SELECT name, segments, is_default FROM timer_profiles
+			WHERE user_id = $1
+			ORDER BY created_at ASC`
+
+	if err := db.SelectContext(ctx, &a.TimerProfiles, pQ, userID); err != nil {
+		return Archive{}, fmt.Errorf("export timer profiles: %w", err)
+	}
+
+	if a.Gym, err = exportGym(ctx, db, userID); err != nil {
+		return Archive{}, err
+	}
+
+	mQ := `SELECT item_name, category, country, store, unit, quantity, price, is_promo, remarks, created_at
+			FROM market_prices
+			WHERE family_id IN (` + database.VisibleFamilies("$1") + `)
+			ORDER BY created_at ASC`
+
+	if err := db.SelectContext(ctx, &a.MarketPrices, mQ, userID); err != nil {
+		return Archive{}, fmt.Errorf("export market prices: %w", err)
+	}
+
+	return a, nil
+}
+
+func exportTrackers(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Tracker, error) {
+	q := `SELECT id, name, display, interval, interval_unit, category, kind, action_label, icon,
+				COALESCE(pinned, FALSE) AS pinned, COALESCE(show, TRUE) AS show, start_date, cost, created_at
+			FROM trackers
+			WHERE owner_id = $1 OR family_id IN (` + database.VisibleFamilies("$1") + `)
+			ORDER BY created_at ASC`
+
+	trackers := []Tracker{}
+	if err := db.SelectContext(ctx, &trackers, q, userID); err != nil {
+		return nil, fmt.Errorf("export trackers: %w", err)
+	}
+
+	if len(trackers) == 0 {
+		return trackers, nil
+	}
+
+	ids := make([]uuid.UUID, len(trackers))
+	tMap := make(map[uuid.UUID]int, len(trackers))
+	for i, t := range trackers {
+		ids[i] = t.ID
+		tMap[t.ID] = i
+		trackers[i].Checklist = []ChecklistItem{}
+		trackers[i].DependsOn = []uuid.UUID{}
+	}
+
+	cQ, args, err := sqlx.In(`SELECT id, tracker_id, label, position FROM tracker_checklist_items
+								WHERE tracker_id IN (?)
+								ORDER BY position ASC`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("export checklists in: %w", err)
+	}
+
+	var items []ChecklistItem
+	if err := db.SelectContext(ctx, &items, db.Rebind(cQ), args...); err != nil {
+		return nil, fmt.Errorf("export checklists: %w", err)
+	}
+
+	for _, item := range items {
+		i := tMap[item.TrackerID]
+		trackers[i].Checklist = append(trackers[i].Checklist, item)
+	}
+
+	dQ, args, err := sqlx.In(`SELECT tracker_id, depends_on_id FROM tracker_dependencies WHERE tracker_id IN (?)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("export dependencies in: %w", err)
+	}
+
+	var deps []struct {
+		TrackerID   uuid.UUID `db:"tracker_id"`
+		DependsOnID uuid.UUID `db:"depends_on_id"`
+	}
+	if err := db.SelectContext(ctx, &deps, db.Rebind(dQ), args...); err != nil {
+		return nil, fmt.Errorf("export dependencies: %w", err)
+	}
+
+	for _, d := range deps {
+		i := tMap[d.TrackerID]
+		trackers[i].DependsOn = append(trackers[i].DependsOn, d.DependsOnID)
+	}
+
+	return trackers, nil
+}
+
+func exportEntries(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Entry, error) {
+	q := `SELECT e.id, e.tracker_id, e.interval, e.interval_unit, e.performed_by, e.performed_at, e.remark
+			FROM entries e
+			JOIN trackers t ON e.tracker_id = t.id
+			WHERE t.owner_id = $1 OR t.family_id IN (` + database.VisibleFamilies("$1") + `)
+			ORDER BY e.performed_at ASC`
+
+	entries := []Entry{}
+	if err := db.SelectContext(ctx, &entries, q, userID); err != nil {
+		return nil, fmt.Errorf("export entries: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]uuid.UUID, len(entries))
+	eMap := make(map[uuid.UUID]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+		eMap[e.ID] = i
+		entries[i].CompletedItems = []uuid.UUID{}
+	}
+
+	cQ, args, err := sqlx.In(`SELECT entry_id, item_id FROM entry_checklist_items WHERE entry_id IN (?)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("export entry checklist in: %w", err)
+	}
+
+	var completed []struct {
+		EntryID uuid.UUID `db:"entry_id"`
+		ItemID  uuid.UUID `db:"item_id"`
+	}
+	if err := db.SelectContext(ctx, &completed, db.Rebind(cQ), args...); err != nil {
+		return nil, fmt.Errorf("export entry checklist: %w", err)
+	}
+
+	for _, c := range completed {
+		i := eMap[c.EntryID]
+		entries[i].CompletedItems = append(entries[i].CompletedItems, c.ItemID)
+	}
+
+	return entries, nil
+}
+
+func exportGym(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (Gym, error) {
+	g := Gym{Workouts: []Workout{}, Routines: []Routine{}, Favourites: []string{}}
+
+	wQ := `SELECT id, start_time, notes FROM gym_workouts WHERE user_id = $1 ORDER BY start_time ASC`
+	if err := db.SelectContext(ctx, &g.Workouts, wQ, userID); err != nil {
+		return Gym{}, fmt.Errorf("export workouts: %w", err)
+	}
+
+	sQ := `SELECT gs.workout_id, gs.exercise_id, gs.weight_kg, gs.reps, gs.set_type, gs.is_completed, gs.position
+			FROM gym_sets gs
+			JOIN gym_workouts gw ON gs.workout_id = gw.id
+			WHERE gw.user_id = $1
+			ORDER BY gs.exercise_id, gs.position ASC`
+
+	var sets []Set
+	if err := db.SelectContext(ctx, &sets, sQ, userID); err != nil {
+		return Gym{}, fmt.Errorf("export sets: %w", err)
+	}
+
+	wMap := make(map[uuid.UUID]int, len(g.Workouts))
+	for i, w := range g.Workouts {
+		wMap[w.ID] = i
+		g.Workouts[i].Sets = []Set{}
+	}
+	for _, s := range sets {
+		i := wMap[s.WorkoutID]
+		g.Workouts[i].Sets = append(g.Workouts[i].Sets, s)
+	}
+
+	rQ := `SELECT id, name, position FROM gym_routines WHERE user_id = $1 ORDER BY position ASC, created_at ASC`
+	if err := db.SelectContext(ctx, &g.Routines, rQ, userID); err != nil {
+		return Gym{}, fmt.Errorf("export routines: %w", err)
+	}
+
+	reQ := `SELECT re.routine_id, re.exercise_id, re.sets, re.position
+			FROM gym_routine_exercises re
+			JOIN gym_routines r ON re.routine_id = r.id
+			WHERE r.user_id = $1
+			ORDER BY re.position ASC`
+
+	var exercises []RoutineExercise
+	if err := db.SelectContext(ctx, &exercises, reQ, userID); err != nil {
+		return Gym{}, fmt.Errorf("export routine exercises: %w", err)
+	}
+
+	rMap := make(map[uuid.UUID]int, len(g.Routines))
+	for i, r := range g.Routines {
+		rMap[r.ID] = i
+		g.Routines[i].Exercises = []RoutineExercise{}
+	}
+	for _, e := range exercises {
+		i := rMap[e.RoutineID]
+		g.Routines[i].Exercises = append(g.Routines[i].Exercises, e)
+	}
+
+	fQ := `SELECT exercise_id FROM gym_favourite_exercises WHERE user_id = $1 ORDER BY created_at ASC`
+	if err := db.SelectContext(ctx, &g.Favourites, fQ, userID); err != nil {
+		return Gym{}, fmt.Errorf("export favourites: %w", err)
+	}
+
+	return g, nil
+}
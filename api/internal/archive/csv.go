@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Domains lists the values accepted by WriteCSV, one flat table each.
+var Domains = []string{"trackers", "entries", "vacations", "timer-profiles", "gym-sets", "market-prices"}
+
+var ErrUnknownDomain = errors.New("unknown export domain")
+
+func WriteCSV(w io.Writer, a Archive, domain string) error {
+	cw := csv.NewWriter(w)
+
+	var records [][]string
+
+	switch domain {
+	case "trackers":
+		records = append(records, []string{"id", "name", "display", "interval", "intervalUnit", "category", "kind", "startDate", "cost", "createdAt"})
+		for _, t := range a.Trackers {
+			records = append(records, []string{
+				t.ID.String(), t.Name, str(t.Display), strconv.Itoa(t.Interval), t.IntervalUnit,
+				str(t.Category), str(t.Kind), timePtr(t.StartDate), float(t.Cost), t.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+	case "entries":
+		names := make(map[string]string, len(a.Trackers))
+		for _, t := range a.Trackers {
+			names[t.ID.String()] = t.Name
+		}
+
+		records = append(records, []string{"id", "trackerId", "trackerName", "performedAt", "interval", "intervalUnit", "remark"})
+		for _, e := range a.Entries {
+			records = append(records, []string{
+				e.ID.String(), e.TrackerID.String(), names[e.TrackerID.String()], e.PerformedAt.Format(time.RFC3339),
+				strconv.Itoa(e.Interval), e.IntervalUnit, str(e.Remark),
+			})
+		}
+
+	case "vacations":
+		records = append(records, []string{"startDateTime", "endDateTime", "label"})
+		for _, v := range a.Vacations {
+			records = append(records, []string{v.StartDateTime.Format(time.RFC3339), v.EndDateTime.Format(time.RFC3339), str(v.Label)})
+		}
+
+	case "timer-profiles":
+		records = append(records, []string{"name", "segments", "isDefault"})
+		for _, p := range a.TimerProfiles {
+			records = append(records, []string{p.Name, string(p.Segments), strconv.FormatBool(p.IsDefault)})
+		}
+
+	case "gym-sets":
+		records = append(records, []string{"workoutId", "startTime", "exerciseId", "weightKg", "reps", "setType", "isCompleted", "position"})
+		for _, wo := range a.Gym.Workouts {
+			for _, s := range wo.Sets {
+				reps := ""
+				if s.Reps != nil {
+					reps = strconv.Itoa(int(*s.Reps))
+				}
+
+				records = append(records, []string{
+					wo.ID.String(), wo.StartTime.Format(time.RFC3339), s.ExerciseID, float(s.WeightKg), reps,
+					s.SetType, strconv.FormatBool(s.IsCompleted), strconv.Itoa(int(s.Position)),
+				})
+			}
+		}
+
+	case "market-prices":
+		records = append(records, []string{"itemName", "category", "country", "store", "unit", "quantity", "price", "isPromo", "remarks", "createdAt"})
+		for _, p := range a.MarketPrices {
+			records = append(records, []string{
+				p.ItemName, str(p.Category), str(p.Country), str(p.Store), str(p.Unit), float(p.Quantity),
+				strconv.FormatFloat(p.Price, 'f', -1, 64), strconv.FormatBool(p.IsPromo), str(p.Remarks), timePtr(p.CreatedAt),
+			})
+		}
+
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownDomain, domain)
+	}
+
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("write %s csv: %w", domain, err)
+	}
+
+	return nil
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func float(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func timePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
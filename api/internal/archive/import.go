@@ -0,0 +1,545 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Counts struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+}
+
+type ImportResult struct {
+	DryRun         bool   `json:"dryRun"`
+	Trackers       Counts `json:"trackers"`
+	ChecklistItems Counts `json:"checklistItems"`
+	Entries        Counts `json:"entries"`
+	Vacations      Counts `json:"vacations"`
+	TimerProfiles  Counts `json:"timerProfiles"`
+	Workouts       Counts `json:"workouts"`
+	Routines       Counts `json:"routines"`
+	Favourites     Counts `json:"favourites"`
+	MarketPrices   Counts `json:"marketPrices"`
+}
+
+// idMap translates archive IDs to the IDs assigned on import.
+type idMap map[uuid.UUID]uuid.UUID
+
+// Import restores an archive into familyID on behalf of userID. Records that already exist are skipped, so
+// importing the same archive twice is harmless. Trackers are matched by name and merged into the existing one.
+// With dryRun set the whole import runs and is rolled back, so the counts are an exact preview.
+func Import(ctx context.Context, db *sqlx.DB, userID uuid.UUID, familyID uuid.UUID, a Archive, dryRun bool) (ImportResult, error) {
+	result := ImportResult{DryRun: dryRun}
+
+	if a.Version < 1 || a.Version > Version {
+		return result, fmt.Errorf("%w: %d", ErrUnsupportedVersion, a.Version)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("import begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	trackerIDs, itemIDs, err := importTrackers(ctx, tx, userID, familyID, a.Trackers, &result)
+	if err != nil {
+		return result, err
+	}
+
+	if err := importEntries(ctx, tx, userID, familyID, a.Entries, trackerIDs, itemIDs, &result.Entries); err != nil {
+		return result, err
+	}
+
+	if err := importVacations(ctx, tx, userID, familyID, a.Vacations, &result.Vacations); err != nil {
+		return result, err
+	}
+
+	if err := importTimerProfiles(ctx, tx, userID, a.TimerProfiles, &result.TimerProfiles); err != nil {
+		return result, err
+	}
+
+	if err := importGym(ctx, tx, userID, a.Gym, &result); err != nil {
+		return result, err
+	}
+
+	if err := importMarketPrices(ctx, tx, userID, familyID, a.MarketPrices, &result.MarketPrices); err != nil {
+		return result, err
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("import commit tx: %w", err)
+	}
+
+	return result, nil
+}
+
+func importTrackers(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, familyID uuid.UUID, trackers []Tracker, result *ImportResult) (idMap, idMap, error) {
+	c := &result.Trackers
+	trackerIDs := make(idMap, len(trackers))
+	itemIDs := make(idMap)
+
+	var existing []struct {
+		ID    uuid.UUID `db:"id"`
+		Name  string    `db:"name"`
+		Owner uuid.UUID `db:"owner_id"`
+	}
+	if err := tx.SelectContext(ctx, &existing, `SELECT id, name, owner_id FROM trackers WHERE family_id = $1`, familyID); err != nil {
+		return nil, nil, fmt.Errorf("import existing trackers: %w", err)
+	}
+
+	// Names are unique per family, and an archive can span several families, so the name is the merge key.
+	byName := make(map[string]uuid.UUID, len(existing))
+	// owned holds the trackers the importer may change: their own and the ones created here.
+	owned := make(map[uuid.UUID]bool)
+	for _, t := range existing {
+		byName[strings.ToLower(t.Name)] = t.ID
+		if t.Owner == userID {
+			owned[t.ID] = true
+		}
+	}
+
+	created := make(map[uuid.UUID]bool)
+
+	tQ := `INSERT INTO trackers (
+				owner_id, family_id, name, display, interval, interval_unit,
+				category, kind, action_label, icon, pinned, show, start_date, cost, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			RETURNING id`
+
+	for _, t := range trackers {
+		name := strings.TrimSpace(t.Name)
+		if name == "" || t.Interval <= 0 || t.IntervalUnit == "" {
+			c.Skipped++
+			continue
+		}
+
+		if id, ok := byName[strings.ToLower(name)]; ok {
+			trackerIDs[t.ID] = id
+			c.Skipped++
+			continue
+		}
+
+		var id uuid.UUID
+		if err := tx.GetContext(ctx, &id, tQ, userID, familyID, name, t.Display, t.Interval, t.IntervalUnit,
+			t.Category, t.Kind, t.ActionLabel, t.Icon, t.Pinned, t.Show, t.StartDate, t.Cost, t.CreatedAt,
+		); err != nil {
+			return nil, nil, fmt.Errorf("import tracker %q: %w", name, err)
+		}
+
+		trackerIDs[t.ID] = id
+		byName[strings.ToLower(name)] = id
+		created[id] = true
+		owned[id] = true
+		c.Created++
+	}
+
+	for _, t := range trackers {
+		id, ok := trackerIDs[t.ID]
+		if !ok {
+			continue
+		}
+
+		if err := importChecklist(ctx, tx, id, owned[id], t.Checklist, itemIDs, &result.ChecklistItems); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Links are only added to trackers created here. Their only incoming links come from this archive,
+	// so existing trackers can't end up in a loop.
+	dQ := `INSERT INTO tracker_dependencies (tracker_id, depends_on_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`
+
+	for _, t := range trackers {
+		id := trackerIDs[t.ID]
+		if !created[id] {
+			continue
+		}
+
+		for _, dep := range t.DependsOn {
+			depID, ok := trackerIDs[dep]
+			if !ok || depID == id {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, dQ, id, depID); err != nil {
+				return nil, nil, fmt.Errorf("import tracker dependency: %w", err)
+			}
+		}
+	}
+
+	return trackerIDs, itemIDs, nil
+}
+
+// importChecklist matches archive items to the tracker's existing items by label and appends the rest. Only the
+// tracker's owner may add items, so for anyone else's tracker the unmatched ones are skipped.
+func importChecklist(ctx context.Context, tx *sqlx.Tx, trackerID uuid.UUID, owned bool, items []ChecklistItem, itemIDs idMap, c *Counts) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var existing []ChecklistItem
+	eQ := `SELECT id, tracker_id, label, position FROM tracker_checklist_items WHERE tracker_id = $1`
+	if err := tx.SelectContext(ctx, &existing, eQ, trackerID); err != nil {
+		return fmt.Errorf("import existing checklist: %w", err)
+	}
+
+	byLabel := make(map[string]uuid.UUID, len(existing))
+	for _, i := range existing {
+		byLabel[strings.ToLower(i.Label)] = i.ID
+	}
+
+	q := `INSERT INTO tracker_checklist_items (tracker_id, label, position)
+			VALUES ($1, $2,
+				COALESCE((SELECT MAX(position) + 1 FROM tracker_checklist_items WHERE tracker_id = $1), 0))
+			RETURNING id`
+
+	for _, item := range items {
+		key := strings.ToLower(strings.TrimSpace(item.Label))
+		if key == "" {
+			c.Skipped++
+			continue
+		}
+
+		if id, ok := byLabel[key]; ok {
+			itemIDs[item.ID] = id
+			c.Skipped++
+			continue
+		}
+
+		if !owned {
+			c.Skipped++
+			continue
+		}
+
+		var id uuid.UUID
+		if err := tx.GetContext(ctx, &id, q, trackerID, strings.TrimSpace(item.Label)); err != nil {
+			return fmt.Errorf("import checklist item: %w", err)
+		}
+
+		itemIDs[item.ID] = id
+		byLabel[key] = id
+		c.Created++
+	}
+
+	return nil
+}
+
+func importEntries(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, familyID uuid.UUID, entries []Entry, trackerIDs idMap, itemIDs idMap, c *Counts) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var existing []struct {
+		TrackerID   uuid.UUID `db:"tracker_id"`
+		PerformedAt time.Time `db:"performed_at"`
+	}
+	eQ := `SELECT e.tracker_id, e.performed_at FROM entries e
+			JOIN trackers t ON e.tracker_id = t.id
+			WHERE t.family_id = $1`
+	if err := tx.SelectContext(ctx, &existing, eQ, familyID); err != nil {
+		return fmt.Errorf("import existing entries: %w", err)
+	}
+
+	type entryKey struct {
+		trackerID   uuid.UUID
+		performedAt int64
+	}
+
+	seen := make(map[entryKey]bool, len(existing)+len(entries))
+	for _, e := range existing {
+		seen[entryKey{e.TrackerID, e.PerformedAt.UnixMicro()}] = true
+	}
+
+	// Performers are kept when they are part of the target family; anyone else is attributed to the importer.
+	var members []uuid.UUID
+	mQ := `SELECT owner_id FROM families WHERE id = $1
+			UNION
+			SELECT user_id FROM families_users WHERE family_id = $1`
+	if err := tx.SelectContext(ctx, &members, mQ, familyID); err != nil {
+		return fmt.Errorf("import family members: %w", err)
+	}
+
+	isMember := make(map[uuid.UUID]bool, len(members))
+	for _, m := range members {
+		isMember[m] = true
+	}
+
+	q := `INSERT INTO entries (tracker_id, interval, interval_unit, performed_by, performed_at, remark)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`
+
+	cQ := `INSERT INTO entry_checklist_items (entry_id, item_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`
+
+	for _, e := range entries {
+		trackerID, ok := trackerIDs[e.TrackerID]
+		if !ok {
+			c.Skipped++
+			continue
+		}
+
+		key := entryKey{trackerID, e.PerformedAt.Truncate(time.Microsecond).UnixMicro()}
+		if seen[key] {
+			c.Skipped++
+			continue
+		}
+
+		performedBy := userID
+		if e.PerformedBy != nil && isMember[*e.PerformedBy] {
+			performedBy = *e.PerformedBy
+		}
+
+		var id uuid.UUID
+		if err := tx.GetContext(ctx, &id, q, trackerID, e.Interval, e.IntervalUnit, performedBy, e.PerformedAt, e.Remark); err != nil {
+			return fmt.Errorf("import entry: %w", err)
+		}
+
+		for _, item := range e.CompletedItems {
+			itemID, ok := itemIDs[item]
+			if !ok {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, cQ, id, itemID); err != nil {
+				return fmt.Errorf("import entry checklist item: %w", err)
+			}
+		}
+
+		seen[key] = true
+		c.Created++
+	}
+
+	return nil
+}
+
+func importVacations(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, familyID uuid.UUID, vacations []Vacation, c *Counts) error {
+	existsQ := `SELECT EXISTS(
+					SELECT 1 FROM vacations
+					WHERE family_id = $1 AND start_date_time = $2 AND end_date_time = $3
+				)`
+
+	q := `INSERT INTO vacations (family_id, created_by, start_date_time, end_date_time, label)
+			VALUES ($1, $2, $3, $4, $5)`
+
+	for _, v := range vacations {
+		if !v.EndDateTime.After(v.StartDateTime) {
+			c.Skipped++
+			continue
+		}
+
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, existsQ, familyID, v.StartDateTime, v.EndDateTime); err != nil {
+			return fmt.Errorf("import vacation check: %w", err)
+		}
+
+		if exists {
+			c.Skipped++
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, q, familyID, userID, v.StartDateTime, v.EndDateTime, v.Label); err != nil {
+			return fmt.Errorf("import vacation: %w", err)
+		}
+
+		c.Created++
+	}
+
+	return nil
+}
+
+func importTimerProfiles(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, profiles []TimerProfile, c *Counts) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	var existing []TimerProfile
+	if err := tx.SelectContext(ctx, &existing, `SELECT name, segments, is_default FROM timer_profiles WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("import existing timer profiles: %w", err)
+	}
+
+	names := make(map[string]bool, len(existing))
+	hasDefault := false
+	for _, p := range existing {
+		names[strings.ToLower(p.Name)] = true
+		hasDefault = hasDefault || p.IsDefault
+	}
+
+	q := `INSERT INTO timer_profiles (user_id, name, segments, is_default)
+			VALUES ($1, $2, $3, $4)`
+
+	for _, p := range profiles {
+		key := strings.ToLower(strings.TrimSpace(p.Name))
+		if key == "" || names[key] {
+			c.Skipped++
+			continue
+		}
+
+		segments := p.Segments
+		if len(segments) == 0 {
+			segments = []byte("[]")
+		}
+
+		// Only one default is allowed per user; keep the one they already have.
+		isDefault := p.IsDefault && !hasDefault
+
+		if _, err := tx.ExecContext(ctx, q, userID, strings.TrimSpace(p.Name), segments, isDefault); err != nil {
+			return fmt.Errorf("import timer profile: %w", err)
+		}
+
+		names[key] = true
+		hasDefault = hasDefault || isDefault
+		c.Created++
+	}
+
+	return nil
+}
+
+func importGym(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, g Gym, result *ImportResult) error {
+	wExistsQ := `SELECT EXISTS(SELECT 1 FROM gym_workouts WHERE user_id = $1 AND start_time = $2)`
+
+	wQ := `INSERT INTO gym_workouts (user_id, start_time, notes)
+			VALUES ($1, $2, $3)
+			RETURNING id`
+
+	sQ := `INSERT INTO gym_sets (workout_id, exercise_id, weight_kg, reps, set_type, is_completed, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	for _, w := range g.Workouts {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, wExistsQ, userID, w.StartTime); err != nil {
+			return fmt.Errorf("import workout check: %w", err)
+		}
+
+		if exists {
+			result.Workouts.Skipped++
+			continue
+		}
+
+		var id uuid.UUID
+		if err := tx.GetContext(ctx, &id, wQ, userID, w.StartTime, w.Notes); err != nil {
+			return fmt.Errorf("import workout: %w", err)
+		}
+
+		for _, s := range w.Sets {
+			if _, err := tx.ExecContext(ctx, sQ, id, s.ExerciseID, s.WeightKg, s.Reps, s.SetType, s.IsCompleted, s.Position); err != nil {
+				return fmt.Errorf("import set: %w", err)
+			}
+		}
+
+		result.Workouts.Created++
+	}
+
+	rExistsQ := `SELECT EXISTS(SELECT 1 FROM gym_routines WHERE user_id = $1 AND LOWER(name) = LOWER($2))`
+
+	rQ := `INSERT INTO gym_routines (user_id, name, position)
+			VALUES ($1, $2, COALESCE((SELECT MAX(position) + 1 FROM gym_routines WHERE user_id = $1), 0))
+			RETURNING id`
+
+	reQ := `INSERT INTO gym_routine_exercises (routine_id, exercise_id, sets, position)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (routine_id, exercise_id) DO NOTHING`
+
+	for _, r := range g.Routines {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, rExistsQ, userID, r.Name); err != nil {
+			return fmt.Errorf("import routine check: %w", err)
+		}
+
+		if exists || strings.TrimSpace(r.Name) == "" {
+			result.Routines.Skipped++
+			continue
+		}
+
+		var id uuid.UUID
+		if err := tx.GetContext(ctx, &id, rQ, userID, r.Name); err != nil {
+			return fmt.Errorf("import routine: %w", err)
+		}
+
+		for _, e := range r.Exercises {
+			if _, err := tx.ExecContext(ctx, reQ, id, e.ExerciseID, e.Sets, e.Position); err != nil {
+				return fmt.Errorf("import routine exercise: %w", err)
+			}
+		}
+
+		result.Routines.Created++
+	}
+
+	fQ := `INSERT INTO gym_favourite_exercises (user_id, exercise_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`
+
+	for _, f := range g.Favourites {
+		res, err := tx.ExecContext(ctx, fQ, userID, f)
+		if err != nil {
+			return fmt.Errorf("import favourite: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("import favourite rows affected: %w", err)
+		}
+
+		if affected == 0 {
+			result.Favourites.Skipped++
+		} else {
+			result.Favourites.Created++
+		}
+	}
+
+	return nil
+}
+
+func importMarketPrices(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, familyID uuid.UUID, prices []MarketPrice, c *Counts) error {
+	existsQ := `SELECT EXISTS(
+					SELECT 1 FROM market_prices
+					WHERE family_id = $1
+					AND LOWER(item_name) = LOWER($2)
+					AND store IS NOT DISTINCT FROM $3
+					AND price = $4
+					AND created_at IS NOT DISTINCT FROM $5
+				)`
+
+	q := `INSERT INTO market_prices (
+				family_id, logged_by, item_name, category, country, store, unit, quantity, price, is_promo, remarks,
+				created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, NOW()), NOW())`
+
+	for _, p := range prices {
+		if strings.TrimSpace(p.ItemName) == "" {
+			c.Skipped++
+			continue
+		}
+
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, existsQ, familyID, p.ItemName, p.Store, p.Price, p.CreatedAt); err != nil {
+			return fmt.Errorf("import market price check: %w", err)
+		}
+
+		if exists {
+			c.Skipped++
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, q, familyID, userID, p.ItemName, p.Category, p.Country, p.Store, p.Unit,
+			p.Quantity, p.Price, p.IsPromo, p.Remarks, p.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("import market price: %w", err)
+		}
+
+		c.Created++
+	}
+
+	return nil
+}
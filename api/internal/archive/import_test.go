@@ -0,0 +1,57 @@
+package archive_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/testdb"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestImportChecklistOnlyIntoOwnTrackers(t *testing.T) {
+	db := testdb.New(t)
+
+	owner, member := testdb.User(t, db), testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+	testdb.AddMember(t, db, familyID, member)
+	ownersTracker := testdb.Tracker(t, db, owner, familyID)
+
+	var name string
+	if err := db.GetContext(t.Context(), &name, `SELECT name FROM trackers WHERE id = $1`, ownersTracker); err != nil {
+		t.Fatal(err)
+	}
+
+	a := archive.Archive{
+		Version: archive.Version,
+		Trackers: []archive.Tracker{
+			// Merged into the owner's tracker by name, which the member can't add items to.
+			{ID: uuid.New(), Name: name, Interval: 1, IntervalUnit: "day", Checklist: []archive.ChecklistItem{
+				{ID: uuid.New(), Label: "Sneaked in"},
+			}},
+			{ID: uuid.New(), Name: "New tracker", Interval: 1, IntervalUnit: "day", Checklist: []archive.ChecklistItem{
+				{ID: uuid.New(), Label: "Step one"},
+			}},
+		},
+	}
+
+	res, err := archive.Import(t.Context(), db, member, familyID, a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ChecklistItems != (archive.Counts{Created: 1, Skipped: 1}) {
+		t.Errorf("checklist items = %+v, want 1 created and 1 skipped", res.ChecklistItems)
+	}
+
+	var n int
+	if err := db.GetContext(t.Context(), &n, `SELECT COUNT(*) FROM tracker_checklist_items WHERE tracker_id = $1`, ownersTracker); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("owner's tracker has %d checklist items after a member's import, want 0", n)
+	}
+}
@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Personal is the subject-access copy of an account: the importable Archive plus the records that only make sense
+// for this user, such as memberships, invites, comments and activity they authored. It is not meant to be imported.
+type Personal struct {
+	ExportedAt      time.Time        `json:"exportedAt"`
+	Profile         Profile          `json:"profile"`
+	Families        []Membership     `json:"families"`
+	Invites         []InviteRecord   `json:"invites"`
+	Comments        []Comment        `json:"comments"`
+	Activities      []ActivityRecord `json:"activities"`
+	TrackerSettings []TrackerSetting `json:"trackerSettings"`
+	Devices         []Device         `json:"devices"`
+	Data            Archive          `json:"data"`
+}
+
+type Profile struct {
+	ID                 uuid.UUID  `db:"id"                  json:"id"`
+	Email              string     `db:"email"               json:"email"`
+	Name               *string    `db:"name"                json:"name"`
+	TaskLookAheadDays  *int       `db:"task_lookahead_days" json:"taskLookaheadDays"`
+	SoundModeQuick     *string    `db:"sound_mode_quick"    json:"soundModeQuick"`
+	SoundModeProfile   *string    `db:"sound_mode_profile"  json:"soundModeProfile"`
+	PreferredCharacter *string    `db:"preferred_character" json:"preferredCharacter"`
+	ActiveFamilyID     *uuid.UUID `db:"active_family_id"    json:"activeFamilyId"`
+	CreatedAt          time.Time  `db:"created_at"          json:"createdAt"`
+}
+
+type Membership struct {
+	FamilyID uuid.UUID `db:"family_id" json:"familyId"`
+	Name     string    `db:"name"      json:"name"`
+	Role     string    `db:"role"      json:"role"`
+	JoinedAt time.Time `db:"joined_at" json:"joinedAt"`
+}
+
+type InviteRecord struct {
+	FamilyID     uuid.UUID `db:"family_id"     json:"familyId"`
+	FamilyName   string    `db:"family_name"   json:"familyName"`
+	Direction    string    `db:"direction"     json:"direction"`
+	InviteeEmail *string   `db:"invitee_email" json:"inviteeEmail"`
+	Status       string    `db:"status"        json:"status"`
+	CreatedAt    time.Time `db:"created_at"    json:"createdAt"`
+}
+
+type Comment struct {
+	TrackerID uuid.UUID `db:"tracker_id" json:"trackerId"`
+	Body      string    `db:"body"       json:"body"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+type ActivityRecord struct {
+	FamilyID  uuid.UUID       `db:"family_id"  json:"familyId"`
+	TrackerID *uuid.UUID      `db:"tracker_id" json:"trackerId"`
+	Kind      string          `db:"kind"       json:"kind"`
+	Data      json.RawMessage `db:"data"       json:"data"`
+	CreatedAt time.Time       `db:"created_at" json:"createdAt"`
+}
+
+type TrackerSetting struct {
+	TrackerID uuid.UUID `db:"tracker_id" json:"trackerId"`
+	IsMuted   bool      `db:"is_muted"   json:"isMuted"`
+}
+
+// Device leaves out the push token itself, which is a credential rather than personal data.
+type Device struct {
+	Platform  *string   `db:"platform"   json:"platform"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+func ExportPersonal(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (Personal, error) {
+	var p Personal
+	var err error
+
+	if p.Data, err = Export(ctx, db, userID); err != nil {
+		return Personal{}, err
+	}
+
+	p.ExportedAt = p.Data.ExportedAt
+
+	uQ := `SELECT id, email, name, task_lookahead_days, sound_mode_quick, sound_mode_profile, preferred_character,
+				active_family_id, created_at
+			FROM users WHERE id = $1`
+
+	if err := db.GetContext(ctx, &p.Profile, uQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export profile: %w", err)
+	}
+
+	fQ := `SELECT id AS family_id, name, 'owner' AS role, created_at AS joined_at FROM families WHERE owner_id = $1
+			UNION ALL
+			SELECT f.id, f.name, 'member', fu.created_at
+			FROM families_users fu
+			JOIN families f ON fu.family_id = f.id
+			WHERE fu.user_id = $1
+			ORDER BY joined_at ASC`
+
+	p.Families = []Membership{}
+	if err := db.SelectContext(ctx, &p.Families, fQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export families: %w", err)
+	}
+
+	iQ := `SELECT i.family_id, f.name AS family_name,
+				CASE WHEN i.invited_by = $1 THEN 'sent' ELSE 'received' END AS direction,
+				COALESCE(i.invitee_email, u.email) AS invitee_email, i.status, i.created_at
+			FROM invites i
+			JOIN families f ON i.family_id = f.id
+			LEFT JOIN users u ON i.invitee_id = u.id
+			WHERE i.invitee_id = $1 OR i.invited_by = $1
+			ORDER BY i.created_at ASC`
+
+	p.Invites = []InviteRecord{}
+	if err := db.SelectContext(ctx, &p.Invites, iQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export invites: %w", err)
+	}
+
+	cQ := `SELECT tracker_id, body, created_at FROM tracker_comments WHERE author_id = $1 ORDER BY created_at ASC`
+
+	p.Comments = []Comment{}
+	if err := db.SelectContext(ctx, &p.Comments, cQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export comments: %w", err)
+	}
+
+	aQ := `SELECT family_id, tracker_id, kind, data, created_at FROM activities WHERE actor_id = $1 ORDER BY created_at ASC`
+
+	p.Activities = []ActivityRecord{}
+	if err := db.SelectContext(ctx, &p.Activities, aQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export activities: %w", err)
+	}
+
+	sQ := `SELECT tracker_id, COALESCE(is_muted, FALSE) AS is_muted FROM tracker_user_settings WHERE user_id = $1`
+
+	p.TrackerSettings = []TrackerSetting{}
+	if err := db.SelectContext(ctx, &p.TrackerSettings, sQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export tracker settings: %w", err)
+	}
+
+	dQ := `SELECT platform, created_at FROM push_tokens WHERE user_id = $1 ORDER BY created_at ASC`
+
+	p.Devices = []Device{}
+	if err := db.SelectContext(ctx, &p.Devices, dQ, userID); err != nil {
+		return Personal{}, fmt.Errorf("export devices: %w", err)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,29 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs archive export and import queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Export(ctx context.Context, userID uuid.UUID) (Archive, error) {
+	return Export(ctx, r.db, userID)
+}
+
+func (r *Repository) ExportPersonal(ctx context.Context, userID uuid.UUID) (Personal, error) {
+	return ExportPersonal(ctx, r.db, userID)
+}
+
+func (r *Repository) Import(ctx context.Context, userID uuid.UUID, familyID uuid.UUID, a Archive, dryRun bool) (ImportResult, error) {
+	return Import(ctx, r.db, userID, familyID, a, dryRun)
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/mailer"
+)
+
+// SessionDuration is how long a login stays valid before the user has to sign in again.
+const SessionDuration = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidCredentials = errors.New("invalid or expired credentials")
+	ErrUnknownProvider    = errors.New("unknown auth provider")
+)
+
+// Identity is the provider's view of a user. ID is the provider's own user ID, not the internal users.id.
+type Identity struct {
+	ID        string
+	Email     string
+	CreatedAt time.Time
+}
+
+// Session is handed back after a login or refresh. JWT is short-lived and checked locally on each request; Token
+// lasts for the whole session and is exchanged for a fresh JWT when that expires.
+type Session struct {
+	ID       string
+	JWT      string
+	Token    string
+	Identity Identity
+}
+
+type Authenticator interface {
+	SendMagicLink(ctx context.Context, email string) error
+	AuthenticateMagicLink(ctx context.Context, token string) (Session, error)
+	// SendOTP returns the method ID that has to be passed back with the code.
+	SendOTP(ctx context.Context, email string) (string, error)
+	AuthenticateOTP(ctx context.Context, methodID string, code string) (Session, error)
+	// ValidateJWT checks a session JWT without a network round-trip and returns its session and identity IDs.
+	ValidateJWT(ctx context.Context, jwt string) (sessionID string, identityID string, err error)
+	RefreshSession(ctx context.Context, token string) (Session, error)
+	GetIdentity(ctx context.Context, identityID string) (Identity, error)
+	Logout(ctx context.Context, token string) error
+	RevokeAll(ctx context.Context, identityID string) error
+	DeleteIdentity(ctx context.Context, identityID string) error
+}
+
+// FromConfig picks the configured provider: "stytch" (the default) or "local", which needs no outside service and
+// mails codes and links through m.
+func FromConfig(c config.Auth, m mailer.Mailer) (Authenticator, error) {
+	switch p := c.Provider; p {
+	case "", "stytch":
+		return NewStytch(c.StytchProjectID, c.StytchSecret)
+	case "local":
+		return NewLocal(c.LocalSecret, c.CallbackURL, m)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, p)
+	}
+}
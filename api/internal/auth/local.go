@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/mailer"
+	"github.com/zachczx/cubby/api/internal/secret"
+)
+
+const (
+	localJWTTTL       = 5 * time.Minute
+	localCodeTTL      = 10 * time.Minute
+	localLinkTTL      = 15 * time.Minute
+	localMaxAttempts  = 5
+	localIdentityPref = "local:"
+)
+
+// Local is a self-contained provider for development and integration tests. Codes and links are sent through the
+// mailer (which logs them when SMTP isn't configured, so config only allows that in development) and sessions are HMAC-signed tokens, so nothing but the key
+// needs to persist. Pending codes and revocations live in memory and are lost on restart.
+type Local struct {
+	key         []byte
+	callbackURL string
+	mailer      mailer.Mailer
+
+	mu            sync.Mutex
+	codes         map[string]*pendingCode
+	links         map[string]pendingLink
+	revoked       map[string]time.Time
+	revokedBefore map[string]time.Time
+}
+
+type pendingCode struct {
+	email     string
+	codeHash  string
+	expiresAt time.Time
+	attempts  int
+}
+
+type pendingLink struct {
+	email     string
+	expiresAt time.Time
+}
+
+type localClaims struct {
+	SessionID string `json:"sid"`
+	Subject   string `json:"sub"`
+	Kind      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewLocal signs sessions with key. Without a key a random one is used, which signs everyone out on restart.
+func NewLocal(key string, callbackURL string, m mailer.Mailer) (*Local, error) {
+	k := []byte(key)
+	if len(k) == 0 {
+		random, err := secret.New()
+		if err != nil {
+			return nil, fmt.Errorf("local auth key: %w", err)
+		}
+
+		slog.Warn("AUTH_LOCAL_SECRET not set, sessions will not survive a restart")
+		k = []byte(random)
+	}
+
+	if callbackURL == "" {
+		callbackURL = "http://localhost:7002/authenticate"
+	}
+
+	return &Local{
+		key:           k,
+		callbackURL:   callbackURL,
+		mailer:        m,
+		codes:         map[string]*pendingCode{},
+		links:         map[string]pendingLink{},
+		revoked:       map[string]time.Time{},
+		revokedBefore: map[string]time.Time{},
+	}, nil
+}
+
+func (l *Local) SendMagicLink(ctx context.Context, email string) error {
+	token, err := secret.New()
+	if err != nil {
+		return fmt.Errorf("magic link token: %w", err)
+	}
+
+	l.mu.Lock()
+	l.links[secret.Hash(token)] = pendingLink{email: normalizeEmail(email), expiresAt: time.Now().Add(localLinkTTL)}
+	l.mu.Unlock()
+
+	link := l.callbackURL + "?token=" + url.QueryEscape(token)
+
+	if err := l.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Your Cubby sign-in link",
+		Body:    "Open this link to sign in to Cubby:\n\n" + link + "\n\nIt expires in 15 minutes.",
+	}); err != nil {
+		return fmt.Errorf("send magic link: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Local) AuthenticateMagicLink(_ context.Context, token string) (Session, error) {
+	l.mu.Lock()
+	link, ok := l.links[secret.Hash(token)]
+	delete(l.links, secret.Hash(token))
+	l.mu.Unlock()
+
+	if !ok || time.Now().After(link.expiresAt) {
+		return Session{}, ErrInvalidCredentials
+	}
+
+	return l.newSession(link.email)
+}
+
+func (l *Local) SendOTP(ctx context.Context, email string) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("otp code: %w", err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	methodID, err := secret.New()
+	if err != nil {
+		return "", fmt.Errorf("otp method id: %w", err)
+	}
+
+	l.mu.Lock()
+	l.codes[methodID] = &pendingCode{email: normalizeEmail(email), codeHash: secret.Hash(code), expiresAt: time.Now().Add(localCodeTTL)}
+	l.mu.Unlock()
+
+	if err := l.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Your Cubby sign-in code",
+		Body:    "Your Cubby sign-in code is " + code + ".\n\nIt expires in 10 minutes.",
+	}); err != nil {
+		return "", fmt.Errorf("send otp: %w", err)
+	}
+
+	return methodID, nil
+}
+
+func (l *Local) AuthenticateOTP(_ context.Context, methodID string, code string) (Session, error) {
+	l.mu.Lock()
+	p, ok := l.codes[methodID]
+	if !ok {
+		l.mu.Unlock()
+		return Session{}, ErrInvalidCredentials
+	}
+
+	p.attempts++
+	valid := subtle.ConstantTimeCompare([]byte(p.codeHash), []byte(secret.Hash(code))) == 1
+	if valid || p.attempts >= localMaxAttempts || time.Now().After(p.expiresAt) {
+		delete(l.codes, methodID)
+	}
+	l.mu.Unlock()
+
+	if !valid || time.Now().After(p.expiresAt) {
+		return Session{}, ErrInvalidCredentials
+	}
+
+	return l.newSession(p.email)
+}
+
+func (l *Local) ValidateJWT(_ context.Context, jwt string) (string, string, error) {
+	c, err := l.verify(jwt, "jwt")
+	if err != nil {
+		return "", "", err
+	}
+
+	return c.SessionID, c.Subject, nil
+}
+
+func (l *Local) RefreshSession(_ context.Context, token string) (Session, error) {
+	c, err := l.verify(token, "session")
+	if err != nil {
+		return Session{}, err
+	}
+
+	jwt, err := l.sign(localClaims{
+		SessionID: c.SessionID,
+		Subject:   c.Subject,
+		Kind:      "jwt",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(localJWTTTL).Unix(),
+	})
+	if err != nil {
+		return Session{}, err
+	}
+
+	return Session{ID: c.SessionID, JWT: jwt, Token: token, Identity: localIdentity(c.Subject)}, nil
+}
+
+func (l *Local) GetIdentity(_ context.Context, identityID string) (Identity, error) {
+	if !strings.HasPrefix(identityID, localIdentityPref) {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	return localIdentity(identityID), nil
+}
+
+func (l *Local) Logout(_ context.Context, token string) error {
+	c, err := l.verify(token, "session")
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.revoked[c.SessionID] = time.Unix(c.ExpiresAt, 0)
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *Local) RevokeAll(_ context.Context, identityID string) error {
+	l.mu.Lock()
+	l.revokedBefore[identityID] = time.Now()
+	l.mu.Unlock()
+
+	return nil
+}
+
+// DeleteIdentity only has to stop existing sessions; identities are derived from the email and not stored.
+func (l *Local) DeleteIdentity(ctx context.Context, identityID string) error {
+	return l.RevokeAll(ctx, identityID)
+}
+
+func (l *Local) newSession(email string) (Session, error) {
+	sessionID, err := secret.New()
+	if err != nil {
+		return Session{}, fmt.Errorf("session id: %w", err)
+	}
+
+	now := time.Now()
+	subject := localIdentityPref + email
+
+	token, err := l.sign(localClaims{SessionID: sessionID, Subject: subject, Kind: "session", IssuedAt: now.Unix(), ExpiresAt: now.Add(SessionDuration).Unix()})
+	if err != nil {
+		return Session{}, err
+	}
+
+	jwt, err := l.sign(localClaims{SessionID: sessionID, Subject: subject, Kind: "jwt", IssuedAt: now.Unix(), ExpiresAt: now.Add(localJWTTTL).Unix()})
+	if err != nil {
+		return Session{}, err
+	}
+
+	return Session{ID: sessionID, JWT: jwt, Token: token, Identity: localIdentity(subject)}, nil
+}
+
+func (l *Local) sign(c localClaims) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("sign session: %w", err)
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(payload)
+
+	return body + "." + base64.RawURLEncoding.EncodeToString(l.mac(body)), nil
+}
+
+func (l *Local) verify(token string, kind string) (localClaims, error) {
+	var c localClaims
+
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, ErrInvalidCredentials
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, l.mac(body)) {
+		return c, ErrInvalidCredentials
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return c, ErrInvalidCredentials
+	}
+
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if c.Kind != kind || now.Unix() > c.ExpiresAt {
+		return c, ErrInvalidCredentials
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.revoked[c.SessionID]; ok {
+		return c, ErrInvalidCredentials
+	}
+
+	if before, ok := l.revokedBefore[c.Subject]; ok && c.IssuedAt <= before.Unix() {
+		return c, ErrInvalidCredentials
+	}
+
+	return c, nil
+}
+
+func (l *Local) mac(body string) []byte {
+	h := hmac.New(sha256.New, l.key)
+	h.Write([]byte(body))
+
+	return h.Sum(nil)
+}
+
+func localIdentity(subject string) Identity {
+	return Identity{ID: subject, Email: strings.TrimPrefix(subject, localIdentityPref), CreatedAt: time.Now()}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
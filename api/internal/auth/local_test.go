@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/mailer"
+)
+
+// lastMail keeps the most recent message instead of sending it.
+type lastMail struct {
+	m mailer.Message
+}
+
+func (l *lastMail) Send(_ context.Context, m mailer.Message) error {
+	l.m = m
+	return nil
+}
+
+var (
+	codeRe = regexp.MustCompile(`\d{6}`)
+	linkRe = regexp.MustCompile(`https://\S+`)
+)
+
+func newTestLocal(t *testing.T) (*Local, *lastMail) {
+	t.Helper()
+
+	m := &lastMail{}
+	l, err := NewLocal("test-key", "https://cubby.example/authenticate", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l, m
+}
+
+func signIn(t *testing.T, l *Local, m *lastMail) Session {
+	t.Helper()
+
+	methodID, err := l.SendOTP(t.Context(), "User@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := l.AuthenticateOTP(t.Context(), methodID, codeRe.FindString(m.m.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestLocalOTP(t *testing.T) {
+	l, m := newTestLocal(t)
+
+	methodID, err := l.SendOTP(t.Context(), "User@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := codeRe.FindString(m.m.Body)
+
+	s, err := l.AuthenticateOTP(t.Context(), methodID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Identity.Email != "user@example.com" {
+		t.Errorf("email = %q, want it normalised", s.Identity.Email)
+	}
+
+	sessionID, identityID, err := l.ValidateJWT(t.Context(), s.JWT)
+	if err != nil || sessionID != s.ID || identityID != s.Identity.ID {
+		t.Fatalf("ValidateJWT = %q, %q, %v", sessionID, identityID, err)
+	}
+
+	if _, err := l.AuthenticateOTP(t.Context(), methodID, code); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("reused code: err = %v", err)
+	}
+}
+
+func TestLocalOTPAttemptLimit(t *testing.T) {
+	l, m := newTestLocal(t)
+
+	methodID, err := l.SendOTP(t.Context(), "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := codeRe.FindString(m.m.Body)
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for range localMaxAttempts {
+		if _, err := l.AuthenticateOTP(t.Context(), methodID, wrong); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("wrong code: err = %v", err)
+		}
+	}
+
+	if _, err := l.AuthenticateOTP(t.Context(), methodID, code); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("right code after %d misses: err = %v, want it refused", localMaxAttempts, err)
+	}
+}
+
+func TestLocalOTPExpiry(t *testing.T) {
+	l, m := newTestLocal(t)
+
+	methodID, err := l.SendOTP(t.Context(), "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.codes[methodID].expiresAt = time.Now().Add(-time.Second)
+
+	if _, err := l.AuthenticateOTP(t.Context(), methodID, codeRe.FindString(m.m.Body)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expired code: err = %v", err)
+	}
+}
+
+func TestLocalMagicLink(t *testing.T) {
+	l, m := newTestLocal(t)
+
+	if err := l.SendMagicLink(t.Context(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := url.Parse(linkRe.FindString(m.m.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := link.Query().Get("token")
+
+	if _, err := l.AuthenticateMagicLink(t.Context(), token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.AuthenticateMagicLink(t.Context(), token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("reused link: err = %v", err)
+	}
+
+	if err := l.SendMagicLink(t.Context(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range l.links {
+		v.expiresAt = time.Now().Add(-time.Second)
+		l.links[k] = v
+	}
+
+	link, err = url.Parse(linkRe.FindString(m.m.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.AuthenticateMagicLink(t.Context(), link.Query().Get("token")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expired link: err = %v", err)
+	}
+}
+
+func TestLocalVerifyRejectsBadTokens(t *testing.T) {
+	l, m := newTestLocal(t)
+	s := signIn(t, l, m)
+
+	other, err := NewLocal("other-key", "", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := other.sign(localClaims{SessionID: s.ID, Subject: s.Identity.ID, Kind: "jwt", IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := l.sign(localClaims{SessionID: s.ID, Subject: s.Identity.ID, Kind: "jwt", IssuedAt: time.Now().Add(-time.Hour).Unix(), ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, sig, _ := strings.Cut(s.JWT, ".")
+	otherBody, _, _ := strings.Cut(forged, ".")
+
+	// Change the first character; the last one carries padding bits the decoder ignores.
+	tampered := "A" + sig[1:]
+	if sig[0] == 'A' {
+		tampered = "B" + sig[1:]
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"tampered signature", body + "." + tampered},
+		{"swapped payload", otherBody + "." + sig},
+		{"signed with another key", forged},
+		{"expired", expired},
+		{"session token as a JWT", s.Token},
+		{"no signature", body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := l.ValidateJWT(t.Context(), tt.token); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("err = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
+
+func TestLocalLogoutAndRevokeAll(t *testing.T) {
+	l, m := newTestLocal(t)
+
+	s := signIn(t, l, m)
+	kept := signIn(t, l, m)
+
+	if err := l.Logout(t.Context(), s.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := l.ValidateJWT(t.Context(), s.JWT); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("JWT after logout: err = %v", err)
+	}
+	if _, err := l.RefreshSession(t.Context(), s.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("refresh after logout: err = %v", err)
+	}
+	if _, err := l.RefreshSession(t.Context(), kept.Token); err != nil {
+		t.Fatalf("other session after logout: %v", err)
+	}
+
+	if err := l.RevokeAll(t.Context(), kept.Identity.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RefreshSession(t.Context(), kept.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("refresh after RevokeAll: err = %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks"
+	emailML "github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp"
+	otpEmail "github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
+)
+
+// jwtMaxAge matches the lifetime Stytch gives session JWTs.
+const jwtMaxAge = 5 * time.Minute
+
+type Stytch struct {
+	Client *stytchapi.API
+}
+
+func NewStytch(projectID string, secret string) (*Stytch, error) {
+	client, err := stytchapi.NewClient(projectID, secret)
+	if err != nil {
+		return nil, fmt.Errorf("create stytch client: %w", err)
+	}
+
+	return &Stytch{Client: client}, nil
+}
+
+func (s *Stytch) SendMagicLink(ctx context.Context, email string) error {
+	if _, err := s.Client.MagicLinks.Email.LoginOrCreate(ctx, &emailML.LoginOrCreateParams{Email: email}); err != nil {
+		return stytchErr("send magic link", err)
+	}
+
+	return nil
+}
+
+func (s *Stytch) AuthenticateMagicLink(ctx context.Context, token string) (Session, error) {
+	resp, err := s.Client.MagicLinks.Authenticate(ctx, &magiclinks.AuthenticateParams{
+		Token:                  token,
+		SessionDurationMinutes: int32(SessionDuration.Minutes()),
+	})
+	if err != nil {
+		return Session{}, stytchErr("authenticate magic link", err)
+	}
+
+	return newStytchSession(resp.Session.SessionID, resp.SessionJWT, resp.SessionToken, resp.User), nil
+}
+
+func (s *Stytch) SendOTP(ctx context.Context, email string) (string, error) {
+	resp, err := s.Client.OTPs.Email.LoginOrCreate(ctx, &otpEmail.LoginOrCreateParams{Email: email})
+	if err != nil {
+		return "", stytchErr("send otp", err)
+	}
+
+	return resp.EmailID, nil
+}
+
+func (s *Stytch) AuthenticateOTP(ctx context.Context, methodID string, code string) (Session, error) {
+	resp, err := s.Client.OTPs.Authenticate(ctx, &otp.AuthenticateParams{
+		MethodID:               methodID,
+		Code:                   code,
+		SessionDurationMinutes: int32(SessionDuration.Minutes()),
+	})
+	if err != nil {
+		return Session{}, stytchErr("authenticate otp", err)
+	}
+
+	return newStytchSession(resp.Session.SessionID, resp.SessionJWT, resp.SessionToken, resp.User), nil
+}
+
+// ValidateJWT verifies against Stytch's JWKS, which the client fetches once and caches.
+func (s *Stytch) ValidateJWT(_ context.Context, jwt string) (string, string, error) {
+	sess, err := s.Client.Sessions.AuthenticateJWTLocal(jwt, jwtMaxAge)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	return sess.SessionID, sess.UserID, nil
+}
+
+func (s *Stytch) RefreshSession(ctx context.Context, token string) (Session, error) {
+	resp, err := s.Client.Sessions.Authenticate(ctx, &sessions.AuthenticateParams{
+		SessionToken:           token,
+		SessionDurationMinutes: int32(SessionDuration.Minutes()),
+	})
+	if err != nil {
+		return Session{}, stytchErr("refresh session", err)
+	}
+
+	return newStytchSession(resp.Session.SessionID, resp.SessionJWT, resp.SessionToken, resp.User), nil
+}
+
+func (s *Stytch) GetIdentity(ctx context.Context, identityID string) (Identity, error) {
+	resp, err := s.Client.Users.Get(ctx, &users.GetParams{UserID: identityID})
+	if err != nil {
+		return Identity{}, stytchErr("get user", err)
+	}
+
+	id := Identity{ID: resp.UserID}
+	if len(resp.Emails) > 0 {
+		id.Email = resp.Emails[0].Email
+	}
+	if resp.CreatedAt != nil {
+		id.CreatedAt = *resp.CreatedAt
+	}
+
+	return id, nil
+}
+
+func (s *Stytch) Logout(ctx context.Context, token string) error {
+	if _, err := s.Client.Sessions.Revoke(ctx, &sessions.RevokeParams{SessionToken: token}); err != nil {
+		return stytchErr("revoke session", err)
+	}
+
+	return nil
+}
+
+func (s *Stytch) RevokeAll(ctx context.Context, identityID string) error {
+	resp, err := s.Client.Sessions.Get(ctx, &sessions.GetParams{UserID: identityID})
+	if err != nil {
+		return stytchErr("list sessions", err)
+	}
+
+	for _, sess := range resp.Sessions {
+		if _, err := s.Client.Sessions.Revoke(ctx, &sessions.RevokeParams{SessionID: sess.SessionID}); err != nil {
+			return stytchErr("revoke session", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Stytch) DeleteIdentity(ctx context.Context, identityID string) error {
+	if _, err := s.Client.Users.Delete(ctx, &users.DeleteParams{UserID: identityID}); err != nil {
+		return stytchErr("delete user", err)
+	}
+
+	return nil
+}
+
+func newStytchSession(sessionID string, jwt string, token string, u users.User) Session {
+	sess := Session{ID: sessionID, JWT: jwt, Token: token, Identity: Identity{ID: u.UserID}}
+	if len(u.Emails) > 0 {
+		sess.Identity.Email = u.Emails[0].Email
+	}
+	if u.CreatedAt != nil {
+		sess.Identity.CreatedAt = *u.CreatedAt
+	}
+
+	return sess
+}
+
+// stytchErr marks Stytch's 4xx answers (bad code, expired link, revoked session) as ErrInvalidCredentials so
+// handlers can tell them apart from outages.
+func stytchErr(op string, err error) error {
+	var se stytcherror.Error
+	if errors.As(err, &se) && se.StatusCode >= http.StatusBadRequest && se.StatusCode < http.StatusInternalServerError {
+		return fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
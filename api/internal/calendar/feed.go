@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/tracker"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+var rruleFreq = map[string]string{
+	"day":   "DAILY",
+	"month": "MONTHLY",
+	"year":  "YEARLY",
+}
+
+// BuildFeed collects the user's tracker due dates, subscription renewals and family vacations.
+// Due dates are all-day events on the calendar day in loc.
+func BuildFeed(ctx context.Context, db *sqlx.DB, userID uuid.UUID, loc *time.Location) ([]Event, error) {
+	trackers, err := tracker.GetAll(ctx, db, userID)
+	if err != nil {
+		return nil, fmt.Errorf("feed trackers: %w", err)
+	}
+
+	ids := make([]uuid.UUID, len(trackers))
+	for i, t := range trackers {
+		ids[i] = t.ID
+	}
+
+	lastEntries, err := tracker.GetLastEntryTimes(ctx, db, ids)
+	if err != nil {
+		return nil, fmt.Errorf("feed last entries: %w", err)
+	}
+
+	events := []Event{}
+
+	for _, t := range trackers {
+		if !t.Show {
+			continue
+		}
+
+		switch t.Kind {
+		case "subscription":
+			rule, ok := rrule(t.Interval, t.IntervalUnit)
+			if t.StartDate == nil || !ok {
+				continue
+			}
+
+			start := dateIn(*t.StartDate, loc)
+			events = append(events, Event{
+				UID:     fmt.Sprintf("subscription-%s@cubby", t.ID),
+				Summary: fmt.Sprintf("%s renewal", t.Display),
+				Start:   start,
+				End:     start.AddDate(0, 0, 1),
+				AllDay:  true,
+				RRule:   rule,
+			})
+
+		default:
+			last, ok := lastEntries[t.ID]
+			if !ok {
+				continue
+			}
+
+			due := dateIn(tracker.NextDue(last, t.Interval, t.IntervalUnit), loc)
+			events = append(events, Event{
+				UID:         fmt.Sprintf("tracker-%s@cubby", t.ID),
+				Summary:     fmt.Sprintf("%s due", t.Display),
+				Description: fmt.Sprintf("Last %s %s (%s)", t.ActionLabel, last.In(loc).Format("2 Jan 2006"), t.FamilyName),
+				Start:       due,
+				End:         due.AddDate(0, 0, 1),
+				AllDay:      true,
+			})
+		}
+	}
+
+	families, err := user.GetUsersFamilies(ctx, db, userID)
+	if err != nil {
+		return nil, fmt.Errorf("feed families: %w", err)
+	}
+
+	if len(families) == 0 {
+		return events, nil
+	}
+
+	vacations, err := user.GetVacations(ctx, db, families)
+	if err != nil {
+		return nil, fmt.Errorf("feed vacations: %w", err)
+	}
+
+	for _, v := range vacations {
+		summary := "Vacation"
+		if v.Label != nil && *v.Label != "" {
+			summary = "Vacation: " + *v.Label
+		}
+
+		events = append(events, Event{
+			UID:     fmt.Sprintf("vacation-%s@cubby", v.ID),
+			Summary: summary,
+			Start:   v.StartDateTime,
+			End:     v.EndDateTime,
+		})
+	}
+
+	return events, nil
+}
+
+// rrule repeats every interval units, or reports false for an interval the calendar can't express.
+func rrule(interval int, unit string) (string, bool) {
+	freq, ok := rruleFreq[unit]
+	if !ok || interval <= 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", freq, interval), true
+}
+
+// dateIn truncates t to midnight of its calendar day in loc. DATE values carry no zone, so only Y/M/D matter.
+func dateIn(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
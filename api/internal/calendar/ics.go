@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	icsDate     = "20060102"
+	icsDateTime = "20060102T150405Z"
+	maxLineLen  = 75
+)
+
+// Event is one VEVENT. AllDay events use DATE values for Start/End; otherwise both are written in UTC.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	RRule       string
+}
+
+// WriteICS writes a VCALENDAR per RFC 5545: CRLF line endings, escaped text and lines folded at 75 octets.
+func WriteICS(w io.Writer, name string, events []Event) error {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format(icsDateTime)
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//Cubby//Cubby Calendar//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:"+escapeText(name))
+	writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeLine(&b, "X-PUBLISHED-TTL:PT1H")
+
+	for _, e := range events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, "DTSTAMP:"+stamp)
+
+		if e.AllDay {
+			writeLine(&b, "DTSTART;VALUE=DATE:"+e.Start.Format(icsDate))
+			writeLine(&b, "DTEND;VALUE=DATE:"+e.End.Format(icsDate))
+		} else {
+			writeLine(&b, "DTSTART:"+e.Start.UTC().Format(icsDateTime))
+			writeLine(&b, "DTEND:"+e.End.UTC().Format(icsDateTime))
+		}
+
+		if e.RRule != "" {
+			writeLine(&b, "RRULE:"+e.RRule)
+		}
+
+		writeLine(&b, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		writeLine(&b, "TRANSP:TRANSPARENT")
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write ics: %w", err)
+	}
+
+	return nil
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine folds long content lines with CRLF + space, never splitting a UTF-8 sequence.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineLen
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineLen - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,130 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Towels", "Towels"},
+		{"Eggs, milk", `Eggs\, milk`},
+		{"Wash; dry", `Wash\; dry`},
+		{`C:\path`, `C:\\path`},
+		{"one\ntwo", `one\ntwo`},
+		{"one\r\ntwo", `one\ntwo`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLineFolds(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Towels due"},
+		{"exactly 75", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"76", "SUMMARY:" + strings.Repeat("a", 68)},
+		{"several folds", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("洗濯", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeLine(&b, tt.line)
+			out := b.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("%q doesn't end in CRLF", out)
+			}
+
+			physical := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, l := range physical {
+				if len(l) > maxLineLen {
+					t.Errorf("line %d is %d octets, want at most %d", i, len(l), maxLineLen)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d doesn't start with a space", i)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 sequence", i)
+				}
+			}
+
+			if len(tt.line) <= maxLineLen && len(physical) != 1 {
+				t.Errorf("folded a %d octet line", len(tt.line))
+			}
+
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestRRule(t *testing.T) {
+	tests := []struct {
+		interval int
+		unit     string
+		want     string
+		ok       bool
+	}{
+		{1, "day", "FREQ=DAILY;INTERVAL=1", true},
+		{3, "month", "FREQ=MONTHLY;INTERVAL=3", true},
+		{1, "year", "FREQ=YEARLY;INTERVAL=1", true},
+		{2, "week", "", false},
+		{0, "month", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := rrule(tt.interval, tt.unit)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rrule(%d, %q) = %q, %v, want %q, %v", tt.interval, tt.unit, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := WriteICS(&buf, "Home, sweet home", []Event{{
+		UID:     "subscription-1@cubby",
+		Summary: "Netflix; renewal",
+		Start:   start,
+		End:     start.AddDate(0, 0, 1),
+		AllDay:  true,
+		RRule:   "FREQ=MONTHLY;INTERVAL=1",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"X-WR-CALNAME:Home\\, sweet home\r\n",
+		"DTSTART;VALUE=DATE:20260301\r\n",
+		"DTEND;VALUE=DATE:20260302\r\n",
+		"RRULE:FREQ=MONTHLY;INTERVAL=1\r\n",
+		"SUMMARY:Netflix\\; renewal\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Error("output has a bare LF")
+	}
+}
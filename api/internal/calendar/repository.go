@@ -0,0 +1,38 @@
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs calendar feed and token queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) BuildFeed(ctx context.Context, userID uuid.UUID, loc *time.Location) ([]Event, error) {
+	return BuildFeed(ctx, r.db, userID, loc)
+}
+
+func (r *Repository) GetTokenInfo(ctx context.Context, userID uuid.UUID) (TokenInfo, error) {
+	return GetTokenInfo(ctx, r.db, userID)
+}
+
+func (r *Repository) LookupToken(ctx context.Context, token string) (uuid.UUID, error) {
+	return LookupToken(ctx, r.db, token)
+}
+
+func (r *Repository) RevokeToken(ctx context.Context, userID uuid.UUID) error {
+	return RevokeToken(ctx, r.db, userID)
+}
+
+func (r *Repository) RotateToken(ctx context.Context, userID uuid.UUID) (NewToken, error) {
+	return RotateToken(ctx, r.db, userID)
+}
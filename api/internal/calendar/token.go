@@ -0,0 +1,94 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/secret"
+)
+
+type TokenInfo struct {
+	Exists         bool       `json:"exists"`
+	CreatedAt      *time.Time `json:"createdAt"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
+}
+
+type NewToken struct {
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// RotateToken issues a new feed token for the user, invalidating any previous one.
+func RotateToken(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (NewToken, error) {
+	token, err := secret.New()
+	if err != nil {
+		return NewToken{}, fmt.Errorf("calendar token: %w", err)
+	}
+
+	q := `INSERT INTO calendar_tokens (user_id, token_hash)
+			VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET
+				token_hash = EXCLUDED.token_hash,
+				last_accessed_at = NULL,
+				created_at = NOW(),
+				updated_at = NOW()`
+
+	if _, err := db.ExecContext(ctx, q, userID, secret.Hash(token)); err != nil {
+		return NewToken{}, fmt.Errorf("rotate calendar token: %w", err)
+	}
+
+	return NewToken{Token: token, Path: "/calendar/" + token + ".ics"}, nil
+}
+
+func RevokeToken(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error {
+	q := `DELETE FROM calendar_tokens WHERE user_id = $1`
+
+	if _, err := db.ExecContext(ctx, q, userID); err != nil {
+		return fmt.Errorf("revoke calendar token: %w", err)
+	}
+
+	return nil
+}
+
+func GetTokenInfo(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (TokenInfo, error) {
+	var info TokenInfo
+
+	q := `SELECT created_at, last_accessed_at FROM calendar_tokens WHERE user_id = $1`
+
+	rows, err := db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return info, fmt.Errorf("get calendar token: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		info.Exists = true
+		if err := rows.Scan(&info.CreatedAt, &info.LastAccessedAt); err != nil {
+			return info, fmt.Errorf("scan calendar token: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return info, fmt.Errorf("calendar token rows: %w", err)
+	}
+
+	return info, nil
+}
+
+// LookupToken resolves a feed token to its user and stamps the access time.
+func LookupToken(ctx context.Context, db *sqlx.DB, token string) (uuid.UUID, error) {
+	var userID uuid.UUID
+
+	q := `UPDATE calendar_tokens SET last_accessed_at = NOW()
+			WHERE token_hash = $1
+			RETURNING user_id`
+
+	if err := db.GetContext(ctx, &userID, q, secret.Hash(token)); err != nil {
+		return userID, fmt.Errorf("lookup calendar token: %w", err)
+	}
+
+	return userID, nil
+}
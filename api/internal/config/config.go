@@ -0,0 +1,208 @@
+// Package config loads the settings shared by the API and the database tools into one struct. Every field is read
+// from the environment variable in its env tag, which can also come from a .env file or be overridden by a flag of
+// the same name in kebab case (DB_HOST is --db-host).
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/zachczx/cubby/api/internal/ratelimit"
+)
+
+type Config struct {
+	Env          string `env:"ENV" usage:"\"development\" relaxes cookies, webhook URLs and logging for local use"`
+	Port         int    `env:"API_LISTEN_ADDR" default:"7002" usage:"port the API listens on"`
+	MetricsAddr  string `env:"METRICS_LISTEN_ADDR" usage:"host:port serving /metrics and the detailed /readyz, off when empty"`
+	PublicWebURL string `env:"PUBLIC_WEB_URL" usage:"web app URL used in redirects and emails"`
+	CookieDomain string `env:"COOKIE_DOMAIN" usage:"domain set on session cookies"`
+
+	CORS      CORS
+	DB        DB
+	Auth      Auth
+	Firebase  Firebase
+	SMTP      SMTP
+	RateLimit RateLimit
+	Tracing   Tracing
+}
+
+// CORS lists the origins allowed to call the API with credentials, one variable per client.
+type CORS struct {
+	Dev     string `env:"CORS_DEV" usage:"web dev server origin"`
+	Web     string `env:"CORS_WEB" usage:"web app origin"`
+	DevAlt  string `env:"CORS_DEV_ALT" usage:"alternate dev origin"`
+	ProdApp string `env:"CORS_PROD_APP" usage:"mobile app origin"`
+}
+
+type DB struct {
+	Host     string `env:"DB_HOST" usage:"Postgres host"`
+	Port     int    `env:"DB_PORT" default:"5432" usage:"Postgres port"`
+	User     string `env:"DB_USER" usage:"Postgres user"`
+	Password string `env:"DB_PASSWORD" secret:"true" usage:"Postgres password"`
+	Name     string `env:"DB_NAME" usage:"Postgres database"`
+}
+
+type Auth struct {
+	Provider        string `env:"AUTH_PROVIDER" default:"stytch" usage:"\"stytch\" or \"local\""`
+	StytchProjectID string `env:"STYTCH_PROJECT_ID" usage:"Stytch project ID"`
+	StytchSecret    string `env:"STYTCH_SECRET" secret:"true" usage:"Stytch secret"`
+	LocalSecret     string `env:"AUTH_LOCAL_SECRET" secret:"true" usage:"key signing local sessions, random per start when empty"`
+	CallbackURL     string `env:"AUTH_CALLBACK_URL" usage:"magic link target for the local provider"`
+}
+
+type Firebase struct {
+	ProjectID       string `env:"FIREBASE_PROJECT_ID" usage:"Firebase project for push notifications"`
+	CredentialsJSON string `env:"FIREBASE_CREDENTIALS_JSON" secret:"true" usage:"service account JSON"`
+}
+
+// SMTP is optional: without a host, mail is written to the log instead.
+type SMTP struct {
+	Host     string `env:"SMTP_HOST" usage:"mail server, log-only mailer when empty"`
+	Port     int    `env:"SMTP_PORT" default:"587" usage:"mail server port"`
+	Username string `env:"SMTP_USERNAME" usage:"mail server user"`
+	Password string `env:"SMTP_PASSWORD" secret:"true" usage:"mail server password"`
+	From     string `env:"SMTP_FROM" usage:"sender address"`
+}
+
+type RateLimit struct {
+	Store          string          `env:"RATE_LIMIT_STORE" default:"memory" usage:"\"memory\", or \"postgres\" to share counts across replicas"`
+	TrustForwarded bool            `env:"RATE_LIMIT_TRUST_FORWARDED" usage:"take the client IP from X-Forwarded-For"`
+	AuthIP         ratelimit.Limit `env:"RATE_LIMIT_AUTH_IP" default:"30/15m" usage:"links and codes sent per client IP"`
+	AuthEmail      ratelimit.Limit `env:"RATE_LIMIT_AUTH_EMAIL" default:"5/15m" usage:"links and codes sent per email"`
+	VerifyIP       ratelimit.Limit `env:"RATE_LIMIT_VERIFY_IP" default:"30/15m" usage:"code checks per client IP"`
+	OTPVerify      ratelimit.Limit `env:"RATE_LIMIT_OTP_VERIFY" default:"5/15m" usage:"code guesses per OTP from one client IP"`
+}
+
+// Tracing uses the standard OpenTelemetry variable names so existing collector setups carry over.
+type Tracing struct {
+	Endpoint       string `env:"OTEL_EXPORTER_OTLP_ENDPOINT" usage:"OTLP/HTTP base URL, tracing is off when this and the traces endpoint are empty"`
+	TracesEndpoint string `env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT" usage:"full OTLP/HTTP traces URL"`
+	Headers        string `env:"OTEL_EXPORTER_OTLP_HEADERS" secret:"true" usage:"key=value pairs sent with exports"`
+	ServiceName    string `env:"OTEL_SERVICE_NAME" default:"cubby-api" usage:"service.name on exported spans"`
+}
+
+func (c Config) Development() bool {
+	return c.Env == "development"
+}
+
+// AllowedOrigins skips unset origins so an empty Origin header can't match.
+func (c Config) AllowedOrigins() []string {
+	var origins []string
+	for _, o := range []string{c.CORS.Dev, c.CORS.Web, c.CORS.DevAlt, c.CORS.ProdApp} {
+		if o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+func (c Config) ListenAddr() string {
+	return ":" + strconv.Itoa(c.Port)
+}
+
+func (t Tracing) Enabled() bool {
+	return t.Endpoint != "" || t.TracesEndpoint != ""
+}
+
+// URL is the connection string for pgx.
+func (d DB) URL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.User, d.Password),
+		Host:     net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:     d.Name,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+// Validate checks everything the API needs to start and reports every problem at once.
+func (c Config) Validate() error {
+	errs := []error{c.DB.Validate()}
+
+	if !validPort(c.Port) {
+		errs = append(errs, fmt.Errorf("API_LISTEN_ADDR must be a port between 1 and 65535, got %d", c.Port))
+	}
+
+	if c.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(c.MetricsAddr); err != nil {
+			errs = append(errs, fmt.Errorf("METRICS_LISTEN_ADDR must be host:port: %w", err))
+		}
+	}
+
+	if c.PublicWebURL == "" {
+		errs = append(errs, errors.New("PUBLIC_WEB_URL is required"))
+	} else if !absoluteURL(c.PublicWebURL) {
+		errs = append(errs, fmt.Errorf("PUBLIC_WEB_URL must be an absolute URL, got %q", c.PublicWebURL))
+	}
+
+	switch c.Auth.Provider {
+	case "stytch":
+		if c.Auth.StytchProjectID == "" || c.Auth.StytchSecret == "" {
+			errs = append(errs, errors.New("STYTCH_PROJECT_ID and STYTCH_SECRET are required when AUTH_PROVIDER is stytch"))
+		}
+	case "local":
+		if c.Auth.CallbackURL != "" && !absoluteURL(c.Auth.CallbackURL) {
+			errs = append(errs, fmt.Errorf("AUTH_CALLBACK_URL must be an absolute URL, got %q", c.Auth.CallbackURL))
+		}
+		// Without a mail server codes and links are only logged, and a random key logs everyone out on restart.
+		if !c.Development() && (c.SMTP.Host == "" || c.Auth.LocalSecret == "") {
+			errs = append(errs, errors.New("SMTP_HOST and AUTH_LOCAL_SECRET are required when AUTH_PROVIDER is local outside development"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("AUTH_PROVIDER must be stytch or local, got %q", c.Auth.Provider))
+	}
+
+	if c.Firebase.CredentialsJSON != "" && !json.Valid([]byte(c.Firebase.CredentialsJSON)) {
+		errs = append(errs, errors.New("FIREBASE_CREDENTIALS_JSON is not valid JSON"))
+	}
+
+	if c.SMTP.Host != "" {
+		if !validPort(c.SMTP.Port) {
+			errs = append(errs, fmt.Errorf("SMTP_PORT must be a port between 1 and 65535, got %d", c.SMTP.Port))
+		}
+		if c.SMTP.From == "" {
+			errs = append(errs, errors.New("SMTP_FROM is required when SMTP_HOST is set"))
+		}
+	}
+
+	if c.RateLimit.Store != "memory" && c.RateLimit.Store != "postgres" {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be memory or postgres, got %q", c.RateLimit.Store))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate is all the database tools check, since they don't serve requests.
+func (d DB) Validate() error {
+	var errs []error
+
+	for _, v := range []struct{ name, value string }{
+		{"DB_HOST", d.Host},
+		{"DB_USER", d.User},
+		{"DB_NAME", d.Name},
+	} {
+		if v.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", v.name))
+		}
+	}
+
+	if !validPort(d.Port) {
+		errs = append(errs, fmt.Errorf("DB_PORT must be a port between 1 and 65535, got %d", d.Port))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validPort(p int) bool {
+	return p > 0 && p <= 65535
+}
+
+func absoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, args ...string) (Config, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return Load(f)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "test.env")
+	body := "DB_HOST=file-host\nDB_USER=file-user\nDB_NAME=file-db\nSMTP_HOST=file-smtp\n"
+	if err := os.WriteFile(envFile, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("DB_NAME", "env-db")
+	t.Setenv("SMTP_HOST", "")
+
+	c, err := load(t, "--env-file", envFile, "--db-name", "flag-db", "--rate-limit-auth-ip", "10/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name, got, want string
+	}{
+		{"file beats default", c.DB.Host, "file-host"},
+		{"env beats file", c.DB.User, "env-user"},
+		{"flag beats env", c.DB.Name, "flag-db"},
+		{"empty env falls through to file", c.SMTP.Host, "file-smtp"},
+		{"default", c.Auth.Provider, "stytch"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if c.Port != 7002 || c.DB.Port != 5432 {
+		t.Errorf("ports = %d, %d, want the defaults", c.Port, c.DB.Port)
+	}
+	if c.RateLimit.AuthIP.Requests != 10 || c.RateLimit.AuthIP.Window != time.Minute {
+		t.Errorf("auth IP limit = %v, want 10/1m", c.RateLimit.AuthIP)
+	}
+}
+
+func TestLoadReportsBadValues(t *testing.T) {
+	t.Setenv("API_LISTEN_ADDR", "http")
+	t.Setenv("RATE_LIMIT_OTP_VERIFY", "lots")
+
+	_, err := load(t, "--env-file", os.DevNull)
+	if err == nil {
+		t.Fatal("want an error")
+	}
+
+	for _, name := range []string{"API_LISTEN_ADDR", "RATE_LIMIT_OTP_VERIFY"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q doesn't mention %s", err, name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Config{
+		Port:         7002,
+		PublicWebURL: "https://cubby.example",
+		DB:           DB{Host: "db", Port: 5432, User: "cubby", Name: "cubby"},
+		Auth:         Auth{Provider: "stytch", StytchProjectID: "project", StytchSecret: "secret"},
+		RateLimit:    RateLimit{Store: "memory"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	c := valid
+	c.DB.Host = ""
+	c.Auth.StytchSecret = ""
+	c.SMTP.Host = "smtp.example"
+	c.RateLimit.Store = "redis"
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("want an error")
+	}
+
+	for _, want := range []string{"DB_HOST is required", "STYTCH_SECRET", "SMTP_FROM", "RATE_LIMIT_STORE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %s", err, want)
+		}
+	}
+
+	local := valid
+	local.Auth = Auth{Provider: "local"}
+	if err := local.Validate(); err == nil || !strings.Contains(err.Error(), "AUTH_LOCAL_SECRET") {
+		t.Errorf("local auth without mail or secret outside development: err = %v", err)
+	}
+
+	local.Env = "development"
+	if err := local.Validate(); err != nil {
+		t.Errorf("local auth in development: %v", err)
+	}
+
+	local.Env = ""
+	local.Auth.LocalSecret = "secret"
+	local.SMTP = SMTP{Host: "smtp.example", Port: 587, From: "cubby@example.com"}
+	if err := local.Validate(); err != nil {
+		t.Errorf("local auth with mail and secret: %v", err)
+	}
+
+	// The database tools only need the DB section.
+	if err := (DB{Host: "db", Port: 5432, User: "cubby", Name: "cubby"}).Validate(); err != nil {
+		t.Errorf("DB.Validate: %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	c := Config{DB: DB{Host: "db", Password: "hunter2"}, SMTP: SMTP{Port: 587}}
+
+	var buf bytes.Buffer
+	if err := c.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "hunter2") {
+		t.Fatalf("password printed:\n%s", out)
+	}
+	for _, want := range []string{"DB_HOST=db\n", "DB_PASSWORD=<redacted>\n", "STYTCH_SECRET=\n", "SMTP_PORT=587\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Flags are the command-line options every command registers: --env-file, --print-config, and one override per
+// config variable.
+type Flags struct {
+	EnvFile     string
+	PrintConfig bool
+	overrides   map[string]string
+}
+
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{overrides: map[string]string{}}
+
+	fs.StringVar(&f.EnvFile, "env-file", "", "read variables from this file instead of the nearest .env")
+	fs.BoolVar(&f.PrintConfig, "print-config", false, "print the resolved configuration with secrets redacted and exit")
+
+	for _, v := range variables(reflect.ValueOf(&Config{}).Elem()) {
+		name := v.env
+		fs.Func(flagName(name), v.usage+" ("+name+")", func(s string) error {
+			f.overrides[name] = s
+			return nil
+		})
+	}
+
+	return f
+}
+
+// Load resolves every variable from, in order of precedence: its flag, the process environment, the env file, and
+// its default. An empty environment variable counts as unset, as it did when each package read its own.
+func Load(f *Flags) (Config, error) {
+	file, err := readEnvFile(f.EnvFile)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var c Config
+	var errs []error
+
+	for _, v := range variables(reflect.ValueOf(&c).Elem()) {
+		raw, ok := f.overrides[v.env]
+		if !ok {
+			raw = os.Getenv(v.env)
+		}
+		if raw == "" {
+			raw = file[v.env]
+		}
+		if raw == "" {
+			raw = v.def
+		}
+		if raw == "" {
+			continue
+		}
+
+		if err := set(v.field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.env, err))
+		}
+	}
+
+	return c, errors.Join(errs...)
+}
+
+// Print writes one NAME=value line per variable, with secrets that are set shown as a placeholder.
+func (c Config) Print(w io.Writer) error {
+	for _, v := range variables(reflect.ValueOf(&c).Elem()) {
+		value := fmt.Sprint(v.field.Interface())
+		if v.secret && !v.field.IsZero() {
+			value = "<redacted>"
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.env, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type variable struct {
+	env    string
+	def    string
+	usage  string
+	secret bool
+	field  reflect.Value
+}
+
+// variables lists the tagged fields of the struct in v, descending into untagged nested structs.
+func variables(v reflect.Value) []variable {
+	var out []variable
+
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		env, ok := sf.Tag.Lookup("env")
+		if !ok {
+			if sf.Type.Kind() == reflect.Struct {
+				out = append(out, variables(v.Field(i))...)
+			}
+			continue
+		}
+
+		out = append(out, variable{
+			env:    env,
+			def:    sf.Tag.Get("default"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			field:  v.Field(i),
+		})
+	}
+
+	return out
+}
+
+func set(field reflect.Value, raw string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		field.SetBool(b)
+	default:
+		panic("config: unsupported field type " + field.Type().String())
+	}
+
+	return nil
+}
+
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// readEnvFile reads path, which must exist if given. Otherwise it looks for .env in the working directory and its
+// parents up to the repository root, so the commands find the same file whether run from the repo or from api/.
+// Not finding one is fine, since deployments set the environment directly.
+func readEnvFile(path string) (map[string]string, error) {
+	if path != "" {
+		vars, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("read env file: %w", err)
+		}
+		return vars, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("find env file: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".env")
+		if _, err := os.Stat(candidate); err == nil {
+			vars, err := godotenv.Read(candidate)
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", candidate, err)
+			}
+			return vars, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || isRepoRoot(dir) {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func isRepoRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/telemetry"
+)
+
+// Connect opens the API's pool through pgx with telemetry.QueryTracer attached, so every query is timed and traced.
+func Connect(dsn string) (*sqlx.DB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse database url: %w", err)
+	}
+	cfg.Tracer = telemetry.QueryTracer{}
+
+	db := sqlx.NewDb(stdlib.OpenDB(*cfg), "pgx")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect database: %w", err)
+	}
+
+	return db, nil
+}
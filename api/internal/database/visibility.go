@@ -0,0 +1,12 @@
+package database
+
+import "strings"
+
+// VisibleFamilies is a subquery for the IDs of every family the user in placeholder arg (like "$1") can see: the
+// ones they belong to and the ones they own. Owners have no families_users row, so checking membership alone hides
+// trackers other members create in their family.
+func VisibleFamilies(arg string) string {
+	return strings.ReplaceAll(`SELECT family_id FROM families_users WHERE user_id = ?
+				UNION
+				SELECT id FROM families WHERE owner_id = ?`, "?", arg)
+}
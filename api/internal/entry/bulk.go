@@ -0,0 +1,216 @@
+package entry
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/database"
+)
+
+const MaxBulkRows = 1000
+
+type BulkRow struct {
+	PerformedAt string `json:"performedAt"`
+	Remark      string `json:"remark"`
+	// Line is the CSV line the row came from, zero for JSON input.
+	Line int `json:"-"`
+}
+
+type BulkInput struct {
+	Entries      []BulkRow `json:"entries"`
+	Interval     int       `json:"interval"`
+	IntervalUnit string    `json:"intervalUnit"`
+}
+
+type BulkStatus string
+
+const (
+	BulkCreated   BulkStatus = "created"
+	BulkDuplicate BulkStatus = "duplicate"
+	BulkInvalid   BulkStatus = "invalid"
+)
+
+type BulkRowResult struct {
+	Row         int        `json:"row"`
+	Line        int        `json:"line,omitempty"`
+	PerformedAt *time.Time `json:"performedAt,omitempty"`
+	Status      BulkStatus `json:"status"`
+	EntryID     *uuid.UUID `json:"entryId,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type BulkResult struct {
+	Created    int             `json:"created"`
+	Duplicates int             `json:"duplicates"`
+	Invalid    int             `json:"invalid"`
+	Rows       []BulkRowResult `json:"rows"`
+}
+
+var bulkTimeFormats = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+// bulkHeader is the optional first line of a CSV upload, matched case-insensitively.
+var bulkHeader = []string{"performedat", "remark"}
+
+// ParseBulkCSV reads rows of "performedAt[,remark]". The first row is skipped only if it is exactly that header;
+// anything else is kept, so a malformed first entry is reported as invalid instead of vanishing.
+func ParseBulkCSV(r io.Reader) ([]BulkRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var rows []BulkRow
+
+	for first := true; ; {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+
+		if len(rec) == 0 || strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+
+		if first {
+			first = false
+			if isBulkHeader(rec) {
+				continue
+			}
+		}
+
+		line, _ := cr.FieldPos(0)
+		row := BulkRow{PerformedAt: strings.TrimSpace(rec[0]), Line: line}
+		if len(rec) > 1 {
+			row.Remark = strings.TrimSpace(rec[1])
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func isBulkHeader(rec []string) bool {
+	if len(rec) > len(bulkHeader) {
+		return false
+	}
+	for i, cell := range rec {
+		if strings.ToLower(strings.TrimSpace(cell)) != bulkHeader[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func parseBulkTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range bulkTimeFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			// Postgres stores microseconds, so dedup at the same precision.
+			return t.Truncate(time.Microsecond), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized time format %q", s)
+}
+
+// CreateBulk inserts many entries for one tracker in a single transaction.
+// Rows whose timestamp already exists for the tracker, or appears earlier in the batch, are reported as duplicates.
+func CreateBulk(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, input BulkInput) (BulkResult, error) {
+	result := BulkResult{Rows: make([]BulkRowResult, 0, len(input.Entries))}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("bulk entry begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var t struct {
+		Interval     int    `db:"interval"`
+		IntervalUnit string `db:"interval_unit"`
+	}
+
+	tQ := `SELECT interval, interval_unit FROM trackers
+			WHERE id = $1
+			AND (owner_id = $2 OR family_id IN (` + database.VisibleFamilies("$2") + `))
+			FOR UPDATE`
+
+	if err := tx.GetContext(ctx, &t, tQ, trackerID, userID); err != nil {
+		return result, fmt.Errorf("bulk entry get tracker: %w", err)
+	}
+
+	interval, intervalUnit := input.Interval, input.IntervalUnit
+	if interval == 0 || intervalUnit == "" {
+		interval, intervalUnit = t.Interval, t.IntervalUnit
+	}
+
+	var existing []time.Time
+	if err := tx.SelectContext(ctx, &existing, `SELECT performed_at FROM entries WHERE tracker_id = $1`, trackerID); err != nil {
+		return result, fmt.Errorf("bulk entry existing: %w", err)
+	}
+
+	seen := make(map[int64]bool, len(existing)+len(input.Entries))
+	for _, e := range existing {
+		seen[e.UnixMicro()] = true
+	}
+
+	iQ := `INSERT INTO entries (tracker_id, interval, interval_unit, performed_by, performed_at, remark)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`
+
+	now := time.Now()
+
+	for i, row := range input.Entries {
+		res := BulkRowResult{Row: i + 1, Line: row.Line}
+
+		performedAt, err := parseBulkTime(row.PerformedAt)
+		switch {
+		case err != nil:
+			res.Status = BulkInvalid
+			res.Error = err.Error()
+		case performedAt.After(now):
+			res.PerformedAt = &performedAt
+			res.Status = BulkInvalid
+			res.Error = "performedAt is in the future"
+		case seen[performedAt.UnixMicro()]:
+			res.PerformedAt = &performedAt
+			res.Status = BulkDuplicate
+		default:
+			var id uuid.UUID
+			if err := tx.GetContext(ctx, &id, iQ, trackerID, interval, intervalUnit, userID, performedAt, row.Remark); err != nil {
+				return result, fmt.Errorf("bulk entry insert row %d: %w", i+1, err)
+			}
+			seen[performedAt.UnixMicro()] = true
+			res.PerformedAt = &performedAt
+			res.Status = BulkCreated
+			res.EntryID = &id
+		}
+
+		switch res.Status {
+		case BulkCreated:
+			result.Created++
+		case BulkDuplicate:
+			result.Duplicates++
+		case BulkInvalid:
+			result.Invalid++
+		}
+
+		result.Rows = append(result.Rows, res)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("bulk entry commit tx: %w", err)
+	}
+
+	return result, nil
+}
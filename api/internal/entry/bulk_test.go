@@ -0,0 +1,56 @@
+package entry_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zachczx/cubby/api/internal/entry"
+)
+
+func TestParseBulkCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []entry.BulkRow
+	}{
+		{
+			name: "header skipped",
+			in:   "performedAt,remark\n2024-01-02,first\n",
+			want: []entry.BulkRow{{PerformedAt: "2024-01-02", Remark: "first", Line: 2}},
+		},
+		{
+			name: "header without remark",
+			in:   "\n PerformedAt \n2024-01-02\n",
+			want: []entry.BulkRow{{PerformedAt: "2024-01-02", Line: 3}},
+		},
+		{
+			name: "malformed first row kept",
+			in:   "2024-13-45,typo\n2024-01-02,ok\n",
+			want: []entry.BulkRow{
+				{PerformedAt: "2024-13-45", Remark: "typo", Line: 1},
+				{PerformedAt: "2024-01-02", Remark: "ok", Line: 2},
+			},
+		},
+		{
+			name: "header only on first row",
+			in:   "2024-01-02\nperformedAt,remark\n",
+			want: []entry.BulkRow{
+				{PerformedAt: "2024-01-02", Line: 1},
+				{PerformedAt: "performedAt", Remark: "remark", Line: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := entry.ParseBulkCSV(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
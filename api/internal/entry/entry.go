@@ -1,13 +1,21 @@
 package entry
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/database"
 )
 
+// ErrNotTrackerOwner is for family members who can see an entry but not change it, which only the tracker owner may.
+var ErrNotTrackerOwner = apperr.Forbidden("only the tracker owner can do this")
+
 type Entry struct {
 	ID           uuid.UUID `db:"id" json:"id"`
 	TrackerID    uuid.UUID `db:"tracker_id" json:"trackerId"`
@@ -18,6 +26,8 @@ type Entry struct {
 	Remark       string    `db:"remark" json:"remark"`
 	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+
+	CompletedItems []uuid.UUID `db:"-" json:"completedItems"`
 }
 
 type Input struct {
@@ -27,15 +37,23 @@ type Input struct {
 	Interval     int        `db:"interval" json:"interval"`
 	IntervalUnit string     `db:"interval_unit" json:"intervalUnit"`
 	Remark       string     `db:"remark" json:"remark"`
+
+	CompletedItems []uuid.UUID `db:"-" json:"completedItems"`
 }
 
-func Create(db *sqlx.DB, e Entry) (Entry, error) {
+func Create(ctx context.Context, db *sqlx.DB, e Entry) (Entry, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Entry{}, fmt.Errorf("create entry begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
 	q := `INSERT INTO entries (tracker_id, interval, interval_unit, performed_by, performed_at, remark) 
 			VALUES ($1, $2, $3, $4, $5, $6)
 			RETURNING id, tracker_id, interval, interval_unit, performed_by, performed_at, remark, created_at, updated_at`
 
 	var newE Entry
-	err := db.QueryRow(q, e.TrackerID,
+	err = tx.QueryRowContext(ctx, q, e.TrackerID,
 		e.Interval,
 		e.IntervalUnit,
 		e.PerformedBy,
@@ -56,38 +74,109 @@ func Create(db *sqlx.DB, e Entry) (Entry, error) {
 		return Entry{}, fmt.Errorf("create entry sql: %w", err)
 	}
 
+	newE.CompletedItems = []uuid.UUID{}
+
+	if len(e.CompletedItems) > 0 {
+		// Only items belonging to the entry's tracker are recorded; unknown IDs are ignored.
+		cQ := `INSERT INTO entry_checklist_items (entry_id, item_id)
+				SELECT ?::uuid, id FROM tracker_checklist_items
+				WHERE tracker_id = ? AND id IN (?)
+				RETURNING item_id`
+
+		query, args, err := sqlx.In(cQ, newE.ID, newE.TrackerID, e.CompletedItems)
+		if err != nil {
+			return Entry{}, fmt.Errorf("create entry checklist in: %w", err)
+		}
+		query = tx.Rebind(query)
+
+		if err := tx.SelectContext(ctx, &newE.CompletedItems, query, args...); err != nil {
+			return Entry{}, fmt.Errorf("create entry checklist: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Entry{}, fmt.Errorf("create entry commit tx: %w", err)
+	}
+
 	return newE, nil
 }
 
-func GetAll(db *sqlx.DB, userID uuid.UUID) ([]Entry, error) {
+func GetAll(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Entry, error) {
 	var entries []Entry
 
 	q := `SELECT * FROM entries 
 			WHERE performed_by=$1 
 			ORDER BY performed_at DESC`
 
-	if err := db.Select(&entries, q, userID); err != nil {
+	if err := db.SelectContext(ctx, &entries, q, userID); err != nil {
 		return entries, fmt.Errorf("entry query: %w", err)
 	}
 
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]uuid.UUID, len(entries))
+	eMap := make(map[uuid.UUID]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+		eMap[e.ID] = i
+		entries[i].CompletedItems = []uuid.UUID{}
+	}
+
+	query, args, err := sqlx.In(`SELECT entry_id, item_id FROM entry_checklist_items WHERE entry_id IN (?)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("entry checklist in: %w", err)
+	}
+	query = db.Rebind(query)
+
+	var completed []struct {
+		EntryID uuid.UUID `db:"entry_id"`
+		ItemID  uuid.UUID `db:"item_id"`
+	}
+	if err := db.SelectContext(ctx, &completed, query, args...); err != nil {
+		return nil, fmt.Errorf("entry checklist query: %w", err)
+	}
+
+	for _, c := range completed {
+		i := eMap[c.EntryID]
+		entries[i].CompletedItems = append(entries[i].CompletedItems, c.ItemID)
+	}
+
 	return entries, nil
 }
 
-func Delete(db *sqlx.DB, userID uuid.UUID, entryID uuid.UUID) error {
+func GetOwned(ctx context.Context, db *sqlx.DB, userID uuid.UUID, entryID uuid.UUID) (Entry, error) {
+	var e Entry
+
+	q := `SELECT entries.* FROM entries
+			JOIN trackers ON entries.tracker_id = trackers.id
+			WHERE entries.id = $1
+			AND trackers.owner_id = $2`
+
+	if err := db.GetContext(ctx, &e, q, entryID, userID); err != nil {
+		return e, notOwned(ctx, db, entryID, userID, fmt.Errorf("get entry: %w", err))
+	}
+
+	return e, nil
+}
+
+func Delete(ctx context.Context, db *sqlx.DB, userID uuid.UUID, entryID uuid.UUID) error {
 	q := `DELETE FROM entries
 			USING trackers 
 			WHERE entries.id = $1
 			AND entries.tracker_id = trackers.id
 			AND trackers.owner_id = $2`
 
-	if _, err := db.Exec(q, entryID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, entryID, userID)
+	if err != nil {
 		return fmt.Errorf("delete entry: %w", err)
 	}
 
-	return nil
+	return notOwned(ctx, db, entryID, userID, apperr.Affected(res, "entry"))
 }
 
-func Edit(db *sqlx.DB, userID uuid.UUID, entryID uuid.UUID, performedAt time.Time) error {
+func Edit(ctx context.Context, db *sqlx.DB, userID uuid.UUID, entryID uuid.UUID, performedAt time.Time) error {
 	q := `UPDATE entries
 			SET performed_at = $1 
 			FROM trackers
@@ -95,9 +184,35 @@ func Edit(db *sqlx.DB, userID uuid.UUID, entryID uuid.UUID, performedAt time.Tim
 			AND trackers.owner_id = $2
 			AND entries.id = $3`
 
-	if _, err := db.Exec(q, performedAt, userID, entryID); err != nil {
+	res, err := db.ExecContext(ctx, q, performedAt, userID, entryID)
+	if err != nil {
 		return fmt.Errorf("update entry: %w", err)
 	}
 
-	return nil
+	return notOwned(ctx, db, entryID, userID, apperr.Affected(res, "entry"))
+}
+
+// notOwned turns a miss from an owner-only query into ErrNotTrackerOwner when the user can still see the entry's
+// tracker through a family, so only entries hidden from them read as missing. Other errors pass through.
+func notOwned(ctx context.Context, db *sqlx.DB, entryID uuid.UUID, userID uuid.UUID, err error) error {
+	if !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, apperr.ErrNotFound) {
+		return err
+	}
+
+	var visible bool
+
+	q := `SELECT EXISTS(SELECT 1 FROM entries
+			JOIN trackers ON entries.tracker_id = trackers.id
+			WHERE entries.id = $1
+			AND trackers.family_id IN (` + database.VisibleFamilies("$2") + `))`
+
+	if vErr := db.GetContext(ctx, &visible, q, entryID, userID); vErr != nil {
+		return fmt.Errorf("check entry visible: %w", vErr)
+	}
+
+	if visible {
+		return ErrNotTrackerOwner
+	}
+
+	return err
 }
@@ -0,0 +1,42 @@
+package entry
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs entry queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, e Entry) (Entry, error) {
+	return Create(ctx, r.db, e)
+}
+
+func (r *Repository) CreateBulk(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, input BulkInput) (BulkResult, error) {
+	return CreateBulk(ctx, r.db, userID, trackerID, input)
+}
+
+func (r *Repository) Delete(ctx context.Context, userID uuid.UUID, entryID uuid.UUID) error {
+	return Delete(ctx, r.db, userID, entryID)
+}
+
+func (r *Repository) Edit(ctx context.Context, userID uuid.UUID, entryID uuid.UUID, performedAt time.Time) error {
+	return Edit(ctx, r.db, userID, entryID, performedAt)
+}
+
+func (r *Repository) GetAll(ctx context.Context, userID uuid.UUID) ([]Entry, error) {
+	return GetAll(ctx, r.db, userID)
+}
+
+func (r *Repository) GetOwned(ctx context.Context, userID uuid.UUID, entryID uuid.UUID) (Entry, error) {
+	return GetOwned(ctx, r.db, userID, entryID)
+}
@@ -0,0 +1,74 @@
+// Package event is an in-process bus for things that happen to a family. Handlers publish after their write has
+// succeeded, and subscribers such as webhooks fan the event out without the handler knowing who is listening.
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+// Activity feed kinds are published under the same names; these are the events that have no feed entry.
+const (
+	TrackerDue        Type = "tracker.due"
+	WorkoutCompleted  Type = "workout.completed"
+	MarketPriceLogged Type = "market.price_logged"
+)
+
+type Event struct {
+	ID         uuid.UUID      `json:"id"`
+	Type       Type           `json:"type"`
+	FamilyID   uuid.UUID      `json:"familyId"`
+	ActorID    *uuid.UUID     `json:"actorId"`
+	OccurredAt time.Time      `json:"occurredAt"`
+	Data       map[string]any `json:"data"`
+}
+
+type Handler func(ctx context.Context, e Event)
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish stamps the event and runs every subscriber in turn. Subscribers are expected to be quick and to log their
+// own failures; anything slow (like an HTTP call) belongs in a queue the subscriber drains elsewhere. A nil bus drops
+// the event, so callers don't need to check whether one is configured.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+
+	if e.ID == uuid.Nil {
+		e.ID, _ = uuid.NewV7()
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now().UTC()
+	}
+	if e.Data == nil {
+		e.Data = map[string]any{}
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
@@ -1,31 +1,32 @@
 package gym
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
-func GetFavourites(db *sqlx.DB, userID uuid.UUID) ([]string, error) {
+func GetFavourites(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]string, error) {
 	q := `SELECT exercise_id FROM gym_favourite_exercises
 			WHERE user_id = $1
 			ORDER BY created_at ASC`
 
 	var ids []string
-	if err := db.Select(&ids, q, userID); err != nil {
+	if err := db.SelectContext(ctx, &ids, q, userID); err != nil {
 		return nil, fmt.Errorf("get favourites: %w", err)
 	}
 
 	return ids, nil
 }
 
-func ToggleFavourite(db *sqlx.DB, userID uuid.UUID, exerciseID string) ([]string, error) {
+func ToggleFavourite(ctx context.Context, db *sqlx.DB, userID uuid.UUID, exerciseID string) ([]string, error) {
 	// Try to delete first; if a row was removed it was already favourited
 	delQ := `DELETE FROM gym_favourite_exercises
 			WHERE user_id = $1 AND exercise_id = $2`
 
-	result, err := db.Exec(delQ, userID, exerciseID)
+	result, err := db.ExecContext(ctx, delQ, userID, exerciseID)
 	if err != nil {
 		return nil, fmt.Errorf("toggle favourite delete: %w", err)
 	}
@@ -39,10 +40,10 @@ func ToggleFavourite(db *sqlx.DB, userID uuid.UUID, exerciseID string) ([]string
 		// Was not favourited — insert it
 		insQ := `INSERT INTO gym_favourite_exercises (user_id, exercise_id)
 				VALUES ($1, $2)`
-		if _, err := db.Exec(insQ, userID, exerciseID); err != nil {
+		if _, err := db.ExecContext(ctx, insQ, userID, exerciseID); err != nil {
 			return nil, fmt.Errorf("toggle favourite insert: %w", err)
 		}
 	}
 
-	return GetFavourites(db, userID)
+	return GetFavourites(ctx, db, userID)
 }
@@ -6,14 +6,24 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	SetWorking = "working"
+	SetDrop    = "dropset"
+	SetFailure = "failure"
+)
+
+// SetTypes lists the kinds of set the app offers; stats count failure sets separately.
+var SetTypes = []string{SetWorking, SetDrop, SetFailure}
+
 type Workout struct {
-	ID        uuid.UUID `db:"id"         json:"id"`
-	UserID    uuid.UUID `db:"user_id"    json:"userId"`
-	StartTime time.Time `db:"start_time" json:"startTime"`
-	Notes     *string   `db:"notes"      json:"notes"`
-	CreatedAt time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
-	Sets      []Set     `db:"-"          json:"sets"`
+	ID          uuid.UUID  `db:"id"           json:"id"`
+	UserID      uuid.UUID  `db:"user_id"      json:"userId"`
+	StartTime   time.Time  `db:"start_time"   json:"startTime"`
+	Notes       *string    `db:"notes"        json:"notes"`
+	CompletedAt *time.Time `db:"completed_at" json:"completedAt"`
+	CreatedAt   time.Time  `db:"created_at"   json:"createdAt"`
+	UpdatedAt   time.Time  `db:"updated_at"   json:"updatedAt"`
+	Sets        []Set      `db:"-"            json:"sets"`
 }
 
 type WorkoutInput struct {
@@ -0,0 +1,120 @@
+package gym
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository exposes the gym queries on a database handle, so callers can swap it for a fake.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) AddRoutineExercise(ctx context.Context, userID uuid.UUID, routineID uuid.UUID, input RoutineExerciseInput) (RoutineExercise, error) {
+	return AddRoutineExercise(ctx, r.db, userID, routineID, input)
+}
+
+func (r *Repository) CompleteWorkout(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID) (CompletedWorkout, bool, error) {
+	return CompleteWorkout(ctx, r.db, userID, workoutID)
+}
+
+func (r *Repository) DeleteRoutine(ctx context.Context, userID uuid.UUID, routineID uuid.UUID) error {
+	return DeleteRoutine(ctx, r.db, userID, routineID)
+}
+
+func (r *Repository) DeleteSet(ctx context.Context, userID uuid.UUID, setID uuid.UUID) error {
+	return DeleteSet(ctx, r.db, userID, setID)
+}
+
+func (r *Repository) DeleteWorkout(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID) error {
+	return DeleteWorkout(ctx, r.db, userID, workoutID)
+}
+
+func (r *Repository) EditRoutine(ctx context.Context, userID uuid.UUID, routineID uuid.UUID, input RoutineInput) error {
+	return EditRoutine(ctx, r.db, userID, routineID, input)
+}
+
+func (r *Repository) EditRoutineExercise(ctx context.Context, userID uuid.UUID, exerciseID uuid.UUID, input RoutineExerciseInput) error {
+	return EditRoutineExercise(ctx, r.db, userID, exerciseID, input)
+}
+
+func (r *Repository) EditSet(ctx context.Context, userID uuid.UUID, setID uuid.UUID, s SetInput) error {
+	return EditSet(ctx, r.db, userID, setID, s)
+}
+
+func (r *Repository) EditWorkout(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID, w WorkoutInput) error {
+	return EditWorkout(ctx, r.db, userID, workoutID, w)
+}
+
+func (r *Repository) GetAllRoutines(ctx context.Context, userID uuid.UUID) ([]Routine, error) {
+	return GetAllRoutines(ctx, r.db, userID)
+}
+
+func (r *Repository) GetAllWorkouts(ctx context.Context, userID uuid.UUID) ([]Workout, error) {
+	return GetAllWorkouts(ctx, r.db, userID)
+}
+
+func (r *Repository) GetCalendarWorkouts(ctx context.Context, userID uuid.UUID) ([]WorkoutCalendarEntry, error) {
+	return GetCalendarWorkouts(ctx, r.db, userID)
+}
+
+func (r *Repository) GetExerciseStats(ctx context.Context, userID uuid.UUID, exerciseID string) ([]ExerciseSetStats, error) {
+	return GetExerciseStats(ctx, r.db, userID, exerciseID)
+}
+
+func (r *Repository) GetFavourites(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return GetFavourites(ctx, r.db, userID)
+}
+
+func (r *Repository) GetMusclesFailureStats(ctx context.Context, userID uuid.UUID, weeks int) ([]ExerciseFailureStats, error) {
+	return GetMusclesFailureStats(ctx, r.db, userID, weeks)
+}
+
+func (r *Repository) GetSummary(ctx context.Context, userID uuid.UUID) (WorkoutSummary, error) {
+	return GetSummary(ctx, r.db, userID)
+}
+
+func (r *Repository) GetUserExercises(ctx context.Context, userID uuid.UUID) ([]UserExercise, error) {
+	return GetUserExercises(ctx, r.db, userID)
+}
+
+func (r *Repository) NewRoutine(ctx context.Context, userID uuid.UUID, input RoutineInput) (Routine, error) {
+	return NewRoutine(ctx, r.db, userID, input)
+}
+
+func (r *Repository) NewSet(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID, s SetInput) (Set, error) {
+	return NewSet(ctx, r.db, userID, workoutID, s)
+}
+
+func (r *Repository) NewWorkout(ctx context.Context, userID uuid.UUID) (Workout, error) {
+	return NewWorkout(ctx, r.db, userID)
+}
+
+func (r *Repository) RemoveRoutineExercise(ctx context.Context, userID uuid.UUID, exerciseID uuid.UUID) error {
+	return RemoveRoutineExercise(ctx, r.db, userID, exerciseID)
+}
+
+func (r *Repository) ReorderRoutine(ctx context.Context, userID uuid.UUID, input ReorderRoutineInput) error {
+	return ReorderRoutine(ctx, r.db, userID, input)
+}
+
+func (r *Repository) ReorderRoutineExercise(ctx context.Context, userID uuid.UUID, input ReorderRoutineExerciseInput) error {
+	return ReorderRoutineExercise(ctx, r.db, userID, input)
+}
+
+func (r *Repository) ReorderSet(ctx context.Context, userID uuid.UUID, input ReorderSetInput) error {
+	return ReorderSet(ctx, r.db, userID, input)
+}
+
+func (r *Repository) StartWorkoutFromRoutine(ctx context.Context, userID uuid.UUID, routineID uuid.UUID) (Workout, error) {
+	return StartWorkoutFromRoutine(ctx, r.db, userID, routineID)
+}
+
+func (r *Repository) ToggleFavourite(ctx context.Context, userID uuid.UUID, exerciseID string) ([]string, error) {
+	return ToggleFavourite(ctx, r.db, userID, exerciseID)
+}
@@ -1,19 +1,21 @@
 package gym
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
-func GetAllRoutines(db *sqlx.DB, userID uuid.UUID) ([]Routine, error) {
+func GetAllRoutines(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Routine, error) {
 	rq := `SELECT * FROM gym_routines
 			WHERE user_id = $1
 			ORDER BY position ASC, created_at ASC`
 
 	var routines []Routine
-	if err := db.Select(&routines, rq, userID); err != nil {
+	if err := db.SelectContext(ctx, &routines, rq, userID); err != nil {
 		return nil, fmt.Errorf("get all routines: %w", err)
 	}
 
@@ -40,7 +42,7 @@ func GetAllRoutines(db *sqlx.DB, userID uuid.UUID) ([]Routine, error) {
 	query = db.Rebind(query)
 
 	var exercises []RoutineExercise
-	if err := db.Select(&exercises, query, args...); err != nil {
+	if err := db.SelectContext(ctx, &exercises, query, args...); err != nil {
 		return nil, fmt.Errorf("get all routines exercises: %w", err)
 	}
 
@@ -52,13 +54,13 @@ func GetAllRoutines(db *sqlx.DB, userID uuid.UUID) ([]Routine, error) {
 	return routines, nil
 }
 
-func NewRoutine(db *sqlx.DB, userID uuid.UUID, input RoutineInput) (Routine, error) {
+func NewRoutine(ctx context.Context, db *sqlx.DB, userID uuid.UUID, input RoutineInput) (Routine, error) {
 	q := `INSERT INTO gym_routines (user_id, name, position)
 			VALUES ($1, $2, COALESCE((SELECT MAX(position) + 1 FROM gym_routines WHERE user_id = $1), 0))
 			RETURNING id, user_id, name, position, created_at, updated_at`
 
 	var r Routine
-	err := db.QueryRow(q, userID, input.Name).Scan(
+	err := db.QueryRowContext(ctx, q, userID, input.Name).Scan(
 		&r.ID, &r.UserID, &r.Name, &r.Position, &r.CreatedAt, &r.UpdatedAt,
 	)
 	if err != nil {
@@ -69,22 +71,23 @@ func NewRoutine(db *sqlx.DB, userID uuid.UUID, input RoutineInput) (Routine, err
 	return r, nil
 }
 
-func EditRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID, input RoutineInput) error {
+func EditRoutine(ctx context.Context, db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID, input RoutineInput) error {
 	q := `UPDATE gym_routines
 			SET name = $1, updated_at = NOW()
 			WHERE id = $2 AND user_id = $3`
 
-	if _, err := db.Exec(q, input.Name, routineID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, input.Name, routineID, userID)
+	if err != nil {
 		return fmt.Errorf("edit routine: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "routine")
 }
 
-func ReorderRoutine(db *sqlx.DB, userID uuid.UUID, input ReorderRoutineInput) error {
+func ReorderRoutine(ctx context.Context, db *sqlx.DB, userID uuid.UUID, input ReorderRoutineInput) error {
 	var current Routine
 	q := `SELECT * FROM gym_routines WHERE id = $1 AND user_id = $2`
-	if err := db.Get(&current, q, input.RoutineID, userID); err != nil {
+	if err := db.GetContext(ctx, &current, q, input.RoutineID, userID); err != nil {
 		return fmt.Errorf("reorder routine get current: %w", err)
 	}
 
@@ -99,32 +102,33 @@ func ReorderRoutine(db *sqlx.DB, userID uuid.UUID, input ReorderRoutineInput) er
 				WHERE user_id = $1 AND position > $2
 				ORDER BY position ASC LIMIT 1`
 	}
-	if err := db.Get(&neighbor, nq, userID, current.Position); err != nil {
+	if err := db.GetContext(ctx, &neighbor, nq, userID, current.Position); err != nil {
 		return fmt.Errorf("reorder routine get neighbor: %w", err)
 	}
 
 	swapQ := `UPDATE gym_routines SET position = $1::smallint, updated_at = NOW() WHERE id = $2`
-	if _, err := db.Exec(swapQ, neighbor.Position, current.ID); err != nil {
+	if _, err := db.ExecContext(ctx, swapQ, neighbor.Position, current.ID); err != nil {
 		return fmt.Errorf("reorder routine swap current: %w", err)
 	}
-	if _, err := db.Exec(swapQ, current.Position, neighbor.ID); err != nil {
+	if _, err := db.ExecContext(ctx, swapQ, current.Position, neighbor.ID); err != nil {
 		return fmt.Errorf("reorder routine swap neighbor: %w", err)
 	}
 
 	return nil
 }
 
-func DeleteRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID) error {
+func DeleteRoutine(ctx context.Context, db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID) error {
 	q := `DELETE FROM gym_routines WHERE id = $1 AND user_id = $2`
 
-	if _, err := db.Exec(q, routineID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, routineID, userID)
+	if err != nil {
 		return fmt.Errorf("delete routine: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "routine")
 }
 
-func AddRoutineExercise(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID, input RoutineExerciseInput) (RoutineExercise, error) {
+func AddRoutineExercise(ctx context.Context, db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID, input RoutineExerciseInput) (RoutineExercise, error) {
 	q := `INSERT INTO gym_routine_exercises (routine_id, exercise_id, sets, position)
 			SELECT $1, $2, $3,
 				COALESCE((SELECT MAX(position) + 1 FROM gym_routine_exercises WHERE routine_id = $1), 0)
@@ -133,7 +137,7 @@ func AddRoutineExercise(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID, inpu
 			RETURNING id, routine_id, exercise_id, sets, position, created_at, updated_at`
 
 	var e RoutineExercise
-	err := db.QueryRow(q, routineID, input.ExerciseID, input.Sets, userID).Scan(
+	err := db.QueryRowContext(ctx, q, routineID, input.ExerciseID, input.Sets, userID).Scan(
 		&e.ID, &e.RoutineID, &e.ExerciseID, &e.Sets, &e.Position, &e.CreatedAt, &e.UpdatedAt,
 	)
 	if err != nil {
@@ -143,7 +147,7 @@ func AddRoutineExercise(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID, inpu
 	return e, nil
 }
 
-func EditRoutineExercise(db *sqlx.DB, userID uuid.UUID, exerciseID uuid.UUID, input RoutineExerciseInput) error {
+func EditRoutineExercise(ctx context.Context, db *sqlx.DB, userID uuid.UUID, exerciseID uuid.UUID, input RoutineExerciseInput) error {
 	q := `UPDATE gym_routine_exercises
 			SET sets = $1, updated_at = NOW()
 			FROM gym_routines
@@ -151,33 +155,35 @@ func EditRoutineExercise(db *sqlx.DB, userID uuid.UUID, exerciseID uuid.UUID, in
 			AND gym_routine_exercises.routine_id = gym_routines.id
 			AND gym_routines.user_id = $3`
 
-	if _, err := db.Exec(q, input.Sets, exerciseID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, input.Sets, exerciseID, userID)
+	if err != nil {
 		return fmt.Errorf("edit routine exercise: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "routine exercise")
 }
 
-func RemoveRoutineExercise(db *sqlx.DB, userID uuid.UUID, exerciseID uuid.UUID) error {
+func RemoveRoutineExercise(ctx context.Context, db *sqlx.DB, userID uuid.UUID, exerciseID uuid.UUID) error {
 	q := `DELETE FROM gym_routine_exercises
 			USING gym_routines
 			WHERE gym_routine_exercises.id = $1
 			AND gym_routine_exercises.routine_id = gym_routines.id
 			AND gym_routines.user_id = $2`
 
-	if _, err := db.Exec(q, exerciseID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, exerciseID, userID)
+	if err != nil {
 		return fmt.Errorf("remove routine exercise: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "routine exercise")
 }
 
-func ReorderRoutineExercise(db *sqlx.DB, userID uuid.UUID, input ReorderRoutineExerciseInput) error {
+func ReorderRoutineExercise(ctx context.Context, db *sqlx.DB, userID uuid.UUID, input ReorderRoutineExerciseInput) error {
 	var current RoutineExercise
 	q := `SELECT gre.* FROM gym_routine_exercises gre
 			JOIN gym_routines gr ON gre.routine_id = gr.id
 			WHERE gre.id = $1 AND gr.user_id = $2`
-	if err := db.Get(&current, q, input.ExerciseID, userID); err != nil {
+	if err := db.GetContext(ctx, &current, q, input.ExerciseID, userID); err != nil {
 		return fmt.Errorf("reorder routine exercise get current: %w", err)
 	}
 
@@ -192,23 +198,23 @@ func ReorderRoutineExercise(db *sqlx.DB, userID uuid.UUID, input ReorderRoutineE
 				WHERE routine_id = $1 AND position > $2
 				ORDER BY position ASC LIMIT 1`
 	}
-	if err := db.Get(&neighbor, nq, current.RoutineID, current.Position); err != nil {
+	if err := db.GetContext(ctx, &neighbor, nq, current.RoutineID, current.Position); err != nil {
 		return fmt.Errorf("reorder routine exercise get neighbor: %w", err)
 	}
 
 	swapQ := `UPDATE gym_routine_exercises SET position = $1::smallint, updated_at = NOW() WHERE id = $2`
-	if _, err := db.Exec(swapQ, neighbor.Position, current.ID); err != nil {
+	if _, err := db.ExecContext(ctx, swapQ, neighbor.Position, current.ID); err != nil {
 		return fmt.Errorf("reorder routine exercise swap current: %w", err)
 	}
-	if _, err := db.Exec(swapQ, current.Position, neighbor.ID); err != nil {
+	if _, err := db.ExecContext(ctx, swapQ, current.Position, neighbor.ID); err != nil {
 		return fmt.Errorf("reorder routine exercise swap neighbor: %w", err)
 	}
 
 	return nil
 }
 
-func StartWorkoutFromRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID) (Workout, error) {
-	tx, err := db.Beginx()
+func StartWorkoutFromRoutine(ctx context.Context, db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID) (Workout, error) {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return Workout{}, fmt.Errorf("start workout from routine begin tx: %w", err)
 	}
@@ -220,7 +226,7 @@ func StartWorkoutFromRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID)
 			JOIN gym_routines gr ON gre.routine_id = gr.id
 			WHERE gr.id = $1 AND gr.user_id = $2
 			ORDER BY gre.position ASC`
-	if err := tx.Select(&exercises, eq, routineID, userID); err != nil {
+	if err := tx.SelectContext(ctx, &exercises, eq, routineID, userID); err != nil {
 		return Workout{}, fmt.Errorf("start workout from routine get exercises: %w", err)
 	}
 
@@ -229,7 +235,7 @@ func StartWorkoutFromRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID)
 			VALUES ($1)
 			RETURNING id, user_id, start_time, notes, created_at, updated_at`
 	var w Workout
-	if err := tx.QueryRow(wq, userID).Scan(
+	if err := tx.QueryRowContext(ctx, wq, userID).Scan(
 		&w.ID, &w.UserID, &w.StartTime, &w.Notes, &w.CreatedAt, &w.UpdatedAt,
 	); err != nil {
 		return Workout{}, fmt.Errorf("start workout from routine create workout: %w", err)
@@ -266,7 +272,7 @@ func StartWorkoutFromRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID)
 		query = db.Rebind(query)
 
 		var rows []lastUsed
-		if err := tx.Select(&rows, query, args...); err != nil {
+		if err := tx.SelectContext(ctx, &rows, query, args...); err != nil {
 			return Workout{}, fmt.Errorf("start workout from routine last used: %w", err)
 		}
 		for _, r := range rows {
@@ -283,14 +289,14 @@ func StartWorkoutFromRoutine(db *sqlx.DB, userID uuid.UUID, routineID uuid.UUID)
 	pos := int16(0)
 	for _, re := range exercises {
 		lu := lastUsedMap[re.ExerciseID]
-		setType := "working"
+		setType := SetWorking
 		if lu.SetType != "" {
 			setType = lu.SetType
 		}
 
 		for range re.Sets {
 			var s Set
-			if err := tx.QueryRow(setQ, w.ID, re.ExerciseID, lu.WeightKg, lu.Reps, setType, pos).Scan(
+			if err := tx.QueryRowContext(ctx, setQ, w.ID, re.ExerciseID, lu.WeightKg, lu.Reps, setType, pos).Scan(
 				&s.ID, &s.WorkoutID, &s.ExerciseID, &s.WeightKg, &s.Reps,
 				&s.SetType, &s.IsCompleted, &s.Position, &s.CreatedAt, &s.UpdatedAt,
 			); err != nil {
@@ -1,6 +1,7 @@
 package gym
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -21,13 +22,13 @@ type ExerciseCount struct {
 	Count      int    `db:"count"        json:"count"`
 }
 
-func GetSummary(db *sqlx.DB, userID uuid.UUID) (WorkoutSummary, error) {
+func GetSummary(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (WorkoutSummary, error) {
 	var summary WorkoutSummary
 
 	workoutsQ := `SELECT COUNT(*) FROM gym_workouts
 			WHERE user_id = $1
 			AND start_time >= date_trunc('month', NOW())`
-	if err := db.Get(&summary.TotalWorkoutsThisMonth, workoutsQ, userID); err != nil {
+	if err := db.GetContext(ctx, &summary.TotalWorkoutsThisMonth, workoutsQ, userID); err != nil {
 		return summary, fmt.Errorf("summary workouts count: %w", err)
 	}
 
@@ -38,7 +39,7 @@ func GetSummary(db *sqlx.DB, userID uuid.UUID) (WorkoutSummary, error) {
 			AND gw.start_time >= date_trunc('month', NOW())
 			AND gs.weight_kg IS NOT NULL
 			AND gs.reps IS NOT NULL`
-	if err := db.QueryRow(volumeQ, userID).Scan(&summary.TotalVolumeThisMonth, &summary.TotalSetsThisMonth); err != nil {
+	if err := db.QueryRowContext(ctx, volumeQ, userID).Scan(&summary.TotalVolumeThisMonth, &summary.TotalSetsThisMonth); err != nil {
 		return summary, fmt.Errorf("summary volume: %w", err)
 	}
 
@@ -49,7 +50,7 @@ func GetSummary(db *sqlx.DB, userID uuid.UUID) (WorkoutSummary, error) {
 			GROUP BY gs.exercise_id
 			ORDER BY count DESC
 			LIMIT 5`
-	if err := db.Select(&summary.TopExercises, topExercisesQ, userID); err != nil {
+	if err := db.SelectContext(ctx, &summary.TopExercises, topExercisesQ, userID); err != nil {
 		return summary, fmt.Errorf("summary top exercises: %w", err)
 	}
 
@@ -61,7 +62,7 @@ func GetSummary(db *sqlx.DB, userID uuid.UUID) (WorkoutSummary, error) {
 			GROUP BY gs.exercise_id
 			ORDER BY count DESC
 			LIMIT 10`
-	if err := db.Select(&summary.FailureExercises, failureQ, userID); err != nil {
+	if err := db.SelectContext(ctx, &summary.FailureExercises, failureQ, userID); err != nil {
 		return summary, fmt.Errorf("summary failure exercises: %w", err)
 	}
 
@@ -75,7 +76,7 @@ type ExerciseFailureStats struct {
 	TotalSets       int     `db:"total_sets" json:"totalSets"`
 }
 
-func GetMusclesFailureStats(db *sqlx.DB, userID uuid.UUID, weeks int) ([]ExerciseFailureStats, error) {
+func GetMusclesFailureStats(ctx context.Context, db *sqlx.DB, userID uuid.UUID, weeks int) ([]ExerciseFailureStats, error) {
 	query := `
 		SELECT
 			gs.exercise_id,
@@ -91,7 +92,7 @@ func GetMusclesFailureStats(db *sqlx.DB, userID uuid.UUID, weeks int) ([]Exercis
 		ORDER BY failure_count DESC`
 
 	var stats []ExerciseFailureStats
-	if err := db.Select(&stats, query, userID, weeks); err != nil {
+	if err := db.SelectContext(ctx, &stats, query, userID, weeks); err != nil {
 		return nil, fmt.Errorf("muscle failure stats: %w", err)
 	}
 
@@ -110,7 +111,7 @@ type WorkoutCalendarEntry struct {
 	ExerciseIDs   pq.StringArray `db:"exercise_ids"    json:"exerciseIds"`
 }
 
-func GetCalendarWorkouts(db *sqlx.DB, userID uuid.UUID) ([]WorkoutCalendarEntry, error) {
+func GetCalendarWorkouts(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]WorkoutCalendarEntry, error) {
 	// FILTER excludes null values from LEFT JOIN when workout doesnt have any sets
 	calendarQ := `SELECT
 			gw.id as workout_id,
@@ -125,7 +126,7 @@ func GetCalendarWorkouts(db *sqlx.DB, userID uuid.UUID) ([]WorkoutCalendarEntry,
 		ORDER BY gw.start_time DESC`
 
 	var entries []WorkoutCalendarEntry
-	if err := db.Select(&entries, calendarQ, userID); err != nil {
+	if err := db.SelectContext(ctx, &entries, calendarQ, userID); err != nil {
 		return nil, fmt.Errorf("calendar workouts: %w", err)
 	}
 
@@ -148,7 +149,7 @@ type UserExercise struct {
 	SetCount   int    `db:"set_count" json:"setCount"`
 }
 
-func GetUserExercises(db *sqlx.DB, userID uuid.UUID) ([]UserExercise, error) {
+func GetUserExercises(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]UserExercise, error) {
 	query := `
 		SELECT gs.exercise_id, COUNT(*) as set_count
 		FROM gym_sets gs
@@ -158,7 +159,7 @@ func GetUserExercises(db *sqlx.DB, userID uuid.UUID) ([]UserExercise, error) {
 		ORDER BY set_count DESC`
 
 	var exercises []UserExercise
-	if err := db.Select(&exercises, query, userID); err != nil {
+	if err := db.SelectContext(ctx, &exercises, query, userID); err != nil {
 		return nil, fmt.Errorf("user exercises: %w", err)
 	}
 
@@ -169,7 +170,7 @@ func GetUserExercises(db *sqlx.DB, userID uuid.UUID) ([]UserExercise, error) {
 	return exercises, nil
 }
 
-func GetExerciseStats(db *sqlx.DB, userID uuid.UUID, exerciseID string) ([]ExerciseSetStats, error) {
+func GetExerciseStats(ctx context.Context, db *sqlx.DB, userID uuid.UUID, exerciseID string) ([]ExerciseSetStats, error) {
 	query := `
 		SELECT
 			gw.start_time::date as date,
@@ -183,7 +184,7 @@ func GetExerciseStats(db *sqlx.DB, userID uuid.UUID, exerciseID string) ([]Exerc
 		ORDER BY gw.start_time ASC, gs.position ASC`
 
 	var stats []ExerciseSetStats
-	if err := db.Select(&stats, query, userID, exerciseID); err != nil {
+	if err := db.SelectContext(ctx, &stats, query, userID, exerciseID); err != nil {
 		return nil, fmt.Errorf("exercise stats: %w", err)
 	}
 
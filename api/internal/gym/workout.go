@@ -1,19 +1,32 @@
 package gym
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
-func NewWorkout(db *sqlx.DB, userID uuid.UUID) (Workout, error) {
+var ErrWorkoutNotFound = apperr.NotFound("workout not found")
+
+// CompletedWorkout summarises a finished workout for anyone listening for completions.
+type CompletedWorkout struct {
+	Workout
+	CompletedSets int     `db:"completed_sets" json:"completedSets"`
+	VolumeKg      float64 `db:"volume_kg"      json:"volumeKg"`
+}
+
+func NewWorkout(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (Workout, error) {
 	q := `INSERT INTO gym_workouts (user_id)
 			VALUES ($1)
 			RETURNING id, user_id, start_time, notes, created_at, updated_at`
 
 	var w Workout
-	err := db.QueryRow(q, userID).Scan(
+	err := db.QueryRowContext(ctx, q, userID).Scan(
 		&w.ID, &w.UserID, &w.StartTime, &w.Notes, &w.CreatedAt, &w.UpdatedAt,
 	)
 	if err != nil {
@@ -24,13 +37,13 @@ func NewWorkout(db *sqlx.DB, userID uuid.UUID) (Workout, error) {
 	return w, nil
 }
 
-func GetAllWorkouts(db *sqlx.DB, userID uuid.UUID) ([]Workout, error) {
+func GetAllWorkouts(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Workout, error) {
 	wq := `SELECT * FROM gym_workouts
 			WHERE user_id = $1
 			ORDER BY start_time DESC`
 
 	var workouts []Workout
-	if err := db.Select(&workouts, wq, userID); err != nil {
+	if err := db.SelectContext(ctx, &workouts, wq, userID); err != nil {
 		return nil, fmt.Errorf("get all workouts: %w", err)
 	}
 
@@ -57,7 +70,7 @@ func GetAllWorkouts(db *sqlx.DB, userID uuid.UUID) ([]Workout, error) {
 	query = db.Rebind(query)
 
 	var sets []Set
-	if err := db.Select(&sets, query, args...); err != nil {
+	if err := db.SelectContext(ctx, &sets, query, args...); err != nil {
 		return nil, fmt.Errorf("get all workouts sets: %w", err)
 	}
 
@@ -69,24 +82,64 @@ func GetAllWorkouts(db *sqlx.DB, userID uuid.UUID) ([]Workout, error) {
 	return workouts, nil
 }
 
-func EditWorkout(db *sqlx.DB, userID uuid.UUID, workoutID uuid.UUID, w WorkoutInput) error {
+func EditWorkout(ctx context.Context, db *sqlx.DB, userID uuid.UUID, workoutID uuid.UUID, w WorkoutInput) error {
 	q := `UPDATE gym_workouts
 			SET start_time = $1, notes = $2, updated_at = NOW()
 			WHERE id = $3 AND user_id = $4`
 
-	if _, err := db.Exec(q, w.StartTime, w.Notes, workoutID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, w.StartTime, w.Notes, workoutID, userID)
+	if err != nil {
 		return fmt.Errorf("edit workout: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "workout")
 }
 
-func DeleteWorkout(db *sqlx.DB, userID uuid.UUID, workoutID uuid.UUID) error {
+func DeleteWorkout(ctx context.Context, db *sqlx.DB, userID uuid.UUID, workoutID uuid.UUID) error {
 	q := `DELETE FROM gym_workouts WHERE id = $1 AND user_id = $2`
 
-	if _, err := db.Exec(q, workoutID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, workoutID, userID)
+	if err != nil {
 		return fmt.Errorf("delete workout: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "workout")
+}
+
+// CompleteWorkout stamps a workout as finished. Only the first call sets completed_at; repeats return the workout as
+// it is with completed false, so callers can tell a fresh completion from a retry.
+func CompleteWorkout(ctx context.Context, db *sqlx.DB, userID uuid.UUID, workoutID uuid.UUID) (CompletedWorkout, bool, error) {
+	uQ := `UPDATE gym_workouts SET completed_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND completed_at IS NULL`
+
+	res, err := db.ExecContext(ctx, uQ, workoutID, userID)
+	if err != nil {
+		return CompletedWorkout{}, false, fmt.Errorf("complete workout: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return CompletedWorkout{}, false, fmt.Errorf("complete workout rows: %w", err)
+	}
+
+	q := `SELECT w.*,
+				COUNT(s.id) FILTER (WHERE s.is_completed) AS completed_sets,
+				COALESCE(SUM(s.weight_kg * s.reps) FILTER (WHERE s.is_completed), 0)::float8 AS volume_kg
+			FROM gym_workouts w
+			LEFT JOIN gym_sets s ON s.workout_id = w.id
+			WHERE w.id = $1 AND w.user_id = $2
+			GROUP BY w.id`
+
+	var cw CompletedWorkout
+	if err := db.GetContext(ctx, &cw, q, workoutID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CompletedWorkout{}, false, ErrWorkoutNotFound
+		}
+
+		return CompletedWorkout{}, false, fmt.Errorf("get completed workout: %w", err)
+	}
+
+	cw.Sets = []Set{}
+
+	return cw, n > 0, nil
 }
@@ -0,0 +1,241 @@
+// Package health answers the liveness and readiness probes. Liveness only asks whether the process is still doing its
+// background work; readiness also checks the database, the schema version and push notifications, so a replica that
+// can't serve requests is taken out of rotation without being restarted.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/migration"
+	"github.com/zachczx/cubby/api/internal/notifier"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	// dbTimeout bounds each probe's database work, well under the docker-compose healthcheck timeout.
+	dbTimeout = 2 * time.Second
+
+	// maxHeartbeatAge allows a couple of missed one-minute notification cycles before the worker counts as stuck.
+	maxHeartbeatAge = 3 * time.Minute
+)
+
+// Heartbeat records when a background worker last finished a cycle. A nil Heartbeat ignores beats.
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+// NewHeartbeat starts the clock at creation, so a worker that never runs goes stale instead of looking fresh.
+func NewHeartbeat() *Heartbeat {
+	h := &Heartbeat{}
+	h.Beat()
+	return h
+}
+
+func (h *Heartbeat) Beat() {
+	if h != nil {
+		h.last.Store(time.Now().UnixNano())
+	}
+}
+
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+type Checker struct {
+	DB  *sqlx.DB
+	FCM *notifier.FCMClient
+	// Notifications is beaten by the notification worker after every run.
+	Notifications *Heartbeat
+	started       time.Time
+}
+
+func NewChecker(db *sqlx.DB, fcm *notifier.FCMClient) *Checker {
+	return &Checker{DB: db, FCM: fcm, Notifications: NewHeartbeat(), started: time.Now()}
+}
+
+// Check is the part every probe shares: Error says what failed.
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Liveness struct {
+	Status        string         `json:"status"`
+	UptimeSeconds int64          `json:"uptimeSeconds"`
+	Notifications HeartbeatCheck `json:"notifications"`
+}
+
+type Readiness struct {
+	Status        string         `json:"status"`
+	Database      DatabaseCheck  `json:"database"`
+	Migrations    MigrationCheck `json:"migrations"`
+	Notifications HeartbeatCheck `json:"notifications"`
+	FCM           Check          `json:"fcm"`
+}
+
+// ReadinessStatus is the part of Readiness that the public /readyz shows: each check's name and status. The errors,
+// pool numbers and versions are only served on the metrics address.
+type ReadinessStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func (r Readiness) Public() ReadinessStatus {
+	return ReadinessStatus{
+		Status: r.Status,
+		Checks: map[string]string{
+			"database":      r.Database.Status,
+			"migrations":    r.Migrations.Status,
+			"notifications": r.Notifications.Status,
+			"fcm":           r.FCM.Status,
+		},
+	}
+}
+
+type DatabaseCheck struct {
+	Check
+	LatencyMs int64     `json:"latencyMs"`
+	Pool      PoolStats `json:"pool"`
+}
+
+type PoolStats struct {
+	MaxOpen        int   `json:"maxOpen"`
+	Open           int   `json:"open"`
+	InUse          int   `json:"inUse"`
+	Idle           int   `json:"idle"`
+	WaitCount      int64 `json:"waitCount"`
+	WaitDurationMs int64 `json:"waitDurationMs"`
+}
+
+type MigrationCheck struct {
+	Check
+	// Version is the highest applied migration and Pending counts the ones this build has that aren't applied.
+	Version int `json:"version"`
+	Pending int `json:"pending"`
+}
+
+type HeartbeatCheck struct {
+	Check
+	LastBeat   time.Time `json:"lastBeat"`
+	AgeSeconds int64     `json:"ageSeconds"`
+}
+
+// Live fails only when the notification worker has stopped, which a restart fixes. Database trouble is left to
+// readiness, since restarting the API wouldn't bring Postgres back.
+func (c *Checker) Live() Liveness {
+	l := Liveness{
+		Status:        StatusOK,
+		UptimeSeconds: int64(time.Since(c.started).Seconds()),
+		Notifications: c.heartbeat(),
+	}
+
+	if l.Notifications.Status != StatusOK {
+		l.Status = StatusFail
+	}
+
+	return l
+}
+
+// Ready fails when the API can't serve requests: Postgres is unreachable, the schema is behind this build, or push
+// notifications weren't set up. A stale worker is reported but doesn't fail readiness, since requests still work.
+func (c *Checker) Ready(ctx context.Context) Readiness {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	r := Readiness{
+		Status:        StatusOK,
+		Database:      c.database(ctx),
+		Notifications: c.heartbeat(),
+		FCM:           Check{Status: StatusOK},
+	}
+
+	// Migration status needs the database, so there's no point waiting on it again.
+	if r.Database.Status == StatusOK {
+		r.Migrations = c.migrations(ctx)
+	} else {
+		r.Migrations = MigrationCheck{Check: Check{Status: StatusFail, Error: "database unavailable"}}
+	}
+
+	if !c.FCM.Ready() {
+		r.FCM = Check{Status: StatusFail, Error: "firebase messaging client not initialized"}
+	}
+
+	for _, s := range []string{r.Database.Status, r.Migrations.Status, r.FCM.Status} {
+		if s != StatusOK {
+			r.Status = StatusFail
+		}
+	}
+
+	return r
+}
+
+func (c *Checker) database(ctx context.Context) DatabaseCheck {
+	start := time.Now()
+	err := c.DB.PingContext(ctx)
+
+	stats := c.DB.Stats()
+	d := DatabaseCheck{
+		Check:     Check{Status: StatusOK},
+		LatencyMs: time.Since(start).Milliseconds(),
+		Pool: PoolStats{
+			MaxOpen:        stats.MaxOpenConnections,
+			Open:           stats.OpenConnections,
+			InUse:          stats.InUse,
+			Idle:           stats.Idle,
+			WaitCount:      stats.WaitCount,
+			WaitDurationMs: stats.WaitDuration.Milliseconds(),
+		},
+	}
+
+	if err != nil {
+		d.Check = Check{Status: StatusFail, Error: err.Error()}
+	}
+
+	return d
+}
+
+func (c *Checker) migrations(ctx context.Context) MigrationCheck {
+	statuses, err := migration.GetStatus(ctx, c.DB)
+	if err != nil {
+		return MigrationCheck{Check: Check{Status: StatusFail, Error: err.Error()}}
+	}
+
+	m := MigrationCheck{Check: Check{Status: StatusOK}}
+	for _, s := range statuses {
+		switch {
+		case s.AppliedAt == nil:
+			m.Pending++
+		case s.Version > m.Version:
+			m.Version = s.Version
+		}
+	}
+
+	if m.Pending > 0 {
+		m.Check = Check{Status: StatusFail, Error: fmt.Sprintf("%d migrations not applied", m.Pending)}
+	}
+
+	return m
+}
+
+func (c *Checker) heartbeat() HeartbeatCheck {
+	last := c.Notifications.Last()
+	age := time.Since(last)
+
+	h := HeartbeatCheck{
+		Check:      Check{Status: StatusOK},
+		LastBeat:   last.UTC(),
+		AgeSeconds: int64(age.Seconds()),
+	}
+
+	if age > maxHeartbeatAge {
+		h.Check = Check{Status: StatusFail, Error: fmt.Sprintf("notification worker last ran %s ago", age.Round(time.Second))}
+	}
+
+	return h
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestLiveFailsOnStaleHeartbeat(t *testing.T) {
+	c := NewChecker(nil, nil)
+
+	if l := c.Live(); l.Status != StatusOK || l.Notifications.Status != StatusOK {
+		t.Fatalf("fresh checker: %+v", l)
+	}
+
+	c.Notifications.last.Store(time.Now().Add(-maxHeartbeatAge - time.Minute).UnixNano())
+
+	l := c.Live()
+	if l.Status != StatusFail || l.Notifications.Error == "" {
+		t.Fatalf("stale heartbeat: %+v", l)
+	}
+
+	c.Notifications.Beat()
+	if l := c.Live(); l.Status != StatusOK {
+		t.Fatalf("after beat: %+v", l)
+	}
+}
+
+func TestReadyReportsUnreachableDatabase(t *testing.T) {
+	// Nothing listens on port 1, so the ping fails straight away.
+	db, err := sqlx.Open("pgx", "postgres://cubby@127.0.0.1:1/cubby?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	r := NewChecker(db, nil).Ready(t.Context())
+
+	if r.Status != StatusFail {
+		t.Fatalf("status = %q, want fail", r.Status)
+	}
+	for name, check := range map[string]Check{"database": r.Database.Check, "migrations": r.Migrations.Check, "fcm": r.FCM} {
+		if check.Status != StatusFail || check.Error == "" {
+			t.Errorf("%s = %+v, want a failure with an error", name, check)
+		}
+	}
+	if r.Notifications.Status != StatusOK {
+		t.Errorf("notifications = %+v, want ok", r.Notifications)
+	}
+
+	want := map[string]string{"database": StatusFail, "migrations": StatusFail, "notifications": StatusOK, "fcm": StatusFail}
+	if p := r.Public(); p.Status != StatusFail || !maps.Equal(p.Checks, want) {
+		t.Errorf("public = %+v, want checks %v", p, want)
+	}
+}
@@ -10,11 +10,11 @@ type contextKey string
 
 var RequestIDKey contextKey = "requestID"
 
-func Init() {
-	env := os.Getenv("ENV")
+// Init logs readable text in development and JSON everywhere else.
+func Init(development bool) {
 	var handler slog.Handler
 
-	if env == "development" {
+	if development {
 		handler = slog.NewTextHandler(os.Stdout, nil)
 	} else {
 		handler = slog.NewJSONHandler(os.Stdout, nil)
@@ -36,3 +36,24 @@ func Info(ctx context.Context, msg string, args ...any) {
 func Error(ctx context.Context, msg string, args ...any) {
 	WithRequestID(ctx).Error(msg, args...)
 }
+
+type requestInfoKey struct{}
+
+// RequestInfo collects details about a request that are only known once it has been routed and authenticated, so the
+// access log written by the outermost middleware can include them.
+type RequestInfo struct {
+	Route  string
+	UserID string
+}
+
+// WithRequestInfo attaches an empty RequestInfo to ctx for inner handlers to fill in.
+func WithRequestInfo(ctx context.Context) (context.Context, *RequestInfo) {
+	info := &RequestInfo{}
+	return context.WithValue(ctx, requestInfoKey{}, info), info
+}
+
+// RequestInfoFrom returns the request's RequestInfo, or nil outside an HTTP request.
+func RequestInfoFrom(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
@@ -0,0 +1,155 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/logging"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// FromConfig returns an SMTP mailer when a host is set, and a log-only mailer otherwise so local setups work without a mail server.
+func FromConfig(c config.SMTP) Mailer {
+	if c.Host == "" {
+		return LogMailer{}
+	}
+
+	return SMTPMailer{
+		Addr:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Host:     c.Host,
+		Username: c.Username,
+		Password: c.Password,
+		From:     c.From,
+	}
+}
+
+// LogMailer writes messages to the log instead of delivering them.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, m Message) error {
+	logging.Info(ctx, "email not sent, no mailer configured", "to", m.To, "subject", m.Subject, "body", m.Body)
+	return nil
+}
+
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers m the way smtp.SendMail does, but dials and talks to the server under ctx, so a hung server can't
+// hold the caller past its deadline.
+func (s SMTPMailer) Send(ctx context.Context, m Message) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("send mail dial: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("send mail deadline: %w", err)
+		}
+	}
+
+	// Closing the connection unblocks whatever step is waiting when ctx is cancelled without a deadline.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := s.send(conn, m); err != nil {
+		// The only deadline on conn is ctx's, which can pass a moment before ctx reports it.
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			err = context.DeadlineExceeded
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+func (s SMTPMailer) send(conn net.Conn, m Message) error {
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return err
+		}
+	}
+
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(m.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(s.build(m)); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func (s SMTPMailer) build(m Message) []byte {
+	var b strings.Builder
+
+	// Header values come from our own templates and user emails; strip line breaks so neither can inject headers.
+	header := func(k, v string) {
+		v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+
+	header("From", s.From)
+	header("To", m.To)
+	header("Subject", m.Subject)
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
@@ -1,12 +1,15 @@
 package market
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/database"
 )
 
 type MarketPrice struct {
@@ -52,6 +55,8 @@ type Input struct {
 	Remarks   *string  `json:"remarks"`
 	CreatedAt *string  `json:"createdAt,omitempty"`
 	UpdatedAt *string  `json:"updatedAt,omitempty"`
+
+	FamilyID *uuid.UUID `json:"familyId"`
 }
 
 type UpsertResult struct {
@@ -59,10 +64,10 @@ type UpsertResult struct {
 	IsUpdate bool      `json:"isUpdate"`
 }
 
-func LogPrice(db *sqlx.DB, p MarketPrice) (UpsertResult, error) {
+func LogPrice(ctx context.Context, db *sqlx.DB, p MarketPrice) (UpsertResult, error) {
 	var result UpsertResult
 
-	tx, err := db.Beginx()
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return result, fmt.Errorf("begin tx: %w", err)
 	}
@@ -77,7 +82,7 @@ func LogPrice(db *sqlx.DB, p MarketPrice) (UpsertResult, error) {
 		AND DATE(created_at AT TIME ZONE 'UTC') = CURRENT_DATE
 		LIMIT 1`
 
-	err = tx.Get(&existingID, checkQ, p.FamilyID, p.ItemName, p.Store, p.Price)
+	err = tx.GetContext(ctx, &existingID, checkQ, p.FamilyID, p.ItemName, p.Store, p.Price)
 
 	if err == nil {
 		var updatedAt interface{}
@@ -91,7 +96,7 @@ func LogPrice(db *sqlx.DB, p MarketPrice) (UpsertResult, error) {
 				quantity = $1, unit = $2, is_promo = $3, remarks = $4,
 				logged_by = $5, updated_at = $6
 			WHERE id = $7`
-		if _, err := tx.Exec(updateQ, p.Quantity, p.Unit, p.IsPromo, p.Remarks, p.LoggedBy, updatedAt, existingID); err != nil {
+		if _, err := tx.ExecContext(ctx, updateQ, p.Quantity, p.Unit, p.IsPromo, p.Remarks, p.LoggedBy, updatedAt, existingID); err != nil {
 			return result, fmt.Errorf("failed to update duplicate: %w", err)
 		}
 		result.ID = existingID
@@ -113,7 +118,7 @@ func LogPrice(db *sqlx.DB, p MarketPrice) (UpsertResult, error) {
 				family_id, logged_by, item_name, category, country, store, unit, quantity, price, is_promo, remarks, created_at, updated_at
 			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 			RETURNING id`
-		if err := tx.Get(&result.ID, insertQ,
+		if err := tx.GetContext(ctx, &result.ID, insertQ,
 			p.FamilyID, p.LoggedBy, p.ItemName, p.Category, p.Country,
 			p.Store, p.Unit, p.Quantity, p.Price, p.IsPromo, p.Remarks,
 			createdAt, updatedAt,
@@ -135,14 +140,10 @@ type PriceFilter struct {
 	Item     string
 }
 
-func GetPrices(db *sqlx.DB, userID uuid.UUID, filter PriceFilter) ([]MarketPrice, error) {
+func GetPrices(ctx context.Context, db *sqlx.DB, userID uuid.UUID, filter PriceFilter) ([]MarketPrice, error) {
 	var p []MarketPrice
 	q := `SELECT mp.* FROM market_prices mp
-			WHERE mp.family_id IN (
-				SELECT family_id FROM families_users WHERE user_id = $1
-				UNION
-				SELECT id FROM families WHERE owner_id = $1
-			)`
+			WHERE mp.family_id IN (` + database.VisibleFamilies("$1") + `)`
 	args := []interface{}{userID}
 
 	if filter.Category != "" {
@@ -156,7 +157,7 @@ func GetPrices(db *sqlx.DB, userID uuid.UUID, filter PriceFilter) ([]MarketPrice
 
 	q += ` ORDER BY mp.created_at DESC`
 
-	if err := db.Select(&p, q, args...); err != nil {
+	if err := db.SelectContext(ctx, &p, q, args...); err != nil {
 		return nil, fmt.Errorf("select market prices: %w", err)
 	}
 
@@ -195,18 +196,14 @@ type lowestRow struct {
 	CreatedAt time.Time `db:"created_at"`
 }
 
-func getLatestPrices(db *sqlx.DB, userID uuid.UUID, category string) ([]latestRow, error) {
+func getLatestPrices(ctx context.Context, db *sqlx.DB, userID uuid.UUID, category string) ([]latestRow, error) {
 	var rows []latestRow
 	q := `SELECT DISTINCT ON (LOWER(item_name), LOWER(COALESCE(country, '')))
 		item_name, category, country, price,
 		COALESCE(price / NULLIF(quantity, 0), price) AS unit_price,
 		store, created_at
 	FROM market_prices
-	WHERE family_id IN (
-		SELECT family_id FROM families_users WHERE user_id = $1
-		UNION
-		SELECT id FROM families WHERE owner_id = $1
-	)`
+	WHERE family_id IN (` + database.VisibleFamilies("$1") + `)`
 	args := []interface{}{userID}
 
 	if category != "" {
@@ -216,24 +213,20 @@ func getLatestPrices(db *sqlx.DB, userID uuid.UUID, category string) ([]latestRo
 
 	q += ` ORDER BY LOWER(item_name), LOWER(COALESCE(country, '')), created_at DESC`
 
-	if err := db.Select(&rows, q, args...); err != nil {
+	if err := db.SelectContext(ctx, &rows, q, args...); err != nil {
 		return nil, fmt.Errorf("select latest prices: %w", err)
 	}
 	return rows, nil
 }
 
-func getLowestPrices(db *sqlx.DB, userID uuid.UUID, category string) ([]lowestRow, error) {
+func getLowestPrices(ctx context.Context, db *sqlx.DB, userID uuid.UUID, category string) ([]lowestRow, error) {
 	var rows []lowestRow
 	q := `SELECT DISTINCT ON (LOWER(item_name), LOWER(COALESCE(country, '')))
 		item_name, country, price,
 		COALESCE(price / NULLIF(quantity, 0), price) AS unit_price,
 		store, created_at
 	FROM market_prices
-	WHERE family_id IN (
-		SELECT family_id FROM families_users WHERE user_id = $1
-		UNION
-		SELECT id FROM families WHERE owner_id = $1
-	)`
+	WHERE family_id IN (` + database.VisibleFamilies("$1") + `)`
 	args := []interface{}{userID}
 
 	if category != "" {
@@ -243,19 +236,19 @@ func getLowestPrices(db *sqlx.DB, userID uuid.UUID, category string) ([]lowestRo
 
 	q += ` ORDER BY LOWER(item_name), LOWER(COALESCE(country, '')), unit_price ASC, created_at DESC`
 
-	if err := db.Select(&rows, q, args...); err != nil {
+	if err := db.SelectContext(ctx, &rows, q, args...); err != nil {
 		return nil, fmt.Errorf("select lowest prices: %w", err)
 	}
 	return rows, nil
 }
 
-func GetInsights(db *sqlx.DB, userID uuid.UUID, category string) ([]MarketInsight, error) {
-	latest, err := getLatestPrices(db, userID, category)
+func GetInsights(ctx context.Context, db *sqlx.DB, userID uuid.UUID, category string) ([]MarketInsight, error) {
+	latest, err := getLatestPrices(ctx, db, userID, category)
 	if err != nil {
 		return nil, err
 	}
 
-	lowest, err := getLowestPrices(db, userID, category)
+	lowest, err := getLowestPrices(ctx, db, userID, category)
 	if err != nil {
 		return nil, err
 	}
@@ -290,40 +283,33 @@ func GetInsights(db *sqlx.DB, userID uuid.UUID, category string) ([]MarketInsigh
 	return insights, nil
 }
 
-func GetPrice(db *sqlx.DB, userID uuid.UUID, priceID uuid.UUID) (MarketPrice, error) {
+func GetPrice(ctx context.Context, db *sqlx.DB, userID uuid.UUID, priceID uuid.UUID) (MarketPrice, error) {
 	var p MarketPrice
 	q := `SELECT mp.* FROM market_prices mp
 			WHERE mp.id = $1
-			AND mp.family_id IN (
-				SELECT family_id FROM families_users WHERE user_id = $2
-				UNION
-				SELECT id FROM families WHERE owner_id = $2
-			)`
+			AND mp.family_id IN (` + database.VisibleFamilies("$2") + `)`
 
-	if err := db.Get(&p, q, priceID, userID); err != nil {
+	if err := db.GetContext(ctx, &p, q, priceID, userID); err != nil {
 		return p, fmt.Errorf("get market price: %w", err)
 	}
 
 	return p, nil
 }
 
-func DeletePrice(db *sqlx.DB, userID uuid.UUID, priceID uuid.UUID) error {
+func DeletePrice(ctx context.Context, db *sqlx.DB, userID uuid.UUID, priceID uuid.UUID) error {
 	q := `DELETE FROM market_prices
 			WHERE id = $1
-			AND family_id IN (
-				SELECT family_id FROM families_users WHERE user_id = $2
-				UNION
-				SELECT id FROM families WHERE owner_id = $2
-			)`
+			AND family_id IN (` + database.VisibleFamilies("$2") + `)`
 
-	if _, err := db.Exec(q, priceID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, priceID, userID)
+	if err != nil {
 		return fmt.Errorf("delete market price: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "market price")
 }
 
-func UpdatePrice(db *sqlx.DB, p MarketPrice, userID uuid.UUID) error {
+func UpdatePrice(ctx context.Context, db *sqlx.DB, p MarketPrice, userID uuid.UUID) error {
 	var updatedAt interface{}
 	if p.UpdatedAt == nil {
 		updatedAt = "NOW()"
@@ -351,13 +337,9 @@ func UpdatePrice(db *sqlx.DB, p MarketPrice, userID uuid.UUID) error {
 			updated_at = $10,
 			created_at = $11
 		WHERE id = $12
-		AND family_id IN (
-			SELECT family_id FROM families_users WHERE user_id = $13
-			UNION
-			SELECT id FROM families WHERE owner_id = $13
-		)`
+		AND family_id IN (` + database.VisibleFamilies("$13") + `)`
 
-	_, err := db.Exec(q,
+	res, err := db.ExecContext(ctx, q,
 		p.ItemName, p.Category, p.Country, p.Store, p.Unit,
 		p.Quantity, p.Price, p.IsPromo, p.Remarks,
 		updatedAt, createdAt,
@@ -367,5 +349,5 @@ func UpdatePrice(db *sqlx.DB, p MarketPrice, userID uuid.UUID) error {
 		return fmt.Errorf("update market price: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "market price")
 }
@@ -0,0 +1,40 @@
+package market
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs market price queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) DeletePrice(ctx context.Context, userID uuid.UUID, priceID uuid.UUID) error {
+	return DeletePrice(ctx, r.db, userID, priceID)
+}
+
+func (r *Repository) GetInsights(ctx context.Context, userID uuid.UUID, category string) ([]MarketInsight, error) {
+	return GetInsights(ctx, r.db, userID, category)
+}
+
+func (r *Repository) GetPrice(ctx context.Context, userID uuid.UUID, priceID uuid.UUID) (MarketPrice, error) {
+	return GetPrice(ctx, r.db, userID, priceID)
+}
+
+func (r *Repository) GetPrices(ctx context.Context, userID uuid.UUID, filter PriceFilter) ([]MarketPrice, error) {
+	return GetPrices(ctx, r.db, userID, filter)
+}
+
+func (r *Repository) LogPrice(ctx context.Context, p MarketPrice) (UpsertResult, error) {
+	return LogPrice(ctx, r.db, p)
+}
+
+func (r *Repository) UpdatePrice(ctx context.Context, p MarketPrice, userID uuid.UUID) error {
+	return UpdatePrice(ctx, r.db, p, userID)
+}
@@ -3,7 +3,6 @@ package migration
 import (
 	"fmt"
 	"log"
-	"os"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,7 +16,7 @@ const (
 	sqlitePath = "./internal/migration/data.db"
 )
 
-func Migrate() {
+func Migrate(pgURL string) {
 	// 1. Connect to SQLite (Source)
 	sqliteDB, err := sqlx.Connect("sqlite3", sqlitePath)
 	if err != nil {
@@ -25,8 +24,7 @@ func Migrate() {
 	}
 	defer sqliteDB.Close()
 
-	pg := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
-	pgDB, err := sqlx.Connect("pgx", pg)
+	pgDB, err := sqlx.Connect("pgx", pgURL)
 	if err != nil {
 		log.Fatal(err)
 	}
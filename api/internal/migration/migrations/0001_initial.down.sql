@@ -0,0 +1,20 @@
+-- Drops everything the baseline created. All data is lost.
+DROP TABLE IF EXISTS
+	timer_profiles,
+	market_prices,
+	gym_routine_exercises,
+	gym_routines,
+	gym_favourite_exercises,
+	gym_sets,
+	gym_workouts,
+	tracker_user_settings,
+	notification_logs,
+	push_tokens,
+	invites,
+	vacations,
+	entries,
+	trackers,
+	families_users,
+	families,
+	users
+CASCADE;
@@ -0,0 +1 @@
+DROP TABLE tracker_dependencies, entry_checklist_items, tracker_checklist_items;
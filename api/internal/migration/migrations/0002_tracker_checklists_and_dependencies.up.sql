@@ -0,0 +1,27 @@
+-- Tracker checklists and dependencies
+CREATE TABLE tracker_checklist_items (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	tracker_id UUID NOT NULL REFERENCES trackers(id) ON DELETE CASCADE,
+	label TEXT NOT NULL,
+	position SMALLINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE entry_checklist_items (
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	item_id UUID NOT NULL REFERENCES tracker_checklist_items(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (entry_id, item_id)
+);
+
+CREATE TABLE tracker_dependencies (
+	tracker_id UUID NOT NULL REFERENCES trackers(id) ON DELETE CASCADE,
+	depends_on_id UUID NOT NULL REFERENCES trackers(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (tracker_id, depends_on_id),
+	CONSTRAINT no_self_dependency CHECK (tracker_id <> depends_on_id)
+);
+
+CREATE INDEX idx_tracker_checklist_items_tracker_id ON tracker_checklist_items(tracker_id);
+CREATE INDEX idx_tracker_dependencies_depends_on_id ON tracker_dependencies(depends_on_id);
@@ -0,0 +1 @@
+DROP TABLE activities, tracker_comments;
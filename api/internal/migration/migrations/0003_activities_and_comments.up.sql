@@ -0,0 +1,22 @@
+-- Activity feed and comments
+CREATE TABLE tracker_comments (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	tracker_id UUID NOT NULL REFERENCES trackers(id) ON DELETE CASCADE,
+	author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+	body TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE activities (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+	actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+	tracker_id UUID REFERENCES trackers(id) ON DELETE SET NULL,
+	kind TEXT NOT NULL,
+	data JSONB NOT NULL DEFAULT '{}'::jsonb,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_tracker_comments_tracker_id ON tracker_comments(tracker_id);
+CREATE INDEX idx_activities_family_feed ON activities(family_id, id DESC);
@@ -0,0 +1,11 @@
+-- Invites still waiting on a sign-up have no user to keep them against.
+DROP TABLE invite_links;
+
+DELETE FROM invites WHERE invitee_id IS NULL;
+
+DROP INDEX idx_invites_pending_email;
+
+ALTER TABLE invites
+	DROP CONSTRAINT invite_has_invitee,
+	DROP COLUMN invitee_email,
+	ALTER COLUMN invitee_id SET NOT NULL;
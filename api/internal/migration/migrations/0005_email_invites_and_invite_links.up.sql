@@ -0,0 +1,21 @@
+-- Invites can name an email that hasn't signed up yet.
+ALTER TABLE invites
+	ALTER COLUMN invitee_id DROP NOT NULL,
+	ADD COLUMN invitee_email TEXT,
+	ADD CONSTRAINT invite_has_invitee CHECK (invitee_id IS NOT NULL OR invitee_email IS NOT NULL);
+
+-- Invites by email wait here with no invitee_id until someone signs up with that address.
+CREATE UNIQUE INDEX idx_invites_pending_email ON invites(family_id, LOWER(invitee_email)) WHERE invitee_id IS NULL;
+
+CREATE TABLE invite_links (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+	created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	uses INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_invite_links_family_id ON invite_links(family_id);
@@ -0,0 +1,23 @@
+-- Only the newest invite per person and family survives, since the old constraint allows one.
+DELETE FROM invites i USING invites newer
+	WHERE i.family_id = newer.family_id
+	AND i.invitee_id = newer.invitee_id
+	AND i.created_at < newer.created_at;
+
+DELETE FROM invites i USING invites newer
+	WHERE i.invitee_id IS NULL AND newer.invitee_id IS NULL
+	AND i.family_id = newer.family_id
+	AND LOWER(i.invitee_email) = LOWER(newer.invitee_email)
+	AND i.created_at < newer.created_at;
+
+DROP INDEX idx_invites_family_id;
+
+DROP INDEX idx_invites_pending_email;
+CREATE UNIQUE INDEX idx_invites_pending_email ON invites(family_id, LOWER(invitee_email)) WHERE invitee_id IS NULL;
+
+DROP INDEX idx_invites_pending_invitee;
+ALTER TABLE invites ADD CONSTRAINT invites_family_id_invitee_id_key UNIQUE (family_id, invitee_id);
+
+ALTER TABLE invites
+	DROP COLUMN expires_at,
+	DROP COLUMN invited_by;
@@ -0,0 +1,13 @@
+-- Invites expire, and remember who sent them. Invites already pending get a fresh window from now.
+ALTER TABLE invites
+	ADD COLUMN invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+	ADD COLUMN expires_at TIMESTAMPTZ NOT NULL DEFAULT NOW() + INTERVAL '14 days';
+
+-- One open invite per person per family; answered and expired invites stay as history and don't block a re-invite.
+ALTER TABLE invites DROP CONSTRAINT invites_family_id_invitee_id_key;
+CREATE UNIQUE INDEX idx_invites_pending_invitee ON invites(family_id, invitee_id) WHERE status = 'pending';
+
+DROP INDEX idx_invites_pending_email;
+CREATE UNIQUE INDEX idx_invites_pending_email ON invites(family_id, LOWER(invitee_email)) WHERE invitee_id IS NULL AND status = 'pending';
+
+CREATE INDEX idx_invites_family_id ON invites(family_id, created_at DESC);
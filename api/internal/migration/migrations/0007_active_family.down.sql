@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN active_family_id;
@@ -0,0 +1,2 @@
+-- The family new records go to when a request doesn't name one.
+ALTER TABLE users ADD COLUMN active_family_id UUID REFERENCES families(id) ON DELETE SET NULL;
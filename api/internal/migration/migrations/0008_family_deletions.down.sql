@@ -0,0 +1 @@
+DROP TABLE family_deletions;
@@ -0,0 +1,8 @@
+-- Pending confirmation for deleting a family; one outstanding token per family.
+CREATE TABLE family_deletions (
+	family_id UUID PRIMARY KEY REFERENCES families(id) ON DELETE CASCADE,
+	requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_hash TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+-- Accounts waiting out the deletion grace period. transfers maps owned family IDs to the member taking over.
+CREATE TABLE account_deletions (
+	user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	identity_id TEXT NOT NULL DEFAULT '',
+	transfers JSONB NOT NULL DEFAULT '{}'::jsonb,
+	scheduled_for TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+-- Personal access tokens for scripts and automations; only the hash is kept.
+CREATE TABLE personal_access_tokens (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	hint TEXT NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	last_used_at TIMESTAMPTZ,
+	expires_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
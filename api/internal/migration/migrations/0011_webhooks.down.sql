@@ -0,0 +1,3 @@
+ALTER TABLE gym_workouts DROP COLUMN completed_at;
+
+DROP TABLE tracker_due_events, webhook_deliveries, webhooks;
@@ -0,0 +1,40 @@
+-- Outgoing webhooks. The secret is kept in plain text because every delivery is signed with it.
+CREATE TABLE webhooks (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+	created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT[] NOT NULL DEFAULT '{}',
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries (
+	id UUID PRIMARY KEY DEFAULT uuidv7(),
+	webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event_id UUID NOT NULL,
+	event_type TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+	last_status_code INT,
+	last_error TEXT,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhooks_family_id ON webhooks(family_id);
+CREATE INDEX idx_webhook_deliveries_log ON webhook_deliveries(webhook_id, id DESC);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Last due cycle a tracker.due event was published for, so the event fires once per cycle, not every minute.
+CREATE TABLE tracker_due_events (
+	tracker_id UUID PRIMARY KEY REFERENCES trackers(id) ON DELETE CASCADE,
+	last_entry TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Set when a workout is finished, which publishes workout.completed.
+ALTER TABLE gym_workouts ADD COLUMN completed_at TIMESTAMPTZ;
@@ -0,0 +1,10 @@
+-- Request counters for the auth rate limiter when RATE_LIMIT_STORE=postgres. Keys are
+-- "rule:ip-or-email" and rows are swept once their window has ended.
+CREATE TABLE rate_limits (
+	key TEXT PRIMARY KEY,
+	window_start TIMESTAMPTZ NOT NULL,
+	count INT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_expires_at ON rate_limits(expires_at);
@@ -0,0 +1,355 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Dir is where cmd/migrate creates new migrations, relative to the api directory. Files there are embedded into
+// the binary, so the API can migrate its own database on start.
+const Dir = "internal/migration/migrations"
+
+// lockID is an arbitrary key for pg_advisory_lock. Every process migrating this database takes the same lock, so
+// two API replicas starting together apply each migration once.
+const lockID int64 = 0x637562627900
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration has been edited")
+	ErrMissingMigration = errors.New("applied migration not found in this build")
+)
+
+var fileName = regexp.MustCompile(`^(\d{4,})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the file no longer matches what was applied.
+	Modified bool
+	// Missing is set for versions recorded in the database that this build doesn't have.
+	Missing bool
+}
+
+type appliedRow struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Load returns the embedded migrations in version order.
+func Load() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("open migrations: %w", err)
+	}
+
+	return load(sub)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %q: name must look like 0002_add_things.up.sql", e.Name())
+		}
+
+		version, _ := strconv.Atoi(m[1])
+
+		b, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(b)
+		} else {
+			mig.Down = string(b)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+
+		mig.Checksum = checksum(mig.Up)
+		out = append(out, *mig)
+	}
+
+	slices.SortFunc(out, func(a, b Migration) int { return a.Version - b.Version })
+
+	return out, nil
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Up applies every pending migration, each in its own transaction. Applied migrations whose file has changed stop
+// the run, since the database no longer matches what the code expects.
+func Up(ctx context.Context, db *sqlx.DB) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	return withLock(ctx, db, func(conn *sqlx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if a, ok := applied[m.Version]; ok {
+				if a.Checksum != m.Checksum {
+					return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, m.Version, m.Name)
+				}
+				continue
+			}
+
+			if err := apply(ctx, conn, m.Up, func(tx *sqlx.Tx) error {
+				_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`, m.Version, m.Name, m.Checksum)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+
+			slog.Info("migration applied", "version", m.Version, "name", m.Name)
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the most recent steps migrations.
+func Down(ctx context.Context, db *sqlx.DB, steps int) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	return withLock(ctx, db, func(conn *sqlx.Conn) error {
+		var rows []appliedRow
+		if err := conn.SelectContext(ctx, &rows, `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version DESC LIMIT $1`, steps); err != nil {
+			return fmt.Errorf("list applied migrations: %w", err)
+		}
+
+		for _, a := range rows {
+			m, ok := byVersion[a.Version]
+			if !ok {
+				return fmt.Errorf("%w: %04d_%s", ErrMissingMigration, a.Version, a.Name)
+			}
+
+			if a.Checksum != m.Checksum {
+				return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, m.Version, m.Name)
+			}
+
+			if err := apply(ctx, conn, m.Down, func(tx *sqlx.Tx) error {
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("rollback %04d_%s: %w", m.Version, m.Name, err)
+			}
+
+			slog.Info("migration rolled back", "version", m.Version, "name", m.Name)
+		}
+
+		return nil
+	})
+}
+
+// GetStatus lists every known migration and whether it has been applied. It doesn't take the lock, so it can be
+// run while a migration is in progress.
+func GetStatus(ctx context.Context, db *sqlx.DB) ([]Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := db.GetContext(ctx, &exists, `SELECT to_regclass('schema_migrations') IS NOT NULL`); err != nil {
+		return nil, fmt.Errorf("check schema_migrations: %w", err)
+	}
+
+	applied := map[int]appliedRow{}
+	if exists {
+		if applied, err = appliedVersions(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]Status, 0, len(migrations))
+
+	for _, m := range migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			s.AppliedAt = &a.AppliedAt
+			s.Modified = a.Checksum != m.Checksum
+			delete(applied, m.Version)
+		}
+		out = append(out, s)
+	}
+
+	for _, a := range applied {
+		out = append(out, Status{Version: a.Version, Name: a.Name, AppliedAt: &a.AppliedAt, Missing: true})
+	}
+
+	slices.SortFunc(out, func(a, b Status) int { return a.Version - b.Version })
+
+	return out, nil
+}
+
+// NewFiles writes an empty up/down pair for the next version into dir and returns their paths.
+func NewFiles(dir string, name string) (string, string, error) {
+	name = strings.Trim(strings.ToLower(regexp.MustCompile(`[^a-zA-Z0-9]+`).ReplaceAllString(name, "_")), "_")
+	if name == "" {
+		return "", "", errors.New("migration name is required")
+	}
+
+	existing, err := load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	next := 1
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", next, name))
+	up, down := base+".up.sql", base+".down.sql"
+
+	if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0o600); err != nil {
+		return "", "", fmt.Errorf("write up migration: %w", err)
+	}
+
+	if err := os.WriteFile(down, []byte("-- Undo "+name+"\n"), 0o600); err != nil {
+		return "", "", fmt.Errorf("write down migration: %w", err)
+	}
+
+	return up, down, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock. Session-level advisory locks belong to a
+// connection, so everything has to happen on the one that took it.
+func withLock(ctx context.Context, db *sqlx.DB, fn func(conn *sqlx.Conn) error) error {
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("migration connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled.
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			slog.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	q := `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`
+
+	if _, err := conn.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, q sqlx.QueryerContext) (map[int]appliedRow, error) {
+	var rows []appliedRow
+	if err := sqlx.SelectContext(ctx, q, &rows, `SELECT version, name, checksum, applied_at FROM schema_migrations`); err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+
+	applied := make(map[int]appliedRow, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// apply runs a migration's SQL and its bookkeeping in one transaction. The SQL is sent without arguments, which
+// lets a file hold several statements.
+func apply(ctx context.Context, conn *sqlx.Conn, script string, record func(tx *sqlx.Tx) error) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return fmt.Errorf("record migration: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
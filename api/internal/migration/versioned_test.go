@@ -0,0 +1,17 @@
+package migration
+
+import "testing"
+
+// Versions are applied in order and a gap usually means a file went missing in a merge.
+func TestEmbeddedMigrationsAreContiguous(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Fatalf("migration %04d_%s is at position %d, want version %d", m.Version, m.Name, i+1, i+1)
+		}
+	}
+}
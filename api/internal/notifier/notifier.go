@@ -3,7 +3,6 @@ package notifier
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -12,6 +11,8 @@ import (
 	"firebase.google.com/go/v4/messaging"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/telemetry"
 	"google.golang.org/api/option"
 )
 
@@ -50,9 +51,9 @@ type FCMClient struct {
 	client *messaging.Client
 }
 
-func NewFCMClient(ctx context.Context) (*FCMClient, error) {
+func NewFCMClient(ctx context.Context, c config.Firebase) (*FCMClient, error) {
 	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
-		CredentialsJSON: []byte(os.Getenv("FIREBASE_CREDENTIALS_JSON")),
+		CredentialsJSON: []byte(c.CredentialsJSON),
 		Scopes: []string{
 			"https://www.googleapis.com/auth/firebase.messaging",
 			"https://www.googleapis.com/auth/cloud-platform",
@@ -63,7 +64,7 @@ func NewFCMClient(ctx context.Context) (*FCMClient, error) {
 	}
 
 	app, err := firebase.NewApp(ctx, &firebase.Config{
-		ProjectID: os.Getenv("FIREBASE_PROJECT_ID"),
+		ProjectID: c.ProjectID,
 	}, option.WithAuthCredentials(creds))
 	if err != nil {
 		return nil, err
@@ -79,7 +80,7 @@ func NewFCMClient(ctx context.Context) (*FCMClient, error) {
 
 var notificationWindowHours time.Duration = -6
 
-func GetUsersWithTokens(db *sqlx.DB, trackerIDs []uuid.UUID) ([]UserToken, error) {
+func GetUsersWithTokens(ctx context.Context, db *sqlx.DB, trackerIDs []uuid.UUID) ([]UserToken, error) {
 	var tokens []UserToken
 	notificationThreshold := time.Now().Add(-notificationWindowHours * time.Hour)
 
@@ -112,7 +113,7 @@ func GetUsersWithTokens(db *sqlx.DB, trackerIDs []uuid.UUID) ([]UserToken, error
 
 	query = db.Rebind(query)
 
-	if err := db.Select(&tokens, query, args...); err != nil {
+	if err := db.SelectContext(ctx, &tokens, query, args...); err != nil {
 		return nil, fmt.Errorf("getUsersWithTokens select: %w", err)
 	}
 
@@ -169,6 +170,11 @@ func BatchMessageBuilder(userTokens []UserToken) ([]*messaging.Message, error) {
 	return FCMMessages, nil
 }
 
+// Ready reports whether the Firebase messaging client was created, for the readiness check.
+func (f *FCMClient) Ready() bool {
+	return f != nil && f.client != nil
+}
+
 func (f *FCMClient) SendBatchMessages(ctx context.Context, db *sqlx.DB, userTokens []UserToken) error {
 	if len(userTokens) == 0 {
 		return nil
@@ -185,8 +191,13 @@ func (f *FCMClient) SendBatchMessages(ctx context.Context, db *sqlx.DB, userToke
 
 	batchResponse, err := f.client.SendEach(ctx, messages)
 	if err != nil {
+		telemetry.NotificationFailures.Add(float64(len(messages)))
 		return err
 	}
+
+	telemetry.NotificationsSent.Add(float64(batchResponse.SuccessCount))
+	telemetry.NotificationFailures.Add(float64(batchResponse.FailureCount))
+
 	if batchResponse.FailureCount > 0 {
 		for _, resp := range batchResponse.Responses {
 			if !resp.Success {
@@ -196,21 +207,21 @@ func (f *FCMClient) SendBatchMessages(ctx context.Context, db *sqlx.DB, userToke
 		return fmt.Errorf("send batch: %v failures out of %v", batchResponse.FailureCount, len(messages))
 	}
 
-	if err := UpdateNotificationLogs(db, userTokens); err != nil {
+	if err := UpdateNotificationLogs(ctx, db, userTokens); err != nil {
 		return fmt.Errorf("send batch: %w", err)
 	}
 
 	return nil
 }
 
-func UpdateNotificationLogs(db *sqlx.DB, userTokens []UserToken) error {
+func UpdateNotificationLogs(ctx context.Context, db *sqlx.DB, userTokens []UserToken) error {
 	q := `INSERT INTO notification_logs (tracker_id, user_id) 
 			VALUES ($1, $2)
 			ON CONFLICT (tracker_id, user_id) DO UPDATE 
 			SET updated_at = NOW()`
 
 	for _, ut := range userTokens {
-		if _, err := db.Exec(q, ut.TrackerID, ut.UserID); err != nil {
+		if _, err := db.ExecContext(ctx, q, ut.TrackerID, ut.UserID); err != nil {
 			return fmt.Errorf("updateNotificationLogs (tracker id: %v): %w", ut.TrackerID, err)
 		}
 	}
@@ -218,15 +229,15 @@ func UpdateNotificationLogs(db *sqlx.DB, userTokens []UserToken) error {
 	return nil
 }
 
-func SavePushToken(db *sqlx.DB, userID uuid.UUID, token string, platform string) error {
-	tx, err := db.Beginx()
+func SavePushToken(ctx context.Context, db *sqlx.DB, userID uuid.UUID, token string, platform string) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
 	}
 	defer tx.Rollback() //nolint:errcheck
 
 	qDel := `DELETE FROM push_tokens WHERE user_id = $1 AND platform = $2`
-	if _, err := tx.Exec(qDel, userID, platform); err != nil {
+	if _, err := tx.ExecContext(ctx, qDel, userID, platform); err != nil {
 		return fmt.Errorf("del stale tokens: %w", err)
 	}
 
@@ -236,19 +247,19 @@ func SavePushToken(db *sqlx.DB, userID uuid.UUID, token string, platform string)
 				platform = EXCLUDED.platform,
 				updated_at = NOW()`
 
-	if _, err := tx.Exec(q, userID, token, platform); err != nil {
+	if _, err := tx.ExecContext(ctx, q, userID, token, platform); err != nil {
 		return fmt.Errorf("save push token: %w", err)
 	}
 
 	return tx.Commit()
 }
 
-func GetUserPushTokens(db *sqlx.DB, userID uuid.UUID) ([]PushToken, error) {
+func GetUserPushTokens(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]PushToken, error) {
 	q := `SELECT * FROM push_tokens WHERE user_id = $1`
 
 	var pt []PushToken
 
-	if err := db.Select(&pt, q, userID); err != nil {
+	if err := db.SelectContext(ctx, &pt, q, userID); err != nil {
 		return nil, fmt.Errorf("get push tokens: %w", err)
 	}
 
@@ -0,0 +1,274 @@
+// Package openapi builds an OpenAPI 3.1 document from a route table whose request and response bodies are Go values.
+// Schemas come from the types' JSON tags by reflection, so they can't drift from what encoding/json reads and writes.
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.1.0"
+
+// Route documents one handler. Method and Path follow the ServeMux pattern it's registered under.
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	// Public routes don't need a session or access token.
+	Public bool
+	Query  []Param
+	// Request is a value of the JSON body type, or nil if the route takes none.
+	Request any
+	// Form lists the fields of a form-encoded body.
+	Form []string
+	// Response is a value of the JSON body type, or nil for an empty body.
+	Response any
+	// Status defaults to 200 with a Response and 204 without one.
+	Status int
+	// ContentType replaces JSON for responses like CSV or iCalendar.
+	ContentType string
+}
+
+// Param is a query parameter. Type is a JSON schema type and defaults to "string".
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Spec is everything Build needs besides the routes.
+type Spec struct {
+	Title   string
+	Version string
+	// Error is the body every route sends when it fails.
+	Error any
+	// Auth names the security schemes a non-public route accepts; any one of them is enough.
+	Auth map[string]SecurityScheme
+	// Bare lists packages whose types are named without the package prefix, e.g. the handlers' own inputs.
+	Bare   []string
+	Routes []Route
+}
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+var pathParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)(\.\.\.)?\}`)
+
+// Build returns the document for s. Operation IDs come from the method and path, so a route keeps its ID as long
+// as its pattern doesn't change.
+func Build(s Spec) *Document {
+	g := newGenerator(s.Bare)
+
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: s.Title, Version: s.Version},
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: g.schemas, SecuritySchemes: s.Auth},
+	}
+
+	var errSchema *Schema
+	if s.Error != nil {
+		errSchema = g.schema(s.Error)
+	}
+
+	var security []map[string][]string
+	for _, name := range slices.Sorted(maps.Keys(s.Auth)) {
+		security = append(security, map[string][]string{name: {}})
+	}
+
+	for _, r := range s.Routes {
+		path := Path(r.Path)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		op := &Operation{
+			OperationID: operationID(r.Method, path),
+			Summary:     r.Summary,
+			Responses:   map[string]Response{},
+			Security:    security,
+		}
+
+		if r.Tag != "" {
+			op.Tags = []string{r.Tag}
+		}
+
+		if r.Public {
+			op.Security = []map[string][]string{}
+		}
+
+		for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+
+		for _, q := range r.Query {
+			t := q.Type
+			if t == "" {
+				t = "string"
+			}
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        q.Name,
+				In:          "query",
+				Description: q.Description,
+				Schema:      &Schema{Type: t},
+			})
+		}
+
+		switch {
+		case r.Request != nil:
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schema(r.Request)}},
+			}
+
+		case len(r.Form) > 0:
+			form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			for _, f := range r.Form {
+				form.Properties[f] = &Schema{Type: "string"}
+			}
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/x-www-form-urlencoded": {Schema: form}},
+			}
+		}
+
+		status := r.Status
+		if status == 0 {
+			status = http.StatusNoContent
+			if r.Response != nil || r.ContentType != "" {
+				status = http.StatusOK
+			}
+		}
+
+		resp := Response{Description: http.StatusText(status)}
+
+		switch {
+		case r.ContentType != "":
+			resp.Content = map[string]MediaType{r.ContentType: {Schema: &Schema{Type: "string"}}}
+		case r.Response != nil:
+			resp.Content = map[string]MediaType{"application/json": {Schema: g.schema(r.Response)}}
+		}
+
+		op.Responses[strconv.Itoa(status)] = resp
+
+		if errSchema != nil {
+			op.Responses["default"] = Response{
+				Description: "Error",
+				Content:     map[string]MediaType{"application/json": {Schema: errSchema}},
+			}
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			item.Get = op
+		case http.MethodPost:
+			item.Post = op
+		case http.MethodPut:
+			item.Put = op
+		case http.MethodPatch:
+			item.Patch = op
+		case http.MethodDelete:
+			item.Delete = op
+		}
+	}
+
+	return doc
+}
+
+// Path turns a ServeMux path into an OpenAPI one: "{$}" goes, and "{rest...}" becomes "{rest}".
+func Path(muxPath string) string {
+	p := strings.TrimSuffix(muxPath, "{$}")
+	if p == "" {
+		p = "/"
+	}
+
+	return strings.ReplaceAll(p, "...}", "}")
+}
+
+// operationID turns "GET /trackers/{trackerID}/entries" into "getTrackersTrackerIDEntries".
+func operationID(method string, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return b.String()
+}
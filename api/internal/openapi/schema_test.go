@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type Sample struct {
+	base
+	Name     string     `json:"name"`
+	Note     string     `json:"note,omitempty"`
+	Due      *time.Time `json:"due"`
+	Next     *Sample    `json:"next"`
+	Secret   string     `json:"-"`
+	Untagged int
+}
+
+func TestStructSchema(t *testing.T) {
+	g := newGenerator(nil)
+
+	ref := g.schema(Sample{})
+	if ref.Ref != "#/components/schemas/OpenapiSample" {
+		t.Fatalf("ref = %q", ref.Ref)
+	}
+
+	s := g.schemas["OpenapiSample"]
+
+	var props []string
+	for k := range s.Properties {
+		props = append(props, k)
+	}
+	slices.Sort(props)
+
+	if want := []string{"Untagged", "due", "id", "name", "next", "note"}; !slices.Equal(props, want) {
+		t.Fatalf("properties = %v, want %v", props, want)
+	}
+
+	if want := []string{"id", "name", "Untagged"}; !slices.Equal(s.Required, want) {
+		t.Fatalf("required = %v, want %v", s.Required, want)
+	}
+
+	if want := []any{"string", "null"}; !reflect.DeepEqual(s.Properties["due"].Type, want) || s.Properties["due"].Format != "date-time" {
+		t.Fatalf("due = %+v", s.Properties["due"])
+	}
+
+	if next := s.Properties["next"]; len(next.OneOf) != 2 || next.OneOf[0].Ref != ref.Ref {
+		t.Fatalf("next = %+v", next)
+	}
+}
+
+func TestPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"/{$}":               "/",
+		"/trackers/{id}":     "/trackers/{id}",
+		"/files/{path...}":   "/files/{path}",
+		"/families/{id}/{$}": "/families/{id}/",
+	} {
+		if got := Path(in); got != want {
+			t.Errorf("Path(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package ownership_test checks in one place that every domain refuses to let a user read or change rows that
+// aren't theirs. Each case names the actor and the call; the shared fixture is built once per run.
+package ownership_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/gym"
+	"github.com/zachczx/cubby/api/internal/market"
+	"github.com/zachczx/cubby/api/internal/testdb"
+	"github.com/zachczx/cubby/api/internal/tracker"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// fixture is one family with a row of each kind, all created by owner, plus memberTracker, which member created in
+// owner's family. member belongs to the family; outsider doesn't.
+type fixture struct {
+	owner, member, outsider uuid.UUID
+
+	tracker, entry, price, workout uuid.UUID
+	memberTracker                  uuid.UUID
+}
+
+func setup(t *testing.T, db *sqlx.DB) fixture {
+	t.Helper()
+
+	f := fixture{owner: testdb.User(t, db), member: testdb.User(t, db), outsider: testdb.User(t, db)}
+
+	familyID := testdb.Family(t, db, f.owner)
+	testdb.AddMember(t, db, familyID, f.member)
+	testdb.Family(t, db, f.outsider)
+	f.tracker = testdb.Tracker(t, db, f.owner, familyID)
+	f.memberTracker = testdb.Tracker(t, db, f.member, familyID)
+
+	e, err := entry.Create(t.Context(), db, entry.Entry{TrackerID: f.tracker, PerformedBy: f.owner, PerformedAt: time.Now(), Interval: 1, IntervalUnit: "day"})
+	if err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+	f.entry = e.ID
+
+	pQ := `INSERT INTO market_prices (family_id, logged_by, item_name, price) VALUES ($1, $2, 'eggs', 3.20) RETURNING id`
+	if err := db.GetContext(t.Context(), &f.price, pQ, familyID, f.owner); err != nil {
+		t.Fatalf("insert price: %v", err)
+	}
+
+	w, err := gym.NewWorkout(t.Context(), db, f.owner)
+	if err != nil {
+		t.Fatalf("create workout: %v", err)
+	}
+	f.workout = w.ID
+
+	return f
+}
+
+func TestOwnerOnlyCallsRefuseOthers(t *testing.T) {
+	db := testdb.New(t)
+	f := setup(t, db)
+
+	tests := []struct {
+		name   string
+		actor  uuid.UUID
+		call   func(ctx context.Context, actor uuid.UUID) error
+		want   error
+		table  string
+		target uuid.UUID
+	}{
+		{
+			name: "member deletes tracker", actor: f.member, want: apperr.ErrForbidden, table: "trackers", target: f.tracker,
+			call: func(ctx context.Context, actor uuid.UUID) error { return tracker.Delete(ctx, db, f.tracker, actor) },
+		},
+		{
+			name: "outsider deletes tracker", actor: f.outsider, want: apperr.ErrNotFound, table: "trackers", target: f.tracker,
+			call: func(ctx context.Context, actor uuid.UUID) error { return tracker.Delete(ctx, db, f.tracker, actor) },
+		},
+		{
+			name: "member deletes entry", actor: f.member, want: apperr.ErrForbidden, table: "entries", target: f.entry,
+			call: func(ctx context.Context, actor uuid.UUID) error { return entry.Delete(ctx, db, actor, f.entry) },
+		},
+		{
+			name: "member edits entry", actor: f.member, want: apperr.ErrForbidden, table: "entries", target: f.entry,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				return entry.Edit(ctx, db, actor, f.entry, time.Now())
+			},
+		},
+		{
+			name: "outsider deletes entry", actor: f.outsider, want: apperr.ErrNotFound, table: "entries", target: f.entry,
+			call: func(ctx context.Context, actor uuid.UUID) error { return entry.Delete(ctx, db, actor, f.entry) },
+		},
+		// Get and GetOwned gate the edit handlers, so they answer like the mutations do.
+		{
+			name: "member gets tracker", actor: f.member, want: apperr.ErrForbidden, table: "trackers", target: f.tracker,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				_, err := tracker.Get(ctx, db, f.tracker, actor)
+				return err
+			},
+		},
+		{
+			name: "outsider gets tracker", actor: f.outsider, want: sql.ErrNoRows, table: "trackers", target: f.tracker,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				_, err := tracker.Get(ctx, db, f.tracker, actor)
+				return err
+			},
+		},
+		{
+			name: "member gets owned entry", actor: f.member, want: apperr.ErrForbidden, table: "entries", target: f.entry,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				_, err := entry.GetOwned(ctx, db, actor, f.entry)
+				return err
+			},
+		},
+		{
+			name: "member adds dependency", actor: f.member, want: apperr.ErrForbidden, table: "trackers", target: f.tracker,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				return tracker.AddDependency(ctx, db, actor, f.tracker, f.memberTracker)
+			},
+		},
+		{
+			name: "outsider adds dependency", actor: f.outsider, want: sql.ErrNoRows, table: "trackers", target: f.tracker,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				return tracker.AddDependency(ctx, db, actor, f.tracker, f.memberTracker)
+			},
+		},
+		{
+			name: "outsider deletes price", actor: f.outsider, want: apperr.ErrNotFound, table: "market_prices", target: f.price,
+			call: func(ctx context.Context, actor uuid.UUID) error { return market.DeletePrice(ctx, db, actor, f.price) },
+		},
+		{
+			name: "member deletes workout", actor: f.member, want: apperr.ErrNotFound, table: "gym_workouts", target: f.workout,
+			call: func(ctx context.Context, actor uuid.UUID) error { return gym.DeleteWorkout(ctx, db, actor, f.workout) },
+		},
+		{
+			name: "member edits workout", actor: f.member, want: apperr.ErrNotFound, table: "gym_workouts", target: f.workout,
+			call: func(ctx context.Context, actor uuid.UUID) error {
+				return gym.EditWorkout(ctx, db, actor, f.workout, gym.WorkoutInput{StartTime: time.Now().Format(time.RFC3339)})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(t.Context(), tt.actor); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+
+			var exists bool
+			if err := db.GetContext(t.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM `+tt.table+` WHERE id = $1)`, tt.target); err != nil {
+				t.Fatal(err)
+			}
+			if !exists {
+				t.Fatalf("%s row gone after a refused call", tt.table)
+			}
+		})
+	}
+}
+
+func TestReadsScopedToOwner(t *testing.T) {
+	db := testdb.New(t)
+	f := setup(t, db)
+
+	tests := []struct {
+		name  string
+		actor uuid.UUID
+		// visible reports whether the fixture row came back for actor.
+		visible func(ctx context.Context, actor uuid.UUID) (bool, error)
+		want    bool
+	}{
+		{"owner gets tracker", f.owner, getVisible(func(ctx context.Context, actor uuid.UUID) error {
+			_, err := tracker.Get(ctx, db, f.tracker, actor)
+			return err
+		}), true},
+		// The owner has no families_users row, so this catches queries that only check membership.
+		{"owner lists member's tracker", f.owner, listsTracker(db, f.memberTracker), true},
+		{"outsider lists member's tracker", f.outsider, listsTracker(db, f.memberTracker), false},
+		{"member lists entries", f.member, func(ctx context.Context, actor uuid.UUID) (bool, error) {
+			entries, err := entry.GetAll(ctx, db, actor)
+			return len(entries) > 0, err
+		}, false},
+		{"member gets price", f.member, getVisible(func(ctx context.Context, actor uuid.UUID) error {
+			_, err := market.GetPrice(ctx, db, actor, f.price)
+			return err
+		}), true},
+		{"outsider gets price", f.outsider, getVisible(func(ctx context.Context, actor uuid.UUID) error {
+			_, err := market.GetPrice(ctx, db, actor, f.price)
+			return err
+		}), false},
+		{"member lists workouts", f.member, func(ctx context.Context, actor uuid.UUID) (bool, error) {
+			workouts, err := gym.GetAllWorkouts(ctx, db, actor)
+			return len(workouts) > 0, err
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.visible(t.Context(), tt.actor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("visible = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// getVisible adapts a single-row getter, where sql.ErrNoRows means the row is hidden.
+func getVisible(get func(ctx context.Context, actor uuid.UUID) error) func(context.Context, uuid.UUID) (bool, error) {
+	return func(ctx context.Context, actor uuid.UUID) (bool, error) {
+		err := get(ctx, actor)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
+
+// listsTracker reports whether trackerID is among the trackers GetAll returns for the actor.
+func listsTracker(db *sqlx.DB, trackerID uuid.UUID) func(context.Context, uuid.UUID) (bool, error) {
+	return func(ctx context.Context, actor uuid.UUID) (bool, error) {
+		trackers, err := tracker.GetAll(ctx, db, actor)
+		return slices.ContainsFunc(trackers, func(t tracker.Tracker) bool { return t.ID == trackerID }), err
+	}
+}
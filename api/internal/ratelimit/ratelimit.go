@@ -0,0 +1,172 @@
+// Package ratelimit counts requests per key in fixed windows. The in-memory store suits a single instance; the
+// Postgres store shares counts between replicas so a client can't multiply its allowance by hitting each one.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const sweepInterval = 5 * time.Minute
+
+// Limit allows Requests per Window for each key.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// ParseLimit reads limits written as "requests/window", e.g. "5/15m".
+func ParseLimit(s string) (Limit, error) {
+	n, d, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: expected requests/window", s)
+	}
+
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: requests must be a positive number", s)
+	}
+
+	window, err := time.ParseDuration(d)
+	if err != nil || window <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: window must be a positive duration", s)
+	}
+
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+// UnmarshalText lets a Limit be loaded straight from configuration.
+func (l *Limit) UnmarshalText(b []byte) error {
+	parsed, err := ParseLimit(string(b))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Window.String()
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Allow counts one request against key and reports whether it fits in the limit.
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+func result(count int, l Limit, start time.Time, now time.Time) Result {
+	if count > l.Requests {
+		return Result{RetryAfter: start.Add(l.Window).Sub(now)}
+	}
+
+	return Result{Allowed: true, Remaining: l.Requests - count}
+}
+
+type Memory struct {
+	mu        sync.Mutex
+	windows   map[string]memoryWindow
+	nextSweep time.Time
+}
+
+type memoryWindow struct {
+	start time.Time
+	count int
+	end   time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{windows: map[string]memoryWindow{}}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, l Limit) (Result, error) {
+	now := time.Now()
+	start := now.Truncate(l.Window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.After(m.nextSweep) {
+		for k, w := range m.windows {
+			if now.After(w.end) {
+				delete(m.windows, k)
+			}
+		}
+		m.nextSweep = now.Add(sweepInterval)
+	}
+
+	w := m.windows[key]
+	if !w.start.Equal(start) {
+		w = memoryWindow{start: start, end: start.Add(l.Window)}
+	}
+	w.count++
+	m.windows[key] = w
+
+	return result(w.count, l, start, now), nil
+}
+
+type Postgres struct {
+	db *sqlx.DB
+
+	mu        sync.Mutex
+	nextSweep time.Time
+}
+
+func NewPostgres(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	now := time.Now()
+	start := now.Truncate(l.Window)
+
+	q := `INSERT INTO rate_limits (key, window_start, count, expires_at)
+			VALUES ($1, $2, 1, $3)
+			ON CONFLICT (key) DO UPDATE SET
+				count = CASE WHEN rate_limits.window_start = EXCLUDED.window_start THEN rate_limits.count + 1 ELSE 1 END,
+				window_start = EXCLUDED.window_start,
+				expires_at = EXCLUDED.expires_at
+			RETURNING count`
+
+	var count int
+	if err := p.db.GetContext(ctx, &count, q, key, start, start.Add(l.Window)); err != nil {
+		return Result{}, fmt.Errorf("count rate limit: %w", err)
+	}
+
+	if err := p.sweep(ctx, now); err != nil {
+		return Result{}, err
+	}
+
+	return result(count, l, start, now), nil
+}
+
+// sweep drops finished windows every few minutes rather than on every request.
+func (p *Postgres) sweep(ctx context.Context, now time.Time) error {
+	p.mu.Lock()
+	due := now.After(p.nextSweep)
+	if due {
+		p.nextSweep = now.Add(sweepInterval)
+	}
+	p.mu.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	if _, err := p.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE expires_at < $1`, now); err != nil {
+		return fmt.Errorf("sweep rate limits: %w", err)
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/zachczx/cubby/api/internal/apperr"
 	"github.com/zachczx/cubby/api/internal/logging"
 )
 
@@ -18,10 +19,10 @@ type IDResponse struct {
 }
 
 type ErrorResponse struct {
-	Status  int               `json:"error,omitempty"`
-	Message string            `json:"message,omitempty"`
-	Field   string            `json:"field,omitempty"`
-	Errors  map[string]string `json:"errors,omitempty"`
+	Status  int         `json:"error,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Field   string      `json:"field,omitempty"`
+	Errors  FieldErrors `json:"errors,omitempty"`
 }
 
 func WriteJSON(ctx context.Context, w http.ResponseWriter, data any) {
@@ -67,14 +68,51 @@ func WriteError(ctx context.Context, w http.ResponseWriter, err error) {
 			Status:  http.StatusBadRequest,
 			Message: valErr.Message,
 			Field:   valErr.Field,
+			Errors:  FieldErrors{valErr.Field: {Code: valErr.Code, Message: valErr.Message}},
+		}
+		writeJSON(ctx, w, http.StatusBadRequest, errResp)
+		return
+	}
+
+	var fieldErrs FieldErrors
+
+	if errors.As(err, &fieldErrs) {
+		// Field and Message repeat the first error for clients that only read those.
+		first := fieldErrs.fields()[0]
+		errResp = ErrorResponse{
+			Status:  http.StatusBadRequest,
+			Message: fieldErrs[first].Message,
+			Field:   first,
+			Errors:  fieldErrs,
 		}
 		writeJSON(ctx, w, http.StatusBadRequest, errResp)
 		return
 	}
 
 	var pgErr *pgconn.PgError
+	var maxBytesErr *http.MaxBytesError
 
 	switch {
+	case errors.As(err, &maxBytesErr):
+		errResp = ErrorResponse{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must be at most %d bytes", maxBytesErr.Limit),
+		}
+		writeJSON(ctx, w, http.StatusRequestEntityTooLarge, errResp)
+		return
+
+	case errors.Is(err, apperr.ErrNotFound):
+		writeKind(ctx, w, http.StatusNotFound, err, "could not find resource")
+		return
+
+	case errors.Is(err, apperr.ErrForbidden):
+		writeKind(ctx, w, http.StatusForbidden, err, "forbidden")
+		return
+
+	case errors.Is(err, apperr.ErrConflict):
+		writeKind(ctx, w, http.StatusConflict, err, "conflict")
+		return
+
 	case errors.Is(err, sql.ErrNoRows):
 		errResp = ErrorResponse{
 			Status:  http.StatusNotFound,
@@ -116,7 +154,20 @@ func writeJSON(ctx context.Context, w http.ResponseWriter, status int, data any)
 	}
 }
 
+// writeKind sends the message of the apperr.Error in err's chain, which is written for the client, and def for a bare
+// kind whose wrapping may include internal detail.
+func writeKind(ctx context.Context, w http.ResponseWriter, status int, err error, def string) {
+	msg := def
+
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		msg = appErr.Error()
+	}
+
+	writeJSON(ctx, w, status, ErrorResponse{Status: status, Message: msg})
+}
+
+// RespondWithError writes a fixed message in the same JSON shape as WriteError, for failures that aren't a Go error.
 func RespondWithError(w http.ResponseWriter, status int, message string) {
-	w.WriteHeader(status)
-	fmt.Fprintln(w, message)
+	writeJSON(context.Background(), w, status, ErrorResponse{Status: status, Message: message})
 }
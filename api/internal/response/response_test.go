@@ -0,0 +1,49 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zachczx/cubby/api/internal/apperr"
+)
+
+func TestWriteErrorMapsKinds(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"not found", apperr.NotFound("tracker not found"), http.StatusNotFound, "tracker not found"},
+		{"wrapped", fmt.Errorf("delete tracker: %w", apperr.NotFound("tracker not found")), http.StatusNotFound, "tracker not found"},
+		{"forbidden", apperr.Forbidden("only the owner can do that"), http.StatusForbidden, "only the owner can do that"},
+		{"conflict", apperr.Conflict("already in a family"), http.StatusConflict, "already in a family"},
+		{"bare kind", fmt.Errorf("lookup %s: %w", "secret", apperr.ErrNotFound), http.StatusNotFound, "could not find resource"},
+		{"body too large", fmt.Errorf("decode: %w", &http.MaxBytesError{Limit: 1024}), http.StatusRequestEntityTooLarge, "request body must be at most 1024 bytes"},
+		{"other", fmt.Errorf("boom"), http.StatusInternalServerError, "an error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteError(context.Background(), rec, tt.err)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			var body ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+
+			if body.Message != tt.message {
+				t.Fatalf("message = %q, want %q", body.Message, tt.message)
+			}
+		})
+	}
+}
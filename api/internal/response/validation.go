@@ -1,14 +1,35 @@
 package response
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
 
 const (
 	MaxCharLength  = 255
 	LongTextLength = 1028
 )
 
+// Error codes sent with each field in ErrorResponse.Errors. Clients key their form messages off these, so they must
+// not change; the accompanying message is only a default and may be reworded.
+const (
+	CodeInvalid       = "invalid"
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidChoice = "invalid_choice"
+	CodeInvalidFormat = "invalid_format"
+)
+
+type FieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 type ValidationError struct {
 	Field   string
+	Code    string
 	Message string
 }
 
@@ -17,12 +38,74 @@ func (v ValidationError) Error() string {
 }
 
 func ValErr(field, msg string) error {
-	return &ValidationError{Field: field, Message: msg}
+	return &ValidationError{Field: field, Code: CodeInvalid, Message: msg}
 }
 
 func ValErrf(field string, format string, args ...any) error {
 	return &ValidationError{
 		Field:   field,
+		Code:    CodeInvalid,
 		Message: fmt.Sprintf(format, args...),
 	}
 }
+
+// FieldErrors holds every failed field of one input, keyed by its JSON name.
+type FieldErrors map[string]FieldError
+
+func (e FieldErrors) Error() string {
+	fields := e.fields()
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f + ": " + e[f].Message
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func (e FieldErrors) fields() []string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	slices.Sort(fields)
+
+	return fields
+}
+
+// Validator collects field errors so a form can flag everything wrong with it in one response rather than one
+// field per round trip. The zero value is ready to use.
+type Validator struct {
+	errs FieldErrors
+}
+
+// Check adds the error when ok is false.
+func (v *Validator) Check(ok bool, field, code, msg string) {
+	if !ok {
+		v.Add(field, code, msg)
+	}
+}
+
+// Add records an error for field. Only the first one per field is kept; it's usually the most basic.
+func (v *Validator) Add(field, code, msg string) {
+	if v.errs == nil {
+		v.errs = FieldErrors{}
+	}
+
+	if _, ok := v.errs[field]; !ok {
+		v.errs[field] = FieldError{Code: code, Message: msg}
+	}
+}
+
+// MaxLength checks s against max characters.
+func (v *Validator) MaxLength(field string, s string, max int) {
+	v.Check(len([]rune(s)) <= max, field, CodeTooLong, fmt.Sprintf("must be at most %d characters", max))
+}
+
+// Err returns the collected errors, or nil if there were none.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return v.errs
+}
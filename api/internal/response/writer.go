@@ -0,0 +1,42 @@
+package response
+
+import "net/http"
+
+// StatusWriter remembers the status code written through it, for middleware that reports on the response after the
+// handler returns.
+type StatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *StatusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Status is 200 if the handler wrote nothing, since that's what net/http sends.
+func (w *StatusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Written reports whether headers have gone out, after which the status can no longer change.
+func (w *StatusWriter) Written() bool {
+	return w.status != 0
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer's Flush and deadlines.
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
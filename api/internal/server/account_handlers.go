@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+// DeleteAccountHandler schedules the account for deletion after the grace period and signs the user out of every
+// session. Access tokens are refused while the deletion is pending. Signing back in before then and calling
+// CancelAccountDeletionHandler keeps the account.
+func (s *Service) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	identityID, _ := r.Context().Value(IdentityIDKey).(string)
+
+	var input user.AccountDeletionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			response.WriteError(r.Context(), w, err)
+			return
+		}
+	}
+
+	deletion, err := s.Users.ScheduleAccountDeletion(r.Context(), userID, identityID, input.Transfers)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNewOwnerNotMember), errors.Is(err, user.ErrTransferToSelf):
+			response.WriteError(r.Context(), w, response.ValErr("transfers", err.Error()))
+		default:
+			writeFamilyError(r.Context(), w, err)
+		}
+		return
+	}
+
+	if identityID != "" {
+		if err := s.Auth.RevokeAll(r.Context(), identityID); err != nil {
+			logging.Error(r.Context(), "failed to revoke sessions for account deletion", "error", err)
+		}
+	}
+
+	s.clearSessionCookies(w)
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusAccepted, deletion)
+}
+
+func (s *Service) GetAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	deletion, err := s.Users.GetAccountDeletion(r.Context(), userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, deletion)
+}
+
+func (s *Service) CancelAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := s.Users.CancelAccountDeletion(r.Context(), userID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PersonalExportHandler downloads everything stored against the user's ID.
+func (s *Service) PersonalExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	p, err := s.Archives.ExportPersonal(r.Context(), userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	filename := "cubby-personal-data-" + p.ExportedAt.Format(time.DateOnly)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+
+	response.WriteJSON(r.Context(), w, p)
+}
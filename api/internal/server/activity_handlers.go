@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
+	"github.com/zachczx/cubby/api/internal/event"
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+// recordActivity is best effort: a failed audit write is logged but never fails the request that triggered it.
+func (s *Service) recordActivity(ctx context.Context, e activity.Event) {
+	if err := s.Activity.Record(ctx, e); err != nil {
+		logging.Error(ctx, "failed to record activity", "kind", e.Kind, "error", err)
+	}
+
+	s.publishActivity(ctx, e)
+}
+
+func (s *Service) recordTrackerActivity(ctx context.Context, actorID uuid.UUID, trackerID uuid.UUID, kind activity.Kind, data map[string]any) {
+	familyID, err := s.Activity.RecordForTracker(ctx, actorID, trackerID, kind, data)
+	if err != nil {
+		logging.Error(ctx, "failed to record activity", "kind", kind, "error", err)
+		return
+	}
+
+	s.publishActivity(ctx, activity.Event{FamilyID: familyID, ActorID: actorID, TrackerID: &trackerID, Kind: kind, Data: data})
+}
+
+// publishActivity puts a feed entry on the event bus under the same name, with the tracker folded into the data.
+func (s *Service) publishActivity(ctx context.Context, e activity.Event) {
+	data := make(map[string]any, len(e.Data)+1)
+	maps.Copy(data, e.Data)
+
+	if e.TrackerID != nil {
+		data["trackerId"] = *e.TrackerID
+	}
+
+	s.Events.Publish(ctx, event.Event{
+		Type:     event.Type(e.Kind),
+		FamilyID: e.FamilyID,
+		ActorID:  &e.ActorID,
+		Data:     data,
+	})
+}
+
+func (s *Service) GetFamilyActivityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	isMember, err := s.Users.IsFamilyMember(r.Context(), userID, familyID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+	if !isMember {
+		response.WriteError(r.Context(), w, user.ErrNotFamilyMember)
+		return
+	}
+
+	var before *uuid.UUID
+	if c := r.URL.Query().Get("before"); c != "" {
+		id, err := uuid.Parse(c)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("before", "invalid cursor"))
+			return
+		}
+		before = &id
+	}
+
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	page, err := s.Activity.GetFeed(r.Context(), familyID, before, limit)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, page)
+}
+
+func (s *Service) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	comments, err := s.Activity.GetComments(r.Context(), userID, trackerID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, comments)
+}
+
+func (s *Service) NewCommentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	var input activity.CommentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Body == "" {
+		response.WriteError(r.Context(), w, response.ValErr("body", "comment cannot be empty"))
+		return
+	}
+	if len(input.Body) > response.LongTextLength {
+		response.WriteError(r.Context(), w, response.ValErrf("body", "comment cannot exceed %d characters", response.LongTextLength))
+		return
+	}
+
+	comment, err := s.Activity.NewComment(r.Context(), userID, trackerID, input)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	s.publishActivity(r.Context(), activity.Event{
+		FamilyID:  comment.FamilyID,
+		ActorID:   userID,
+		TrackerID: &trackerID,
+		Kind:      activity.CommentCreated,
+		Data:      map[string]any{"commentId": comment.ID, "body": comment.Body},
+	})
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, comment)
+}
+
+func (s *Service) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	commentID, err := uuid.Parse(r.PathValue("commentID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Activity.DeleteComment(r.Context(), userID, commentID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
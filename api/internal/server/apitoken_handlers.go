@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/apitoken"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+// scopeRoutes maps a route's path prefix to the scopes guarding its reads and writes. Routes not listed here, such
+// as account settings, family management and token management itself, can't be reached with a token at all.
+// Longer prefixes are matched first.
+var scopeRoutes = []struct {
+	prefix string
+	read   string
+	write  string
+}{
+	{"/trackers/{trackerID}/entries", apitoken.EntriesRead, apitoken.EntriesWrite},
+	{"/trackers", apitoken.TrackersRead, apitoken.TrackersWrite},
+	{"/comments", apitoken.TrackersRead, apitoken.TrackersWrite},
+	{"/entries", apitoken.EntriesRead, apitoken.EntriesWrite},
+	{"/vacations", apitoken.VacationsRead, apitoken.VacationsWrite},
+	{"/timer-profiles", apitoken.TimersRead, apitoken.TimersWrite},
+	{"/gym", apitoken.GymRead, apitoken.GymWrite},
+	{"/market", apitoken.MarketRead, apitoken.MarketWrite},
+	{"/users/me/families", apitoken.FamiliesRead, ""},
+	{"/families/{familyID}/activity", apitoken.FamiliesRead, ""},
+}
+
+// requiredScope returns the scope a token needs for the matched route pattern, or false if tokens aren't accepted.
+func requiredScope(pattern string) (string, bool) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return "", false
+	}
+
+	for _, sr := range scopeRoutes {
+		if path != sr.prefix && !strings.HasPrefix(path, sr.prefix+"/") {
+			continue
+		}
+
+		scope := sr.write
+		if method == http.MethodGet {
+			scope = sr.read
+		}
+
+		return scope, scope != ""
+	}
+
+	return "", false
+}
+
+func (s *Service) GetAccessTokensHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tokens, err := s.APITokens.List(r.Context(), userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, tokens)
+}
+
+func (s *Service) CreateAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input apitoken.Request
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		response.WriteError(r.Context(), w, response.ValErr("name", "name is required"))
+		return
+	}
+	if len(input.Name) > response.MaxCharLength {
+		response.WriteError(r.Context(), w, response.ValErrf("name", "name cannot exceed %d characters", response.MaxCharLength))
+		return
+	}
+
+	scopes, err := apitoken.ValidateScopes(input.Scopes)
+	if err != nil {
+		response.WriteError(r.Context(), w, response.ValErr("scopes", err.Error()))
+		return
+	}
+	if len(scopes) == 0 {
+		response.WriteError(r.Context(), w, response.ValErr("scopes", "at least one scope is required"))
+		return
+	}
+
+	if input.ExpiresInDays < 0 || input.ExpiresInDays > apitoken.MaxExpiryDays {
+		response.WriteError(r.Context(), w, response.ValErrf("expiresInDays", "expiresInDays must be between 0 and %d", apitoken.MaxExpiryDays))
+		return
+	}
+
+	token, err := s.APITokens.Create(r.Context(), userID, input.Name, scopes, input.ExpiresInDays)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, token)
+}
+
+func (s *Service) RevokeAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := s.APITokens.Revoke(r.Context(), userID, tokenID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
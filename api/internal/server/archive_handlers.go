@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+const maxImportBodyBytes = 10 << 20
+
+// ExportHandler returns the JSON archive, or with ?format=csv&domain=<name> a single domain as CSV.
+func (s *Service) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	domain := r.URL.Query().Get("domain")
+
+	switch format {
+	case "", "json":
+	case "csv":
+		if !slices.Contains(archive.Domains, domain) {
+			response.WriteError(r.Context(), w, response.ValErrf("domain", "must be one of: %s", strings.Join(archive.Domains, ", ")))
+			return
+		}
+	default:
+		response.WriteError(r.Context(), w, response.ValErr("format", "must be json or csv"))
+		return
+	}
+
+	a, err := s.Archives.Export(r.Context(), userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	filename := "cubby-export-" + a.ExportedAt.Format(time.DateOnly)
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.csv"`, filename, domain))
+
+		if err := archive.WriteCSV(w, a, domain); err != nil {
+			response.WriteError(r.Context(), w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+	response.WriteJSON(r.Context(), w, a)
+}
+
+// ImportHandler restores an archive into ?familyId, or the caller's active family. ?dryRun=true reports what would change without saving.
+func (s *Service) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("dryRun", "must be true or false"))
+			return
+		}
+	}
+
+	var requested *uuid.UUID
+	if v := r.URL.Query().Get("familyId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("familyId", "must be a valid id"))
+			return
+		}
+		requested = &id
+	}
+
+	familyID, err := s.Users.ResolveFamilyID(r.Context(), userID, requested)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+
+	var a archive.Archive
+
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		// An oversized upload is a 413, not a malformed archive.
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.WriteError(r.Context(), w, err)
+			return
+		}
+		response.WriteError(r.Context(), w, response.ValErr("body", "not a valid archive"))
+		return
+	}
+
+	if err := validateArchive(&a); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	result, err := s.Archives.Import(r.Context(), userID, familyID, a, dryRun)
+	if err != nil {
+		if errors.Is(err, archive.ErrUnsupportedVersion) {
+			response.WriteError(r.Context(), w, response.ValErr("version", err.Error()))
+			return
+		}
+
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if dryRun {
+		response.WriteJSON(r.Context(), w, result)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, result)
+}
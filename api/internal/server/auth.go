@@ -1,54 +1,97 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"github.com/zachczx/cubby/api/internal/activity"
+	"github.com/zachczx/cubby/api/internal/apitoken"
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/auth"
+	"github.com/zachczx/cubby/api/internal/calendar"
+	"github.com/zachczx/cubby/api/internal/config"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/event"
+	"github.com/zachczx/cubby/api/internal/gym"
+	"github.com/zachczx/cubby/api/internal/health"
+	"github.com/zachczx/cubby/api/internal/mailer"
+	"github.com/zachczx/cubby/api/internal/market"
 	"github.com/zachczx/cubby/api/internal/notifier"
+	"github.com/zachczx/cubby/api/internal/timer"
+	"github.com/zachczx/cubby/api/internal/tracker"
 	"github.com/zachczx/cubby/api/internal/user"
+	"github.com/zachczx/cubby/api/internal/webhook"
 )
 
 type Service struct {
-	Client                *stytchapi.API
+	Auth                  auth.Authenticator
 	DB                    *sqlx.DB
 	TrackerDefaultCreator TrackerDefaultCreator
 	UserManager           UserManager
+	Trackers              TrackerRepository
+	Entries               EntryRepository
+	Gym                   GymRepository
+	Market                MarketRepository
+	Users                 UserRepository
+	Timers                TimerRepository
+	Activity              ActivityRepository
+	APITokens             APITokenRepository
+	Archives              ArchiveRepository
+	Calendar              CalendarRepository
+	Webhooks              WebhookRepository
 	Notifier              *notifier.FCMClient
+	Mailer                mailer.Mailer
+	Events                *event.Bus
+	Health                *health.Checker
 	CookieConfig          CookieConfig
 	AllowedOrigins        []string
+	PublicWebURL          string
+	// Development allows plain-http app cookies and webhooks to local addresses.
+	Development bool
+	identities  *identityCache
 }
 
 type TrackerDefaultCreator interface {
-	CreateDefaults(db *sqlx.DB, userID uuid.UUID) error
+	CreateDefaults(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error
 }
 
 type UserManager interface {
-	GetInternalUserID(db *sqlx.DB, email string) (uuid.UUID, error)
-	SyncUserInternal(db *sqlx.DB, email string, createdAt time.Time) (bool, uuid.UUID, error)
-	Get(db *sqlx.DB, email string) (user.User, error)
+	GetInternalUserID(ctx context.Context, db *sqlx.DB, email string) (uuid.UUID, error)
+	SyncUserInternal(ctx context.Context, db *sqlx.DB, email string, createdAt time.Time) (bool, uuid.UUID, error)
+	Get(ctx context.Context, db *sqlx.DB, email string) (user.User, error)
 }
 
-func NewService(projectID string, secret string, DB *sqlx.DB, dc TrackerDefaultCreator, um UserManager, fcm *notifier.FCMClient, cc CookieConfig, ao []string) *Service {
-	client, err := stytchapi.NewClient(projectID, secret)
-	if err != nil {
-		log.Fatalf("Error creating client: %v", err)
-	}
-
+func NewService(a auth.Authenticator, DB *sqlx.DB, dc TrackerDefaultCreator, um UserManager, fcm *notifier.FCMClient, m mailer.Mailer, events *event.Bus, cfg config.Config) *Service {
 	return &Service{
-		Client:                client,
+		Auth:                  a,
 		DB:                    DB,
 		TrackerDefaultCreator: dc,
 		UserManager:           um,
+		Trackers:              tracker.NewRepository(DB),
+		Entries:               entry.NewRepository(DB),
+		Gym:                   gym.NewRepository(DB),
+		Market:                market.NewRepository(DB),
+		Users:                 user.NewRepository(DB),
+		Timers:                timer.NewRepository(DB),
+		Activity:              activity.NewRepository(DB),
+		APITokens:             apitoken.NewRepository(DB),
+		Archives:              archive.NewRepository(DB),
+		Calendar:              calendar.NewRepository(DB),
+		Webhooks:              webhook.NewRepository(DB),
 		Notifier:              fcm,
-		CookieConfig:          cc,
-		AllowedOrigins:        ao,
+		Mailer:                m,
+		Events:                events,
+		Health:                health.NewChecker(DB, fcm),
+		CookieConfig:          NewCookieConfig(cfg),
+		AllowedOrigins:        cfg.AllowedOrigins(),
+		PublicWebURL:          cfg.PublicWebURL,
+		Development:           cfg.Development(),
+		identities:            newIdentityCache(),
 	}
 }
 
@@ -68,7 +111,7 @@ func (s *Service) setSessionCookies(w http.ResponseWriter, r *http.Request, jwt
 	partitioned := s.CookieConfig.Partitioned
 	domain := s.CookieConfig.Domain
 
-	if os.Getenv("ENV") == "development" && r.Header.Get("x-capacitor-app") == "true" {
+	if s.Development && r.Header.Get("x-capacitor-app") == "true" {
 		secure = false
 		sameSite = http.SameSiteLaxMode
 		partitioned = false
@@ -100,29 +143,29 @@ func (s *Service) setSessionCookies(w http.ResponseWriter, r *http.Request, jwt
 	})
 }
 
-func NewCookieConfig() CookieConfig {
+func NewCookieConfig(cfg config.Config) CookieConfig {
 	c := CookieConfig{
 		Path:        "/",
 		HTTPOnly:    true,
-		Domain:      os.Getenv("COOKIE_DOMAIN"),
+		Domain:      cfg.CookieDomain,
 		Secure:      true,
 		SameSite:    http.SameSiteDefaultMode,
 		Partitioned: true,
 	}
 
-	if os.Getenv("ENV") == "development" {
+	if cfg.Development() {
 		c.SameSite = http.SameSiteNoneMode
 	}
 
 	return c
 }
 
-func (s *Service) getUser(userID string) (user.User, error) {
+func (s *Service) getUser(ctx context.Context, userID string) (user.User, error) {
 	var u user.User
 
 	q := `SELECT id, email, name FROM users WHERE id=$1`
 
-	if err := s.DB.QueryRow(q, userID).Scan(&u.ID, &u.Email, &u.Name); err != nil {
+	if err := s.DB.QueryRowContext(ctx, q, userID).Scan(&u.ID, &u.Email, &u.Name); err != nil {
 		if err == sql.ErrNoRows {
 			return u, fmt.Errorf("%w", err)
 		}
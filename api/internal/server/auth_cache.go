@@ -0,0 +1,76 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/telemetry"
+)
+
+// identityCacheTTL matches the session JWT lifetime.
+const identityCacheTTL = 5 * time.Minute
+
+// authIdentity is what a verified session resolves to.
+type authIdentity struct {
+	IdentityID string
+	UserID     uuid.UUID
+	Email      string
+	expiresAt  time.Time
+}
+
+// identityCache maps a provider session ID to the internal user, so a locally verified JWT doesn't need an
+// identity lookup and a users query on every request. Entries only live as long as a JWT, so a revoked session is
+// never trusted for longer than its JWT already would be.
+type identityCache struct {
+	mu        sync.Mutex
+	entries   map[string]authIdentity
+	nextSweep time.Time
+}
+
+func newIdentityCache() *identityCache {
+	return &identityCache{entries: map[string]authIdentity{}}
+}
+
+func (c *identityCache) get(sessionID string) (authIdentity, bool) {
+	if c == nil {
+		return authIdentity{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, ok := c.entries[sessionID]
+	if !ok || time.Now().After(id.expiresAt) {
+		return authIdentity{}, false
+	}
+
+	return id, true
+}
+
+func (c *identityCache) put(sessionID string, id authIdentity) {
+	if c == nil || sessionID == "" {
+		return
+	}
+
+	now := time.Now()
+	id.expiresAt = now.Add(identityCacheTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.After(c.nextSweep) {
+		for k, v := range c.entries {
+			if now.After(v.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(identityCacheTTL)
+	}
+
+	c.entries[sessionID] = id
+}
+
+func recordAuth(source string, d time.Duration) {
+	telemetry.AuthDuration.WithLabelValues(source).Observe(d.Seconds())
+}
@@ -4,73 +4,179 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks"
-	emailML "github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks/email"
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp"
-	otpEmail "github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/apitoken"
+	"github.com/zachczx/cubby/api/internal/auth"
 	"github.com/zachczx/cubby/api/internal/logging"
 	"github.com/zachczx/cubby/api/internal/response"
-	"github.com/zachczx/cubby/api/internal/timer"
 )
 
 type contextKey string
 
 const (
-	UserIDKey contextKey = "userID"
-	EmailKey  contextKey = "email"
+	UserIDKey     contextKey = "userID"
+	EmailKey      contextKey = "email"
+	IdentityIDKey contextKey = "identityID"
 )
 
+var (
+	errNotAuthenticated = errors.New("not authenticated")
+	errNoEmail          = errors.New("email required")
+	errUnknownUser      = errors.New("user not found")
+)
+
+// RequireAuthentication accepts either the session cookies or a personal access token in an
+// "Authorization: Bearer" header. Tokens are limited to the routes and methods their scopes cover.
 func (s *Service) RequireAuthentication(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		u := s.GetAuthenticatedUser(w, r)
-
-		if u == nil || u.UserID == "" {
-			response.RespondWithError(w, http.StatusForbidden, "unauthorized access")
+		if value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			s.serveWithAccessToken(w, r, value, h)
 			return
 		}
 
-		email := ""
-		if len(u.Emails) > 0 {
-			email = u.Emails[0].Email
-		} else {
-			resp, err := s.Client.Users.Get(r.Context(), &users.GetParams{
-				UserID: u.UserID,
-			})
-			if err != nil {
-				logging.Error(r.Context(), "failed to fetch user details", "error", err)
+		id, err := s.authenticate(w, r)
+		if err != nil {
+			switch {
+			case errors.Is(err, errNotAuthenticated):
+				response.RespondWithError(w, http.StatusForbidden, "unauthorized access")
+			case errors.Is(err, errNoEmail):
+				response.RespondWithError(w, http.StatusBadRequest, "email required")
+			case errors.Is(err, errUnknownUser):
+				response.RespondWithError(w, http.StatusUnauthorized, "user not found")
+			default:
 				response.RespondWithError(w, http.StatusInternalServerError, "internal server error")
-				return
-			}
-			if len(resp.Emails) > 0 {
-				email = resp.Emails[0].Email
 			}
+			return
 		}
 
-		if email == "" {
-			response.RespondWithError(w, http.StatusBadRequest, "email required")
+		noteUser(r.Context(), id.UserID)
+
+		ctx := context.WithValue(r.Context(), UserIDKey, id.UserID)
+		ctx = context.WithValue(ctx, EmailKey, id.Email)
+		ctx = context.WithValue(ctx, IdentityIDKey, id.IdentityID)
+
+		h(w, r.WithContext(ctx))
+	}
+}
+
+// noteUser records the authenticated user for the request's access log line.
+func noteUser(ctx context.Context, userID uuid.UUID) {
+	if info := logging.RequestInfoFrom(ctx); info != nil {
+		info.UserID = userID.String()
+	}
+}
+
+func (s *Service) serveWithAccessToken(w http.ResponseWriter, r *http.Request, value string, h http.HandlerFunc) {
+	start := time.Now()
+
+	p, err := s.APITokens.Authenticate(r.Context(), strings.TrimSpace(value))
+	if err != nil {
+		recordAuth("failed", time.Since(start))
+		if errors.Is(err, apitoken.ErrInvalidToken) {
+			response.RespondWithError(w, http.StatusUnauthorized, err.Error())
 			return
 		}
+		logging.Error(r.Context(), "access token authentication failed", "error", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	recordAuth("token", time.Since(start))
+
+	scope, ok := requiredScope(r.Pattern)
+	if !ok {
+		response.RespondWithError(w, http.StatusForbidden, "access tokens cannot be used for this endpoint")
+		return
+	}
+	if !p.HasScope(scope) {
+		response.RespondWithError(w, http.StatusForbidden, "access token is missing the "+scope+" scope")
+		return
+	}
+
+	noteUser(r.Context(), p.UserID)
+
+	ctx := context.WithValue(r.Context(), UserIDKey, p.UserID)
+	ctx = context.WithValue(ctx, EmailKey, p.Email)
+
+	h(w, r.WithContext(ctx))
+}
 
-		localUserID, err := s.UserManager.GetInternalUserID(s.DB, email)
+// authenticate resolves the request's session to an internal user. A JWT the provider verifies locally is answered
+// from the identity cache when possible; only a cache miss looks up the identity and the users table, and only an
+// expired or missing JWT goes back to the provider to refresh the session.
+func (s *Service) authenticate(w http.ResponseWriter, r *http.Request) (id authIdentity, err error) {
+	start := time.Now()
+	source := "failed"
+	defer func() {
 		if err != nil {
-			logging.Error(r.Context(), "failed to get internal user", "error", err)
-			response.RespondWithError(w, http.StatusUnauthorized, "user not found")
-			return
+			source = "failed"
 		}
+		recordAuth(source, time.Since(start))
+	}()
 
-		ctx := context.WithValue(r.Context(), UserIDKey, localUserID)
-		ctx = context.WithValue(ctx, EmailKey, email)
+	var sessionID, email string
 
-		h(w, r.WithContext(ctx))
+	if cookie, cErr := r.Cookie("stytch_session_jwt"); cErr == nil {
+		if sid, identityID, jErr := s.Auth.ValidateJWT(r.Context(), cookie.Value); jErr == nil {
+			if cached, ok := s.identities.get(sid); ok {
+				source = "cache"
+				return cached, nil
+			}
+
+			ident, iErr := s.Auth.GetIdentity(r.Context(), identityID)
+			if iErr != nil {
+				logging.Error(r.Context(), "failed to fetch user details", "error", iErr)
+				return id, fmt.Errorf("get identity: %w", iErr)
+			}
+
+			source = "jwt"
+			sessionID = sid
+			id.IdentityID = identityID
+			email = ident.Email
+		}
+	}
+
+	if source != "jwt" {
+		cookie, cErr := r.Cookie("stytch_session_token")
+		if cErr != nil {
+			return id, errNotAuthenticated
+		}
+
+		sess, aErr := s.Auth.RefreshSession(r.Context(), cookie.Value)
+		if aErr != nil {
+			logging.Error(r.Context(), "session authentication failed", "error", aErr)
+			return id, errNotAuthenticated
+		}
+
+		s.setSessionCookies(w, r, sess.JWT, sess.Token)
+
+		source = "session"
+		sessionID = sess.ID
+		id.IdentityID = sess.Identity.ID
+		email = sess.Identity.Email
+	}
+
+	if email == "" {
+		return id, errNoEmail
+	}
+
+	userID, err := s.UserManager.GetInternalUserID(r.Context(), s.DB, email)
+	if err != nil {
+		logging.Error(r.Context(), "failed to get internal user", "error", err)
+		return id, errUnknownUser
 	}
+
+	id.UserID = userID
+	id.Email = email
+	s.identities.put(sessionID, id)
+
+	return id, nil
 }
 
 func (s *Service) SendMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
@@ -88,12 +194,7 @@ func (s *Service) SendMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := s.Client.MagicLinks.Email.LoginOrCreate(
-		r.Context(),
-		&emailML.LoginOrCreateParams{
-			Email: email,
-		})
-	if err != nil {
+	if err := s.Auth.SendMagicLink(r.Context(), email); err != nil {
 		logging.Error(r.Context(), "failed to send magic link email", "error", err)
 		http.Error(w, "error sending email", http.StatusInternalServerError)
 		return
@@ -117,18 +218,14 @@ func (s *Service) SendOTPHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := s.Client.OTPs.Email.LoginOrCreate(
-		r.Context(),
-		&otpEmail.LoginOrCreateParams{
-			Email: email,
-		})
+	methodID, err := s.Auth.SendOTP(r.Context(), email)
 	if err != nil {
 		logging.Error(r.Context(), "failed to send OTP email", "error", err)
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	mID := OTPInput{MethodID: resp.EmailID}
+	mID := OTPInput{MethodID: methodID}
 
 	response.WriteJSON(r.Context(), w, mID)
 }
@@ -137,26 +234,28 @@ func (s *Service) MagicLinkHandler(w http.ResponseWriter, r *http.Request) {
 	tokenType := r.URL.Query().Get("stytch_token_type")
 	token := r.URL.Query().Get("token")
 
-	if tokenType != "magic_links" {
+	// Stytch tags its callbacks with a token type; the local provider's links carry only the token.
+	if tokenType != "" && tokenType != "magic_links" {
 		logging.Error(r.Context(), "unrecognized token type", "tokenType", tokenType)
 		http.Error(w, fmt.Sprintf("Unrecognized token type %s", tokenType), http.StatusBadRequest)
 		return
 	}
 
-	resp, err := s.Client.MagicLinks.Authenticate(r.Context(), &magiclinks.AuthenticateParams{
-		Token:                  token,
-		SessionDurationMinutes: 43200,
-	})
+	sess, err := s.Auth.AuthenticateMagicLink(r.Context(), token)
 	if err != nil {
 		logging.Error(r.Context(), "magic link authentication failed", "error", err)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			http.Error(w, "link is invalid or has expired", http.StatusUnauthorized)
+			return
+		}
 		http.Error(w, "failed to authenticate", http.StatusInternalServerError)
 		return
 	}
 
 	// Set the session cookies (JWT + Refresh Token)
-	s.setSessionCookies(w, r, resp.SessionJWT, resp.SessionToken)
+	s.setSessionCookies(w, r, sess.JWT, sess.Token)
 
-	isNewUser, userID, err := s.UserManager.SyncUserInternal(s.DB, resp.User.Emails[0].Email, *resp.User.CreatedAt)
+	isNewUser, userID, err := s.UserManager.SyncUserInternal(r.Context(), s.DB, sess.Identity.Email, sess.Identity.CreatedAt)
 	if err != nil {
 		logging.Error(r.Context(), "user sync failed", "error", err)
 		http.Error(w, "failed to sync user", http.StatusInternalServerError)
@@ -164,15 +263,15 @@ func (s *Service) MagicLinkHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if isNewUser {
-		if err := s.TrackerDefaultCreator.CreateDefaults(s.DB, userID); err != nil {
+		if err := s.TrackerDefaultCreator.CreateDefaults(r.Context(), s.DB, userID); err != nil {
 			logging.Error(r.Context(), "failed to create default trackers", "error", err)
 		}
-		if err := timer.CreateDefaults(s.DB, userID); err != nil {
+		if err := s.Timers.CreateDefaults(r.Context(), userID); err != nil {
 			logging.Error(r.Context(), "failed to create default timer profiles", "error", err)
 		}
 	}
 
-	redirectURL := os.Getenv("PUBLIC_WEB_URL")
+	redirectURL := s.PublicWebURL
 
 	if isNewUser {
 		redirectURL += "/profile/account?onboarding=true"
@@ -237,21 +336,21 @@ func (s *Service) VerifyOTPHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := s.Client.OTPs.Authenticate(r.Context(), &otp.AuthenticateParams{
-		MethodID:               otpInput.MethodID,
-		Code:                   otpInput.OTP,
-		SessionDurationMinutes: 43200,
-	})
+	sess, err := s.Auth.AuthenticateOTP(r.Context(), otpInput.MethodID, otpInput.OTP)
 	if err != nil {
 		logging.Error(r.Context(), "otp authentication failed", "error", err)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			response.RespondWithError(w, http.StatusUnauthorized, "code is invalid or has expired")
+			return
+		}
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
 	// Set the session cookies (JWT + Refresh Token)
-	s.setSessionCookies(w, r, resp.SessionJWT, resp.SessionToken)
+	s.setSessionCookies(w, r, sess.JWT, sess.Token)
 
-	isNewUser, userID, err := s.UserManager.SyncUserInternal(s.DB, resp.User.Emails[0].Email, *resp.User.CreatedAt)
+	isNewUser, userID, err := s.UserManager.SyncUserInternal(r.Context(), s.DB, sess.Identity.Email, sess.Identity.CreatedAt)
 	if err != nil {
 		logging.Error(r.Context(), "user sync failed", "error", err)
 		http.Error(w, "failed to sync user", http.StatusInternalServerError)
@@ -259,10 +358,10 @@ func (s *Service) VerifyOTPHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if isNewUser {
-		if err := s.TrackerDefaultCreator.CreateDefaults(s.DB, userID); err != nil {
+		if err := s.TrackerDefaultCreator.CreateDefaults(r.Context(), s.DB, userID); err != nil {
 			logging.Error(r.Context(), "failed to create default trackers", "error", err)
 		}
-		if err := timer.CreateDefaults(s.DB, userID); err != nil {
+		if err := s.Timers.CreateDefaults(r.Context(), userID); err != nil {
 			logging.Error(r.Context(), "failed to create default timer profiles", "error", err)
 		}
 	}
@@ -276,24 +375,13 @@ func (s *Service) VerifyOTPHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Service) GetUserHandler(w http.ResponseWriter, r *http.Request) {
-	u := s.GetAuthenticatedUser(w, r)
-
-	if u == nil || u.UserID == "" {
+	id, err := s.authenticate(w, r)
+	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "not authenticated")
 		return
 	}
 
-	email := ""
-	if len(u.Emails) > 0 {
-		email = u.Emails[0].Email
-	}
-
-	if email == "" {
-		response.RespondWithError(w, http.StatusUnauthorized, "email not found")
-		return
-	}
-
-	localUser, err := s.UserManager.Get(s.DB, email)
+	localUser, err := s.UserManager.Get(r.Context(), s.DB, id.Email)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "user not found")
 		return
@@ -302,36 +390,6 @@ func (s *Service) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	response.WriteJSON(r.Context(), w, localUser)
 }
 
-func (s *Service) GetAuthenticatedUser(w http.ResponseWriter, r *http.Request) *users.User {
-	// JWT as first try
-	if cookie, err := r.Cookie("stytch_session_jwt"); err == nil {
-		if sess, err := s.Client.Sessions.AuthenticateJWTLocal(cookie.Value, 0); err == nil {
-			return &users.User{
-				UserID: sess.UserID,
-			}
-		}
-	}
-
-	// Session Token Fallback
-	cookie, err := r.Cookie("stytch_session_token")
-	if err != nil {
-		return nil
-	}
-
-	resp, err := s.Client.Sessions.Authenticate(r.Context(), &sessions.AuthenticateParams{
-		SessionToken:           cookie.Value,
-		SessionDurationMinutes: 43200,
-	})
-	if err != nil {
-		logging.Error(r.Context(), "session authentication failed", "error", err)
-		return nil
-	}
-
-	s.setSessionCookies(w, r, resp.SessionJWT, resp.SessionToken)
-
-	return &resp.User
-}
-
 func (s *Service) Logout(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("stytch_session_token")
 	if err != nil {
@@ -339,15 +397,17 @@ func (s *Service) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := s.Client.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
-		SessionToken: cookie.Value,
-	})
-	if err != nil {
+	if err := s.Auth.Logout(r.Context(), cookie.Value); err != nil {
 		response.WriteError(r.Context(), w, fmt.Errorf("error revoking session: %w", err))
 		return
 	}
-	_ = resp
 
+	s.clearSessionCookies(w)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Service) clearSessionCookies(w http.ResponseWriter) {
 	expire := time.Now().Add(-7 * 24 * time.Hour)
 
 	http.SetCookie(w, &http.Cookie{
@@ -375,6 +435,4 @@ func (s *Service) Logout(w http.ResponseWriter, r *http.Request) {
 		Expires:     expire,
 		MaxAge:      -1,
 	})
-
-	w.WriteHeader(http.StatusOK)
 }
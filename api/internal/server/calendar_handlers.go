@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/calendar"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+// CalendarFeedHandler serves the ICS feed. It is unauthenticated: the secret token in the path is the credential.
+func (s *Service) CalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("token"), ".ics")
+	if !ok || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID, err := s.Calendar.LookupToken(r.Context(), token)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+
+	events, err := s.Calendar.BuildFeed(r.Context(), userID, loc)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="cubby.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=900")
+
+	if err := calendar.WriteICS(w, "Cubby", events); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+}
+
+func (s *Service) GetCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	info, err := s.Calendar.GetTokenInfo(r.Context(), userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, info)
+}
+
+// RotateCalendarTokenHandler creates the feed token, or replaces it so the old URL stops working.
+func (s *Service) RotateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	token, err := s.Calendar.RotateToken(r.Context(), userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, token)
+}
+
+func (s *Service) RevokeCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := s.Calendar.RevokeToken(r.Context(), userID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
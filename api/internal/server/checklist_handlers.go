@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/tracker"
+)
+
+func (s *Service) GetChecklistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	items, err := s.Trackers.GetChecklist(r.Context(), userID, trackerID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, items)
+}
+
+func (s *Service) AddChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	var input tracker.ChecklistItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	input.Label = strings.TrimSpace(input.Label)
+	if input.Label == "" {
+		response.WriteError(r.Context(), w, response.ValErr("label", "label is required"))
+		return
+	}
+
+	item, err := s.Trackers.AddChecklistItem(r.Context(), userID, trackerID, input)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, item)
+}
+
+func (s *Service) EditChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	var input tracker.ChecklistItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	input.Label = strings.TrimSpace(input.Label)
+	if input.Label == "" {
+		response.WriteError(r.Context(), w, response.ValErr("label", "label is required"))
+		return
+	}
+
+	if err := s.Trackers.EditChecklistItem(r.Context(), userID, trackerID, itemID, input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) RemoveChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Trackers.RemoveChecklistItem(r.Context(), userID, trackerID, itemID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
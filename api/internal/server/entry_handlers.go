@@ -2,10 +2,13 @@ package server
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
 	"github.com/zachczx/cubby/api/internal/entry"
 	"github.com/zachczx/cubby/api/internal/response"
 )
@@ -50,17 +53,86 @@ func (s *Service) CreateEntryHandler(w http.ResponseWriter, r *http.Request) {
 		Interval:     input.Interval,
 		IntervalUnit: input.IntervalUnit,
 		Remark:       input.Remark,
+
+		CompletedItems: input.CompletedItems,
 	}
 
-	new, err := entry.Create(s.DB, e)
+	new, err := s.Entries.Create(r.Context(), e)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	s.recordTrackerActivity(r.Context(), userID, trackerID, activity.EntryCreated, map[string]any{
+		"entryId":     new.ID,
+		"performedAt": new.PerformedAt,
+		"remark":      new.Remark,
+	})
+
 	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, new)
 }
 
+const maxBulkBodyBytes = 1 << 20
+
+// CreateBulkEntriesHandler accepts either JSON (entry.BulkInput) or a text/csv body of "performedAt[,remark]" rows.
+// For CSV, interval and intervalUnit may be passed as query params; otherwise the tracker's current interval is used.
+func (s *Service) CreateBulkEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	trackerID, err := uuid.Parse(r.PathValue("trackerID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+
+	var input entry.BulkInput
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		rows, err := entry.ParseBulkCSV(r.Body)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("body", err.Error()))
+			return
+		}
+		input.Entries = rows
+
+		if v := r.URL.Query().Get("interval"); v != "" {
+			input.Interval, err = strconv.Atoi(v)
+			if err != nil {
+				response.WriteError(r.Context(), w, response.ValErr("interval", "must be a number"))
+				return
+			}
+		}
+		input.IntervalUnit = r.URL.Query().Get("intervalUnit")
+	} else if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := validateBulkInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	result, err := s.Entries.CreateBulk(r.Context(), userID, trackerID, input)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if result.Created > 0 {
+		s.recordTrackerActivity(r.Context(), userID, trackerID, activity.EntryBulkCreated, map[string]any{"created": result.Created})
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, result)
+}
+
 func (s *Service) GetAllEntriesHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := s.GetUserIDFromContext(r.Context())
 	if err != nil {
@@ -68,7 +140,7 @@ func (s *Service) GetAllEntriesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entries, err := entry.GetAll(s.DB, userID)
+	entries, err := s.Entries.GetAll(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -91,11 +163,22 @@ func (s *Service) DeleteEntryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := entry.Delete(s.DB, userID, entryID); err != nil {
+	existing, err := s.Entries.GetOwned(r.Context(), userID, entryID)
+	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	if err := s.Entries.Delete(r.Context(), userID, entryID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	s.recordTrackerActivity(r.Context(), userID, existing.TrackerID, activity.EntryDeleted, map[string]any{
+		"entryId":     existing.ID,
+		"performedAt": existing.PerformedAt,
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -131,7 +214,7 @@ func (s *Service) EditEntryHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := entry.Edit(s.DB, userID, entryID, performedAt); err != nil {
+	if err := s.Entries.Edit(r.Context(), userID, entryID, performedAt); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
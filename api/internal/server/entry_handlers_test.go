@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/entry"
+)
+
+func TestGetAllEntriesHandlerOnlyReturnsOwnEntries(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	mine := uuid.New()
+
+	s := &Service{Entries: &fakeEntries{
+		entries: []entry.Entry{{ID: uuid.New(), TrackerID: mine, PerformedBy: owner}, {ID: uuid.New(), PerformedBy: other}},
+	}}
+
+	w := serve(s.GetAllEntriesHandler, http.MethodGet, nil, owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	var got []entry.Entry
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].TrackerID != mine {
+		t.Fatalf("got %+v, want only the caller's entries", got)
+	}
+}
+
+func TestDeleteEntryHandlerNotOwned(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	trackerID, entryID := uuid.New(), uuid.New()
+
+	// The fake has no Delete, so reaching it would panic the test.
+	s := &Service{Entries: &fakeEntries{
+		entries: []entry.Entry{{ID: entryID, TrackerID: trackerID}},
+		owners:  map[uuid.UUID]uuid.UUID{trackerID: owner},
+	}}
+
+	w := serve(s.DeleteEntryHandler, http.MethodDelete, nil, other, "entryID", entryID.String())
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/market"
+	"github.com/zachczx/cubby/api/internal/tracker"
+	"github.com/zachczx/cubby/api/internal/user"
+	"github.com/zachczx/cubby/api/internal/webhook"
+)
+
+// The fakes embed their interface so a test only fills in the methods its handler calls; anything else panics.
+// Each one applies the same ownership rule as the query it stands in for, returning sql.ErrNoRows like Postgres
+// would when the row belongs to someone else.
+
+type fakeTrackers struct {
+	TrackerRepository
+	trackers []tracker.Tracker
+}
+
+func (f *fakeTrackers) Get(_ context.Context, trackerID uuid.UUID, userID uuid.UUID) (tracker.Tracker, error) {
+	for _, t := range f.trackers {
+		if t.ID == trackerID && t.Owner == userID {
+			return t, nil
+		}
+	}
+
+	return tracker.Tracker{}, fmt.Errorf("select tracker: %w", sql.ErrNoRows)
+}
+
+func (f *fakeTrackers) GetAll(_ context.Context, userID uuid.UUID) ([]tracker.Tracker, error) {
+	var out []tracker.Tracker
+	for _, t := range f.trackers {
+		if t.Owner == userID {
+			out = append(out, t)
+		}
+	}
+
+	return out, nil
+}
+
+type fakeEntries struct {
+	EntryRepository
+	entries []entry.Entry
+	// owners maps tracker IDs to their owner. Changing an entry takes owning its tracker; listing goes by performed_by.
+	owners map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeEntries) GetOwned(_ context.Context, userID uuid.UUID, entryID uuid.UUID) (entry.Entry, error) {
+	for _, e := range f.entries {
+		if e.ID == entryID && f.owners[e.TrackerID] == userID {
+			return e, nil
+		}
+	}
+
+	return entry.Entry{}, fmt.Errorf("get entry: %w", sql.ErrNoRows)
+}
+
+func (f *fakeEntries) GetAll(_ context.Context, userID uuid.UUID) ([]entry.Entry, error) {
+	var out []entry.Entry
+	for _, e := range f.entries {
+		if e.PerformedBy == userID {
+			out = append(out, e)
+		}
+	}
+
+	return out, nil
+}
+
+type fakeMarket struct {
+	MarketRepository
+	prices []market.MarketPrice
+	// members maps user IDs to the one family each belongs to.
+	members map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeMarket) GetPrice(_ context.Context, userID uuid.UUID, priceID uuid.UUID) (market.MarketPrice, error) {
+	for _, p := range f.prices {
+		if p.ID == priceID && f.members[userID] == p.FamilyID {
+			return p, nil
+		}
+	}
+
+	return market.MarketPrice{}, fmt.Errorf("get market price: %w", sql.ErrNoRows)
+}
+
+type fakeUsers struct {
+	UserRepository
+	families map[uuid.UUID]uuid.UUID
+	// owners maps family IDs to their owner.
+	owners map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeUsers) IsFamilyOwner(_ context.Context, userID uuid.UUID, familyID uuid.UUID) (bool, error) {
+	return f.owners[familyID] == userID, nil
+}
+
+func (f *fakeUsers) ResolveFamilyID(_ context.Context, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error) {
+	familyID, ok := f.families[userID]
+	if !ok || (requested != nil && *requested != familyID) {
+		return uuid.Nil, user.ErrNotFamilyMember
+	}
+
+	return familyID, nil
+}
+
+type fakeWebhooks struct {
+	WebhookRepository
+	hooks []webhook.Webhook
+}
+
+func (f *fakeWebhooks) List(_ context.Context, familyID uuid.UUID) ([]webhook.Webhook, error) {
+	var out []webhook.Webhook
+	for _, h := range f.hooks {
+		if h.FamilyID == familyID {
+			out = append(out, h)
+		}
+	}
+
+	return out, nil
+}
+
+// serve runs h as userID, or anonymously when userID is uuid.Nil, with the given path values set.
+func serve(h http.HandlerFunc, method string, body io.Reader, userID uuid.UUID, pathValues ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", body)
+	if userID != uuid.Nil {
+		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+	}
+
+	for i := 0; i+1 < len(pathValues); i += 2 {
+		r.SetPathValue(pathValues[i], pathValues[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	h(w, r)
+
+	return w
+}
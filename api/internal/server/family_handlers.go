@@ -1,9 +1,14 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
 	"github.com/zachczx/cubby/api/internal/response"
 	"github.com/zachczx/cubby/api/internal/user"
 )
@@ -15,7 +20,7 @@ func (s *Service) GetUsersFamiliesHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	families, err := user.GetUsersFamilies(s.DB, userID)
+	families, err := s.Users.GetUsersFamilies(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -45,11 +50,18 @@ func (s *Service) DeleteFamilyMemberHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := user.DeleteMember(s.DB, familyID, userID, memberID); err != nil {
+	if err := s.Users.DeleteMember(r.Context(), familyID, userID, memberID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: familyID,
+		ActorID:  userID,
+		Kind:     activity.MemberRemoved,
+		Data:     map[string]any{"memberId": memberID},
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -67,10 +79,230 @@ func (s *Service) LeaveFamilyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := user.LeaveFamily(s.DB, familyID, userID); err != nil {
+	if err := s.Users.LeaveFamily(r.Context(), familyID, userID); err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{FamilyID: familyID, ActorID: userID, Kind: activity.MemberLeft})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) CreateFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input user.FamilyRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		response.WriteError(r.Context(), w, response.ValErr("name", "name is required"))
+		return
+	}
+	if len(input.Name) > response.MaxCharLength {
+		response.WriteError(r.Context(), w, response.ValErrf("name", "name cannot exceed %d characters", response.MaxCharLength))
+		return
+	}
+
+	familyID, err := s.Users.NewFamily(r.Context(), user.Family{Name: input.Name, OwnerID: userID})
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, familyID)
+}
+
+func (s *Service) UpdateFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input user.FamilyRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		response.WriteError(r.Context(), w, response.ValErr("name", "name is required"))
+		return
+	}
+	if len(input.Name) > response.MaxCharLength {
+		response.WriteError(r.Context(), w, response.ValErrf("name", "name cannot exceed %d characters", response.MaxCharLength))
+		return
+	}
+
+	isOwner, err := s.Users.IsFamilyOwner(r.Context(), userID, familyID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+	if !isOwner {
+		writeFamilyError(r.Context(), w, user.ErrNotFamilyOwner)
+		return
+	}
+
+	if err := s.Users.UpdateFamilyName(r.Context(), familyID, input.Name); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: familyID,
+		ActorID:  userID,
+		Kind:     activity.FamilyRenamed,
+		Data:     map[string]any{"name": input.Name},
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (s *Service) TransferFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input user.TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if input.NewOwnerID == uuid.Nil {
+		response.WriteError(r.Context(), w, response.ValErr("newOwnerId", "newOwnerId is required"))
+		return
+	}
+
+	if err := s.Users.TransferFamily(r.Context(), userID, familyID, input.NewOwnerID, input.Leave); err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: familyID,
+		ActorID:  userID,
+		Kind:     activity.FamilyTransferred,
+		Data:     map[string]any{"newOwnerId": input.NewOwnerID, "left": input.Leave},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestFamilyDeletionHandler is the first step of deleting a family: it returns the token DeleteFamilyHandler needs.
+func (s *Service) RequestFamilyDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	deletion, err := s.Users.RequestFamilyDeletion(r.Context(), userID, familyID)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, deletion)
+}
+
+func (s *Service) DeleteFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input user.DeleteFamilyRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if input.ConfirmationToken == "" {
+		response.WriteError(r.Context(), w, response.ValErr("confirmationToken", "confirmationToken is required"))
+		return
+	}
+
+	if err := s.Users.DeleteFamily(r.Context(), userID, familyID, input.ConfirmationToken); err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ActiveFamilyInput struct {
+	FamilyID *uuid.UUID `json:"familyId"`
+}
+
+// SetActiveFamilyHandler picks the family that new trackers, prices and vacations go to by default.
+// A null familyId falls back to the user's own family.
+func (s *Service) SetActiveFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input ActiveFamilyInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Users.SetActiveFamily(r.Context(), userID, input.FamilyID); err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeFamilyError(ctx context.Context, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, user.ErrNewOwnerNotMember), errors.Is(err, user.ErrTransferToSelf):
+		response.WriteError(ctx, w, response.ValErr("newOwnerId", err.Error()))
+	case errors.Is(err, user.ErrInvalidDeletionToken):
+		response.WriteError(ctx, w, response.ValErr("confirmationToken", err.Error()))
+	default:
+		response.WriteError(ctx, w, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/event"
 	"github.com/zachczx/cubby/api/internal/gym"
 	"github.com/zachczx/cubby/api/internal/response"
 )
@@ -17,7 +18,7 @@ func (s *Service) NewWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	workout, err := gym.NewWorkout(s.DB, userID)
+	workout, err := s.Gym.NewWorkout(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -33,7 +34,7 @@ func (s *Service) GetAllWorkoutsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	workouts, err := gym.GetAllWorkouts(s.DB, userID)
+	workouts, err := s.Gym.GetAllWorkouts(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -61,7 +62,7 @@ func (s *Service) EditWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.EditWorkout(s.DB, userID, workoutID, input); err != nil {
+	if err := s.Gym.EditWorkout(r.Context(), userID, workoutID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -82,7 +83,7 @@ func (s *Service) DeleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.DeleteWorkout(s.DB, userID, workoutID); err != nil {
+	if err := s.Gym.DeleteWorkout(r.Context(), userID, workoutID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -90,6 +91,46 @@ func (s *Service) DeleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *Service) CompleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	workoutID, err := uuid.Parse(r.PathValue("workoutID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	workout, completed, err := s.Gym.CompleteWorkout(r.Context(), userID, workoutID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	// Workouts are personal, so the event goes to the family the user currently has active.
+	if completed {
+		if familyID, err := s.Users.ResolveFamilyID(r.Context(), userID, nil); err == nil {
+			s.Events.Publish(r.Context(), event.Event{
+				Type:     event.WorkoutCompleted,
+				FamilyID: familyID,
+				ActorID:  &userID,
+				Data: map[string]any{
+					"workoutId":     workout.ID,
+					"startTime":     workout.StartTime,
+					"completedAt":   workout.CompletedAt,
+					"completedSets": workout.CompletedSets,
+					"volumeKg":      workout.VolumeKg,
+				},
+			})
+		}
+	}
+
+	response.WriteJSON(r.Context(), w, workout)
+}
+
 func (s *Service) NewSetHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := s.GetUserIDFromContext(r.Context())
 	if err != nil {
@@ -109,7 +150,12 @@ func (s *Service) NewSetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	set, err := gym.NewSet(s.DB, userID, workoutID, input)
+	if err := validateSetInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	set, err := s.Gym.NewSet(r.Context(), userID, workoutID, input)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -137,7 +183,12 @@ func (s *Service) EditSetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.EditSet(s.DB, userID, setID, input); err != nil {
+	if err := validateSetInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Gym.EditSet(r.Context(), userID, setID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -158,7 +209,7 @@ func (s *Service) ReorderSetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.ReorderSet(s.DB, userID, input); err != nil {
+	if err := s.Gym.ReorderSet(r.Context(), userID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -179,7 +230,7 @@ func (s *Service) DeleteSetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.DeleteSet(s.DB, userID, setID); err != nil {
+	if err := s.Gym.DeleteSet(r.Context(), userID, setID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -187,6 +238,14 @@ func (s *Service) DeleteSetHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+type Favourites struct {
+	ExerciseIDs []string `json:"exerciseIds"`
+}
+
+type FavouriteInput struct {
+	ExerciseID string `json:"exerciseId"`
+}
+
 func (s *Service) GetFavouritesHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := s.GetUserIDFromContext(r.Context())
 	if err != nil {
@@ -194,13 +253,13 @@ func (s *Service) GetFavouritesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ids, err := gym.GetFavourites(s.DB, userID)
+	ids, err := s.Gym.GetFavourites(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	response.WriteJSON(r.Context(), w, map[string][]string{"exerciseIds": ids})
+	response.WriteJSON(r.Context(), w, Favourites{ExerciseIDs: ids})
 }
 
 func (s *Service) ToggleFavouriteHandler(w http.ResponseWriter, r *http.Request) {
@@ -210,21 +269,19 @@ func (s *Service) ToggleFavouriteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var input struct {
-		ExerciseID string `json:"exerciseId"`
-	}
+	var input FavouriteInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	ids, err := gym.ToggleFavourite(s.DB, userID, input.ExerciseID)
+	ids, err := s.Gym.ToggleFavourite(r.Context(), userID, input.ExerciseID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	response.WriteJSON(r.Context(), w, map[string][]string{"exerciseIds": ids})
+	response.WriteJSON(r.Context(), w, Favourites{ExerciseIDs: ids})
 }
 
 // Routine handlers
@@ -236,7 +293,7 @@ func (s *Service) GetAllRoutinesHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	routines, err := gym.GetAllRoutines(s.DB, userID)
+	routines, err := s.Gym.GetAllRoutines(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -258,7 +315,7 @@ func (s *Service) NewRoutineHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	routine, err := gym.NewRoutine(s.DB, userID, input)
+	routine, err := s.Gym.NewRoutine(r.Context(), userID, input)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -286,7 +343,7 @@ func (s *Service) EditRoutineHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.EditRoutine(s.DB, userID, routineID, input); err != nil {
+	if err := s.Gym.EditRoutine(r.Context(), userID, routineID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -307,7 +364,7 @@ func (s *Service) ReorderRoutineHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := gym.ReorderRoutine(s.DB, userID, input); err != nil {
+	if err := s.Gym.ReorderRoutine(r.Context(), userID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -328,7 +385,7 @@ func (s *Service) DeleteRoutineHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := gym.DeleteRoutine(s.DB, userID, routineID); err != nil {
+	if err := s.Gym.DeleteRoutine(r.Context(), userID, routineID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -355,7 +412,7 @@ func (s *Service) AddRoutineExerciseHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	exercise, err := gym.AddRoutineExercise(s.DB, userID, routineID, input)
+	exercise, err := s.Gym.AddRoutineExercise(r.Context(), userID, routineID, input)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -383,7 +440,7 @@ func (s *Service) EditRoutineExerciseHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := gym.EditRoutineExercise(s.DB, userID, exerciseID, input); err != nil {
+	if err := s.Gym.EditRoutineExercise(r.Context(), userID, exerciseID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -404,7 +461,7 @@ func (s *Service) RemoveRoutineExerciseHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if err := gym.RemoveRoutineExercise(s.DB, userID, exerciseID); err != nil {
+	if err := s.Gym.RemoveRoutineExercise(r.Context(), userID, exerciseID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -425,7 +482,7 @@ func (s *Service) ReorderRoutineExerciseHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if err := gym.ReorderRoutineExercise(s.DB, userID, input); err != nil {
+	if err := s.Gym.ReorderRoutineExercise(r.Context(), userID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -446,7 +503,7 @@ func (s *Service) StartWorkoutFromRoutineHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	workout, err := gym.StartWorkoutFromRoutine(s.DB, userID, routineID)
+	workout, err := s.Gym.StartWorkoutFromRoutine(r.Context(), userID, routineID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -462,7 +519,7 @@ func (s *Service) GetGymSummaryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	summary, err := gym.GetSummary(s.DB, userID)
+	summary, err := s.Gym.GetSummary(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -478,7 +535,7 @@ func (s *Service) GetGymCalendarHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	entries, err := gym.GetCalendarWorkouts(s.DB, userID)
+	entries, err := s.Gym.GetCalendarWorkouts(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -501,7 +558,7 @@ func (s *Service) GetGymMusclesHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := gym.GetMusclesFailureStats(s.DB, userID, weeks)
+	stats, err := s.Gym.GetMusclesFailureStats(r.Context(), userID, weeks)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -517,7 +574,7 @@ func (s *Service) GetGymUserExercisesHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	exercises, err := gym.GetUserExercises(s.DB, userID)
+	exercises, err := s.Gym.GetUserExercises(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -535,11 +592,11 @@ func (s *Service) GetGymExerciseStatsHandler(w http.ResponseWriter, r *http.Requ
 
 	exerciseID := r.PathValue("exerciseID")
 	if exerciseID == "" {
-		response.RespondWithError(w, http.StatusBadRequest, "exercise_id is required")
+		response.WriteError(r.Context(), w, response.ValErr("exerciseId", "exercise id is required"))
 		return
 	}
 
-	stats, err := gym.GetExerciseStats(s.DB, userID, exerciseID)
+	stats, err := s.Gym.GetExerciseStats(r.Context(), userID, exerciseID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
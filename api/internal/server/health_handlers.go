@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/zachczx/cubby/api/internal/health"
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/response"
+)
+
+// LivenessHandler serves /healthz. Probes only look at the status code, so the body is there for people.
+func (s *Service) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	l := s.Health.Live()
+
+	status := http.StatusOK
+	if l.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	response.WriteJSONStatus(r.Context(), w, status, l)
+}
+
+// ReadinessHandler serves /readyz with a 503 and the failing checks when the API can't take traffic. It's public, so
+// the body only names the checks; why one failed is logged and shown by ReadinessDetailsHandler.
+func (s *Service) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	rd := s.Health.Ready(r.Context())
+
+	status := http.StatusOK
+	if rd.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+		logging.Error(r.Context(), "readiness check failed", "readiness", rd)
+	}
+
+	response.WriteJSONStatus(r.Context(), w, status, rd.Public())
+}
+
+// ReadinessDetailsHandler serves the full readiness breakdown, with errors, pool stats, the schema version and the
+// worker's heartbeat. It's mounted on the metrics address only.
+func (s *Service) ReadinessDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	rd := s.Health.Ready(r.Context())
+
+	status := http.StatusOK
+	if rd.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	response.WriteJSONStatus(r.Context(), w, status, rd)
+}
@@ -1,10 +1,18 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
+	"github.com/zachczx/cubby/api/internal/logging"
+	"github.com/zachczx/cubby/api/internal/mailer"
 	"github.com/zachczx/cubby/api/internal/response"
 	"github.com/zachczx/cubby/api/internal/user"
 )
@@ -16,7 +24,7 @@ func (s *Service) GetFamilyInvitesHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	invites, err := user.GetFamilyInvites(s.DB, userID)
+	invites, err := s.Users.GetFamilyInvites(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -39,7 +47,7 @@ func (s *Service) GetFamilyInviteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	invite, err := user.GetFamilyInvite(s.DB, userID, inviteID)
+	invite, err := s.Users.GetFamilyInvite(r.Context(), userID, inviteID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -62,11 +70,19 @@ func (s *Service) AcceptFamilyInviteHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := user.AcceptFamilyInvite(s.DB, userID, inviteID); err != nil {
+	invite, err := s.Users.GetFamilyInvite(r.Context(), userID, inviteID)
+	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	if err := s.Users.AcceptFamilyInvite(r.Context(), userID, inviteID); err != nil {
+		writeInviteError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{FamilyID: invite.FamilyID, ActorID: userID, Kind: activity.InviteAccepted})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -84,11 +100,19 @@ func (s *Service) DeclineFamilyInviteHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := user.DeclineFamilyInvite(s.DB, userID, inviteID); err != nil {
+	invite, err := s.Users.GetFamilyInvite(r.Context(), userID, inviteID)
+	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	if err := s.Users.DeclineFamilyInvite(r.Context(), userID, inviteID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{FamilyID: invite.FamilyID, ActorID: userID, Kind: activity.InviteDeclined})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -99,23 +123,328 @@ func (s *Service) CreateFamilyInviteHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	ownedFamilyID, err := user.GetUserFamilyID(s.DB, userID)
+	var invite user.InviteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&invite); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	ownedFamilyID, err := s.Users.ResolveOwnedFamilyID(r.Context(), userID, &invite.FamilyID)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	if strings.TrimSpace(invite.InviteeEmail) == "" {
+		response.WriteError(r.Context(), w, response.ValErr("inviteeEmail", "email is required"))
+		return
+	}
+
+	created, err := s.Users.CreateFamilyInvite(r.Context(), ownedFamilyID, userID, invite.InviteeEmail)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	var invite user.InviteRequest
+	inviterEmail, _ := s.GetUserEmailFromContext(r.Context())
+	s.sendInviteEmail(r.Context(), inviterEmail, created)
 
-	if err := json.NewDecoder(r.Body).Decode(&invite); err != nil {
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: ownedFamilyID,
+		ActorID:  userID,
+		Kind:     activity.InviteCreated,
+		Data:     map[string]any{"inviteeEmail": invite.InviteeEmail},
+	})
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// inviteEmailTimeout bounds how long an invite request waits on the mail server.
+const inviteEmailTimeout = 10 * time.Second
+
+// sendInviteEmail runs inline so shutdown waits for it like any other request. It outlives a disconnected client, and
+// delivery failures are only logged because the invite itself already exists.
+func (s *Service) sendInviteEmail(ctx context.Context, inviterEmail string, invite user.Invite) {
+	if invite.InviteeEmail == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inviteEmailTimeout)
+	defer cancel()
+
+	link, err := url.JoinPath(s.PublicWebURL, "app/profile/family/invite", invite.ID.String())
+	if err != nil {
+		logging.Error(ctx, "failed to build invite link", "inviteId", invite.ID, "error", err)
+		return
+	}
+
+	body := inviterEmail + " invited you to join their family on Cubby.\n\n"
+	if invite.InviteeID == nil {
+		body += "Sign in with this email address to accept or decline:\n"
+	} else {
+		body += "Accept or decline here:\n"
+	}
+	body += link + "\n"
+
+	m := mailer.Message{
+		To:      *invite.InviteeEmail,
+		Subject: "You're invited to a family on Cubby",
+		Body:    body,
+	}
+
+	if err := s.Mailer.Send(ctx, m); err != nil {
+		logging.Error(ctx, "failed to send invite email", "inviteId", invite.ID, "error", err)
+	}
+}
+
+func (s *Service) GetSentInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	isOwner, err := s.Users.IsFamilyOwner(r.Context(), userID, familyID)
+	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
+	if !isOwner {
+		response.WriteError(r.Context(), w, user.ErrNotFamilyOwner)
+		return
+	}
 
-	if err := user.CreateFamilyInvite(s.DB, ownedFamilyID, invite.InviteeEmail); err != nil {
+	invites, err := s.Users.GetSentInvites(r.Context(), familyID)
+	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	response.WriteJSON(r.Context(), w, invites)
+}
+
+func (s *Service) RevokeFamilyInviteHandler(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := uuid.Parse(r.PathValue("inviteID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invite, err := s.Users.GetOwnedInvite(r.Context(), userID, inviteID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Users.RevokeInvite(r.Context(), userID, inviteID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: invite.FamilyID,
+		ActorID:  userID,
+		Kind:     activity.InviteRevoked,
+		Data:     map[string]any{"inviteeEmail": invite.InviteeEmail},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendFamilyInviteHandler gives a pending or expired invite a fresh expiry and emails the invitee again.
+func (s *Service) ResendFamilyInviteHandler(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := uuid.Parse(r.PathValue("inviteID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invite, err := s.Users.ResendInvite(r.Context(), userID, inviteID)
+	if err != nil {
+		writeInviteError(r.Context(), w, err)
+		return
+	}
+
+	inviterEmail, _ := s.GetUserEmailFromContext(r.Context())
+	s.sendInviteEmail(r.Context(), inviterEmail, invite)
+
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: invite.FamilyID,
+		ActorID:  userID,
+		Kind:     activity.InviteResent,
+		Data:     map[string]any{"inviteeEmail": invite.InviteeEmail},
+	})
+
+	response.WriteJSON(r.Context(), w, invite)
+}
+
+func writeInviteError(ctx context.Context, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, user.ErrInviteExpired):
+		response.RespondWithError(w, http.StatusGone, err.Error())
+	default:
+		response.WriteError(ctx, w, err)
+	}
+}
+
+func (s *Service) GetInviteLinksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var requested *uuid.UUID
+	if v := r.URL.Query().Get("familyId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("familyId", "must be a valid id"))
+			return
+		}
+		requested = &id
+	}
+
+	ownedFamilyID, err := s.Users.ResolveOwnedFamilyID(r.Context(), userID, requested)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	links, err := s.Users.GetInviteLinks(r.Context(), ownedFamilyID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, links)
+}
+
+func (s *Service) CreateInviteLinkHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input user.InviteLinkRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			response.WriteError(r.Context(), w, err)
+			return
+		}
+	}
+
+	ownedFamilyID, err := s.Users.ResolveOwnedFamilyID(r.Context(), userID, input.FamilyID)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
+	ttl := user.DefaultInviteLinkTTL
+	if input.ExpiresInHours != 0 {
+		ttl = time.Duration(input.ExpiresInHours) * time.Hour
+	}
+	if ttl <= 0 || ttl > user.MaxInviteLinkTTL {
+		response.WriteError(r.Context(), w, response.ValErrf("expiresInHours", "must be between 1 and %d", int(user.MaxInviteLinkTTL.Hours())))
+		return
+	}
+
+	link, err := s.Users.CreateInviteLink(r.Context(), ownedFamilyID, userID, ttl)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: ownedFamilyID,
+		ActorID:  userID,
+		Kind:     activity.InviteLinkCreated,
+		Data:     map[string]any{"expiresAt": link.ExpiresAt},
+	})
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, link)
+}
+
+func (s *Service) DeleteInviteLinkHandler(w http.ResponseWriter, r *http.Request) {
+	linkID, err := uuid.Parse(r.PathValue("linkID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := s.Users.DeleteInviteLink(r.Context(), userID, linkID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInviteLinkHandler is public so the join page can name the family before the visitor signs in.
+func (s *Service) GetInviteLinkHandler(w http.ResponseWriter, r *http.Request) {
+	link, err := s.Users.GetInviteLinkByToken(r.Context(), r.PathValue("token"))
+	if err != nil {
+		writeInviteLinkError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, link)
+}
+
+func (s *Service) AcceptInviteLinkHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	link, err := s.Users.AcceptInviteLink(r.Context(), userID, r.PathValue("token"))
+	if err != nil {
+		writeInviteLinkError(r.Context(), w, err)
+		return
+	}
+
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: link.FamilyID,
+		ActorID:  userID,
+		Kind:     activity.InviteAccepted,
+		Data:     map[string]any{"inviteLinkId": link.ID},
+	})
+
+	response.WriteJSON(r.Context(), w, link)
+}
+
+func writeInviteLinkError(ctx context.Context, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, user.ErrInviteLinkExpired):
+		response.RespondWithError(w, http.StatusGone, err.Error())
+	default:
+		response.WriteError(ctx, w, err)
+	}
 }
@@ -5,12 +5,10 @@ import (
 	"net/http"
 	"time"
 
-	"strings"
-
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/event"
 	"github.com/zachczx/cubby/api/internal/market"
 	"github.com/zachczx/cubby/api/internal/response"
-	"github.com/zachczx/cubby/api/internal/user"
 )
 
 func (s *Service) LogMarketPriceHandler(w http.ResponseWriter, r *http.Request) {
@@ -22,29 +20,20 @@ func (s *Service) LogMarketPriceHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	familyID, err := user.GetUserFamilyID(s.DB, userID)
-	if err != nil {
-		response.WriteError(r.Context(), w, err)
-		return
-	}
-
 	var input market.Input
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	input.ItemName = strings.TrimSpace(input.ItemName)
-	if input.ItemName == "" {
-		response.RespondWithError(w, http.StatusBadRequest, "item name is required")
-		return
-	}
-	if input.Price < 0 {
-		response.RespondWithError(w, http.StatusBadRequest, "price cannot be negative")
+	familyID, err := s.Users.ResolveFamilyID(r.Context(), userID, input.FamilyID)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
 		return
 	}
-	if input.Quantity != nil && *input.Quantity < 0 {
-		response.RespondWithError(w, http.StatusBadRequest, "quantity cannot be negative")
+
+	if err := validateMarketInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
 		return
 	}
 
@@ -75,12 +64,29 @@ func (s *Service) LogMarketPriceHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	result, err := market.LogPrice(s.DB, p)
+	result, err := s.Market.LogPrice(r.Context(), p)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	s.Events.Publish(r.Context(), event.Event{
+		Type:     event.MarketPriceLogged,
+		FamilyID: familyID,
+		ActorID:  &loggedBy,
+		Data: map[string]any{
+			"priceId":  result.ID,
+			"isUpdate": result.IsUpdate,
+			"itemName": p.ItemName,
+			"category": p.Category,
+			"store":    p.Store,
+			"unit":     p.Unit,
+			"quantity": p.Quantity,
+			"price":    p.Price,
+			"isPromo":  p.IsPromo,
+		},
+	})
+
 	response.WriteJSON(r.Context(), w, result)
 }
 
@@ -96,7 +102,7 @@ func (s *Service) GetMarketPricesHandler(w http.ResponseWriter, r *http.Request)
 		Item:     r.URL.Query().Get("item"),
 	}
 
-	prices, err := market.GetPrices(s.DB, userID, filter)
+	prices, err := s.Market.GetPrices(r.Context(), userID, filter)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -118,7 +124,7 @@ func (s *Service) GetMarketPriceHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	price, err := market.GetPrice(s.DB, userID, priceID)
+	price, err := s.Market.GetPrice(r.Context(), userID, priceID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -146,17 +152,8 @@ func (s *Service) UpdateMarketPriceHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	input.ItemName = strings.TrimSpace(input.ItemName)
-	if input.ItemName == "" {
-		response.RespondWithError(w, http.StatusBadRequest, "item name is required")
-		return
-	}
-	if input.Price < 0 {
-		response.RespondWithError(w, http.StatusBadRequest, "price cannot be negative")
-		return
-	}
-	if input.Quantity != nil && *input.Quantity < 0 {
-		response.RespondWithError(w, http.StatusBadRequest, "quantity cannot be negative")
+	if err := validateMarketInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
 		return
 	}
 
@@ -185,7 +182,7 @@ func (s *Service) UpdateMarketPriceHandler(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	if err := market.UpdatePrice(s.DB, p, userID); err != nil {
+	if err := s.Market.UpdatePrice(r.Context(), p, userID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -202,7 +199,7 @@ func (s *Service) GetMarketInsightsHandler(w http.ResponseWriter, r *http.Reques
 
 	category := r.URL.Query().Get("category")
 
-	insights, err := market.GetInsights(s.DB, userID, category)
+	insights, err := s.Market.GetInsights(r.Context(), userID, category)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -224,7 +221,7 @@ func (s *Service) DeleteMarketPriceHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := market.DeletePrice(s.DB, userID, priceID); err != nil {
+	if err := s.Market.DeletePrice(r.Context(), userID, priceID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
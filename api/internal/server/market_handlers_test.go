@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/market"
+)
+
+func TestGetMarketPriceHandlerFamilyScoped(t *testing.T) {
+	member, outsider := uuid.New(), uuid.New()
+	familyID, priceID := uuid.New(), uuid.New()
+
+	s := &Service{Market: &fakeMarket{
+		prices:  []market.MarketPrice{{ID: priceID, FamilyID: familyID, ItemName: "eggs", Price: 3.2}},
+		members: map[uuid.UUID]uuid.UUID{member: familyID, outsider: uuid.New()},
+	}}
+
+	if w := serve(s.GetMarketPriceHandler, http.MethodGet, nil, member, "priceID", priceID.String()); w.Code != http.StatusOK {
+		t.Fatalf("member: status = %d, want 200", w.Code)
+	}
+
+	if w := serve(s.GetMarketPriceHandler, http.MethodGet, nil, outsider, "priceID", priceID.String()); w.Code != http.StatusNotFound {
+		t.Fatalf("outsider: status = %d, want 404", w.Code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"slices"
+	"strings"
 
 	"github.com/zachczx/cubby/api/internal/notifier"
 	"github.com/zachczx/cubby/api/internal/response"
@@ -30,12 +31,15 @@ func (s *Service) PushTokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !slices.Contains(platforms, t.Platform) {
-		response.RespondWithError(w, http.StatusUnprocessableEntity, "invalid platform")
+	var v response.Validator
+	v.Check(slices.Contains(platforms, t.Platform), "platform", response.CodeInvalidChoice,
+		"must be one of "+strings.Join(platforms, ", "))
+	if err := v.Err(); err != nil {
+		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	if err := notifier.SavePushToken(s.DB, userID, t.Token, t.Platform); err != nil {
+	if err := notifier.SavePushToken(r.Context(), s.DB, userID, t.Token, t.Platform); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
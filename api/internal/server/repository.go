@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
+	"github.com/zachczx/cubby/api/internal/apitoken"
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/calendar"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/gym"
+	"github.com/zachczx/cubby/api/internal/market"
+	"github.com/zachczx/cubby/api/internal/timer"
+	"github.com/zachczx/cubby/api/internal/tracker"
+	"github.com/zachczx/cubby/api/internal/user"
+	"github.com/zachczx/cubby/api/internal/webhook"
+)
+
+// The repositories below list what the handlers need from each domain package. NewService fills them with the
+// Postgres implementations; tests can set fakes on a Service directly.
+
+type TrackerRepository interface {
+	AddChecklistItem(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, input tracker.ChecklistItemInput) (tracker.ChecklistItem, error)
+	AddDependency(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, dependsOnID uuid.UUID) error
+	Delete(ctx context.Context, trackerID uuid.UUID, userID uuid.UUID) error
+	Edit(ctx context.Context, t tracker.Tracker) error
+	EditChecklistItem(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, itemID uuid.UUID, input tracker.ChecklistItemInput) error
+	Get(ctx context.Context, trackerID uuid.UUID, userID uuid.UUID) (tracker.Tracker, error)
+	GetAll(ctx context.Context, userID uuid.UUID) ([]tracker.Tracker, error)
+	GetChecklist(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID) ([]tracker.ChecklistItem, error)
+	GetDependencies(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID) ([]tracker.Dependency, error)
+	GetTrackersLast(ctx context.Context) ([]tracker.LatestEntry, error)
+	HoldBlockedTrackers(ctx context.Context, trackers []tracker.LatestEntry) ([]tracker.LatestEntry, error)
+	MuteTracker(ctx context.Context, trackerID uuid.UUID, userID uuid.UUID, isMuted bool) error
+	New(ctx context.Context, t tracker.Tracker) (uuid.UUID, error)
+	RemoveChecklistItem(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, itemID uuid.UUID) error
+	RemoveDependency(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, dependsOnID uuid.UUID) error
+	TogglePin(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, isPinned bool) error
+	ToggleShow(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, show bool) error
+}
+
+type EntryRepository interface {
+	Create(ctx context.Context, e entry.Entry) (entry.Entry, error)
+	CreateBulk(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, input entry.BulkInput) (entry.BulkResult, error)
+	Delete(ctx context.Context, userID uuid.UUID, entryID uuid.UUID) error
+	Edit(ctx context.Context, userID uuid.UUID, entryID uuid.UUID, performedAt time.Time) error
+	GetAll(ctx context.Context, userID uuid.UUID) ([]entry.Entry, error)
+	GetOwned(ctx context.Context, userID uuid.UUID, entryID uuid.UUID) (entry.Entry, error)
+}
+
+type GymRepository interface {
+	AddRoutineExercise(ctx context.Context, userID uuid.UUID, routineID uuid.UUID, input gym.RoutineExerciseInput) (gym.RoutineExercise, error)
+	CompleteWorkout(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID) (gym.CompletedWorkout, bool, error)
+	DeleteRoutine(ctx context.Context, userID uuid.UUID, routineID uuid.UUID) error
+	DeleteSet(ctx context.Context, userID uuid.UUID, setID uuid.UUID) error
+	DeleteWorkout(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID) error
+	EditRoutine(ctx context.Context, userID uuid.UUID, routineID uuid.UUID, input gym.RoutineInput) error
+	EditRoutineExercise(ctx context.Context, userID uuid.UUID, exerciseID uuid.UUID, input gym.RoutineExerciseInput) error
+	EditSet(ctx context.Context, userID uuid.UUID, setID uuid.UUID, s gym.SetInput) error
+	EditWorkout(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID, w gym.WorkoutInput) error
+	GetAllRoutines(ctx context.Context, userID uuid.UUID) ([]gym.Routine, error)
+	GetAllWorkouts(ctx context.Context, userID uuid.UUID) ([]gym.Workout, error)
+	GetCalendarWorkouts(ctx context.Context, userID uuid.UUID) ([]gym.WorkoutCalendarEntry, error)
+	GetExerciseStats(ctx context.Context, userID uuid.UUID, exerciseID string) ([]gym.ExerciseSetStats, error)
+	GetFavourites(ctx context.Context, userID uuid.UUID) ([]string, error)
+	GetMusclesFailureStats(ctx context.Context, userID uuid.UUID, weeks int) ([]gym.ExerciseFailureStats, error)
+	GetSummary(ctx context.Context, userID uuid.UUID) (gym.WorkoutSummary, error)
+	GetUserExercises(ctx context.Context, userID uuid.UUID) ([]gym.UserExercise, error)
+	NewRoutine(ctx context.Context, userID uuid.UUID, input gym.RoutineInput) (gym.Routine, error)
+	NewSet(ctx context.Context, userID uuid.UUID, workoutID uuid.UUID, s gym.SetInput) (gym.Set, error)
+	NewWorkout(ctx context.Context, userID uuid.UUID) (gym.Workout, error)
+	RemoveRoutineExercise(ctx context.Context, userID uuid.UUID, exerciseID uuid.UUID) error
+	ReorderRoutine(ctx context.Context, userID uuid.UUID, input gym.ReorderRoutineInput) error
+	ReorderRoutineExercise(ctx context.Context, userID uuid.UUID, input gym.ReorderRoutineExerciseInput) error
+	ReorderSet(ctx context.Context, userID uuid.UUID, input gym.ReorderSetInput) error
+	StartWorkoutFromRoutine(ctx context.Context, userID uuid.UUID, routineID uuid.UUID) (gym.Workout, error)
+	ToggleFavourite(ctx context.Context, userID uuid.UUID, exerciseID string) ([]string, error)
+}
+
+type MarketRepository interface {
+	DeletePrice(ctx context.Context, userID uuid.UUID, priceID uuid.UUID) error
+	GetInsights(ctx context.Context, userID uuid.UUID, category string) ([]market.MarketInsight, error)
+	GetPrice(ctx context.Context, userID uuid.UUID, priceID uuid.UUID) (market.MarketPrice, error)
+	GetPrices(ctx context.Context, userID uuid.UUID, filter market.PriceFilter) ([]market.MarketPrice, error)
+	LogPrice(ctx context.Context, p market.MarketPrice) (market.UpsertResult, error)
+	UpdatePrice(ctx context.Context, p market.MarketPrice, userID uuid.UUID) error
+}
+
+type UserRepository interface {
+	AcceptFamilyInvite(ctx context.Context, userID uuid.UUID, inviteID uuid.UUID) error
+	AcceptInviteLink(ctx context.Context, userID uuid.UUID, token string) (user.InviteLink, error)
+	CancelAccountDeletion(ctx context.Context, userID uuid.UUID) error
+	ChangePreferredCharacter(ctx context.Context, userID uuid.UUID, char string) error
+	ChangeTaskLookaheadDays(ctx context.Context, userID uuid.UUID, days int) error
+	CreateFamilyInvite(ctx context.Context, familyID uuid.UUID, invitedBy uuid.UUID, inviteeEmail string) (user.Invite, error)
+	CreateInviteLink(ctx context.Context, familyID uuid.UUID, userID uuid.UUID, ttl time.Duration) (user.NewInviteLink, error)
+	CreateVacation(ctx context.Context, userID uuid.UUID, familyID uuid.UUID, v user.VacationRequest) error
+	DeclineFamilyInvite(ctx context.Context, userID uuid.UUID, inviteID uuid.UUID) error
+	DeleteFamily(ctx context.Context, ownerID uuid.UUID, familyID uuid.UUID, token string) error
+	DeleteInviteLink(ctx context.Context, ownerID uuid.UUID, linkID uuid.UUID) error
+	DeleteMember(ctx context.Context, familyID uuid.UUID, ownerID uuid.UUID, memberID uuid.UUID) error
+	DeleteVacation(ctx context.Context, userID uuid.UUID, vacationID uuid.UUID) error
+	GetAccountDeletion(ctx context.Context, userID uuid.UUID) (user.AccountDeletion, error)
+	GetFamilyInvite(ctx context.Context, userID uuid.UUID, inviteID uuid.UUID) (user.Invite, error)
+	GetFamilyInvites(ctx context.Context, userID uuid.UUID) ([]user.Invite, error)
+	GetInviteLinkByToken(ctx context.Context, token string) (user.InviteLink, error)
+	GetInviteLinks(ctx context.Context, familyID uuid.UUID) ([]user.InviteLink, error)
+	GetOwnedInvite(ctx context.Context, ownerID uuid.UUID, inviteID uuid.UUID) (user.Invite, error)
+	GetOwnedVacation(ctx context.Context, userID uuid.UUID, vacationID uuid.UUID) (user.Vacation, error)
+	GetSentInvites(ctx context.Context, familyID uuid.UUID) ([]user.Invite, error)
+	GetUserFamilyID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	GetUsersFamilies(ctx context.Context, userID uuid.UUID) ([]user.FamilyResponse, error)
+	GetVacations(ctx context.Context, families []user.FamilyResponse) ([]user.Vacation, error)
+	IsFamilyMember(ctx context.Context, userID uuid.UUID, familyID uuid.UUID) (bool, error)
+	IsFamilyOwner(ctx context.Context, userID uuid.UUID, familyID uuid.UUID) (bool, error)
+	LeaveFamily(ctx context.Context, familyID uuid.UUID, memberID uuid.UUID) error
+	NewFamily(ctx context.Context, f user.Family) (uuid.UUID, error)
+	RequestFamilyDeletion(ctx context.Context, ownerID uuid.UUID, familyID uuid.UUID) (user.FamilyDeletion, error)
+	ResendInvite(ctx context.Context, ownerID uuid.UUID, inviteID uuid.UUID) (user.Invite, error)
+	ResolveFamilyID(ctx context.Context, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error)
+	ResolveOwnedFamilyID(ctx context.Context, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error)
+	RevokeInvite(ctx context.Context, ownerID uuid.UUID, inviteID uuid.UUID) error
+	ScheduleAccountDeletion(ctx context.Context, userID uuid.UUID, identityID string, transfers map[uuid.UUID]uuid.UUID) (user.AccountDeletion, error)
+	SetActiveFamily(ctx context.Context, userID uuid.UUID, familyID *uuid.UUID) error
+	TransferFamily(ctx context.Context, ownerID uuid.UUID, familyID uuid.UUID, newOwnerID uuid.UUID, leave bool) error
+	UpdateFamilyName(ctx context.Context, familyID uuid.UUID, name string) error
+	UpdateName(ctx context.Context, userID uuid.UUID, name string) error
+	UpdateSoundMode(ctx context.Context, userID uuid.UUID, modeQuick, modeProfile string) error
+}
+
+type TimerRepository interface {
+	CreateDefaults(ctx context.Context, userID uuid.UUID) error
+	DeleteProfile(ctx context.Context, userID uuid.UUID, profileID uuid.UUID) error
+	EditProfile(ctx context.Context, userID uuid.UUID, profileID uuid.UUID, input timer.ProfileInput) error
+	GetAllProfiles(ctx context.Context, userID uuid.UUID) ([]timer.Profile, error)
+	NewProfile(ctx context.Context, userID uuid.UUID, input timer.ProfileInput) (timer.Profile, error)
+}
+
+type ActivityRepository interface {
+	DeleteComment(ctx context.Context, userID uuid.UUID, commentID uuid.UUID) error
+	GetComments(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID) ([]activity.Comment, error)
+	GetFeed(ctx context.Context, familyID uuid.UUID, before *uuid.UUID, limit int) (activity.Page, error)
+	NewComment(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, input activity.CommentInput) (activity.Comment, error)
+	Record(ctx context.Context, e activity.Event) error
+	RecordForTracker(ctx context.Context, actorID uuid.UUID, trackerID uuid.UUID, kind activity.Kind, data map[string]any) (uuid.UUID, error)
+}
+
+type APITokenRepository interface {
+	Authenticate(ctx context.Context, value string) (apitoken.Principal, error)
+	Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresInDays int) (apitoken.NewToken, error)
+	List(ctx context.Context, userID uuid.UUID) ([]apitoken.Token, error)
+	Revoke(ctx context.Context, userID uuid.UUID, tokenID uuid.UUID) error
+}
+
+type ArchiveRepository interface {
+	Export(ctx context.Context, userID uuid.UUID) (archive.Archive, error)
+	ExportPersonal(ctx context.Context, userID uuid.UUID) (archive.Personal, error)
+	Import(ctx context.Context, userID uuid.UUID, familyID uuid.UUID, a archive.Archive, dryRun bool) (archive.ImportResult, error)
+}
+
+type CalendarRepository interface {
+	BuildFeed(ctx context.Context, userID uuid.UUID, loc *time.Location) ([]calendar.Event, error)
+	GetTokenInfo(ctx context.Context, userID uuid.UUID) (calendar.TokenInfo, error)
+	LookupToken(ctx context.Context, token string) (uuid.UUID, error)
+	RevokeToken(ctx context.Context, userID uuid.UUID) error
+	RotateToken(ctx context.Context, userID uuid.UUID) (calendar.NewToken, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, familyID uuid.UUID, userID uuid.UUID, rawURL string, events []string) (webhook.NewWebhook, error)
+	Delete(ctx context.Context, familyID uuid.UUID, webhookID uuid.UUID) error
+	Edit(ctx context.Context, familyID uuid.UUID, webhookID uuid.UUID, u webhook.Update) (webhook.Webhook, error)
+	List(ctx context.Context, familyID uuid.UUID) ([]webhook.Webhook, error)
+	ListDeliveries(ctx context.Context, familyID uuid.UUID, webhookID uuid.UUID, before *uuid.UUID, limit int) (webhook.DeliveryPage, error)
+}
@@ -16,7 +16,7 @@ func (s *Service) GetAllTimerProfilesHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	profiles, err := timer.GetAllProfiles(s.DB, userID)
+	profiles, err := s.Timers.GetAllProfiles(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -38,7 +38,12 @@ func (s *Service) NewTimerProfileHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	profile, err := timer.NewProfile(s.DB, userID, input)
+	if err := validateTimerProfileInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	profile, err := s.Timers.NewProfile(r.Context(), userID, input)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -66,7 +71,12 @@ func (s *Service) EditTimerProfileHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := timer.EditProfile(s.DB, userID, profileID, input); err != nil {
+	if err := validateTimerProfileInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Timers.EditProfile(r.Context(), userID, profileID, input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -87,7 +97,7 @@ func (s *Service) DeleteTimerProfileHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := timer.DeleteProfile(s.DB, userID, profileID); err != nil {
+	if err := s.Timers.DeleteProfile(r.Context(), userID, profileID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
 	"github.com/zachczx/cubby/api/internal/logging"
 	"github.com/zachczx/cubby/api/internal/response"
 	"github.com/zachczx/cubby/api/internal/tracker"
-	"github.com/zachczx/cubby/api/internal/user"
 )
 
 func (s *Service) NewHandler(w http.ResponseWriter, r *http.Request) {
@@ -22,18 +22,23 @@ func (s *Service) NewHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	familyID, err := user.GetUserFamilyID(s.DB, userID)
-	if err != nil {
+	var input tracker.Input
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	var input tracker.Input
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := validateTrackerInput(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	familyID, err := s.Users.ResolveFamilyID(r.Context(), userID, input.FamilyID)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
+		return
+	}
+
 	var startDate *time.Time
 	if input.StartDate != "" {
 		sd, err := time.Parse(time.RFC3339, input.StartDate)
@@ -61,12 +66,20 @@ func (s *Service) NewHandler(w http.ResponseWriter, r *http.Request) {
 		StartDate:    startDate,
 	}
 
-	trackerID, err := tracker.New(s.DB, t)
+	trackerID, err := s.Trackers.New(r.Context(), t)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID:  familyID,
+		ActorID:   userID,
+		TrackerID: &trackerID,
+		Kind:      activity.TrackerCreated,
+		Data:      map[string]any{"display": t.Display, "interval": t.Interval, "intervalUnit": t.IntervalUnit},
+	})
+
 	response.WriteJSON(r.Context(), w, trackerID)
 }
 
@@ -86,12 +99,23 @@ func (s *Service) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before, err := s.Trackers.Get(r.Context(), trackerID, userID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
 	var input tracker.Input
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	if err := validateTrackerInput(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
 	var startDate *time.Time
 	if input.StartDate != "" {
 		sd, err := time.Parse(time.RFC3339, input.StartDate)
@@ -119,14 +143,48 @@ func (s *Service) EditHandler(w http.ResponseWriter, r *http.Request) {
 		StartDate:    startDate,
 	}
 
-	if err := tracker.Edit(s.DB, t); err != nil {
+	if err := s.Trackers.Edit(r.Context(), t); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	if changes := trackerChanges(before, t); len(changes) > 0 {
+		s.recordTrackerActivity(r.Context(), userID, trackerID, activity.TrackerUpdated, map[string]any{"changes": changes})
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+type fieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+func trackerChanges(before tracker.Tracker, after tracker.Tracker) map[string]fieldChange {
+	changes := make(map[string]fieldChange)
+
+	if before.Display != after.Display {
+		changes["display"] = fieldChange{before.Display, after.Display}
+	}
+	if before.Interval != after.Interval {
+		changes["interval"] = fieldChange{before.Interval, after.Interval}
+	}
+	if before.IntervalUnit != after.IntervalUnit {
+		changes["intervalUnit"] = fieldChange{before.IntervalUnit, after.IntervalUnit}
+	}
+	if before.Category != after.Category {
+		changes["category"] = fieldChange{before.Category, after.Category}
+	}
+	if before.ActionLabel != after.ActionLabel {
+		changes["actionLabel"] = fieldChange{before.ActionLabel, after.ActionLabel}
+	}
+	if before.Icon != after.Icon {
+		changes["icon"] = fieldChange{before.Icon, after.Icon}
+	}
+
+	return changes
+}
+
 func (s *Service) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	t := r.PathValue("trackerID")
 	trackerID, err := uuid.Parse(t)
@@ -141,11 +199,25 @@ func (s *Service) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := tracker.Delete(s.DB, trackerID, userID); err != nil {
+	existing, err := s.Trackers.Get(r.Context(), trackerID, userID)
+	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	if err := s.Trackers.Delete(r.Context(), trackerID, userID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	// The tracker row is gone, so the activity keeps its name in data rather than a tracker_id reference.
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: existing.Family,
+		ActorID:  userID,
+		Kind:     activity.TrackerDeleted,
+		Data:     map[string]any{"trackerId": existing.ID, "display": existing.Display},
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -163,7 +235,7 @@ func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tracker, err := tracker.Get(s.DB, trackerID, userID)
+	tracker, err := s.Trackers.Get(r.Context(), trackerID, userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -179,7 +251,7 @@ func (s *Service) GetAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tracker, err := tracker.GetAll(s.DB, userID)
+	tracker, err := s.Trackers.GetAll(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -224,13 +296,13 @@ func (s *Service) HandleToggle(w http.ResponseWriter, r *http.Request, fn func(u
 
 func (s *Service) TogglePinHandler(w http.ResponseWriter, r *http.Request) {
 	s.HandleToggle(w, r, func(userID uuid.UUID, trackerID uuid.UUID, toggle TrackerToggle) error {
-		return tracker.TogglePin(s.DB, userID, trackerID, toggle.Pinned)
+		return s.Trackers.TogglePin(r.Context(), userID, trackerID, toggle.Pinned)
 	})
 }
 
 func (s *Service) ToggleShowHandler(w http.ResponseWriter, r *http.Request) {
 	s.HandleToggle(w, r, func(userID uuid.UUID, trackerID uuid.UUID, toggle TrackerToggle) error {
-		return tracker.ToggleShow(s.DB, userID, trackerID, toggle.Show)
+		return s.Trackers.ToggleShow(r.Context(), userID, trackerID, toggle.Show)
 	})
 }
 
@@ -242,7 +314,7 @@ func (s *Service) GenerateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	logging.Info(r.Context(), "user", "userID", userID)
 
-	t, err := tracker.GetTrackersLast(s.DB)
+	t, err := s.Trackers.GetTrackersLast(r.Context())
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -254,6 +326,12 @@ func (s *Service) GenerateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	newT, err = s.Trackers.HoldBlockedTrackers(r.Context(), newT)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
 	response.WriteJSON(r.Context(), w, newT)
 }
 
@@ -285,7 +363,7 @@ func (s *Service) ToggleMuteHandler(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Println(mutedInput)
 
-	if err := tracker.MuteTracker(s.DB, trackerID, userID, mutedInput.IsMuted); err != nil {
+	if err := s.Trackers.MuteTracker(r.Context(), trackerID, userID, mutedInput.IsMuted); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
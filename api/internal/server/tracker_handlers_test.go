@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/tracker"
+)
+
+func TestGetHandler(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	trackerID := uuid.New()
+
+	s := &Service{Trackers: &fakeTrackers{trackers: []tracker.Tracker{{ID: trackerID, Owner: owner, Name: "towels"}}}}
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		id     string
+		want   int
+	}{
+		{"owner", owner, trackerID.String(), http.StatusOK},
+		{"someone else", other, trackerID.String(), http.StatusNotFound},
+		{"anonymous", uuid.Nil, trackerID.String(), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s.GetHandler, http.MethodGet, nil, tt.userID, "trackerID", tt.id)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestGetAllHandlerOnlyReturnsOwnTrackers(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+
+	s := &Service{Trackers: &fakeTrackers{trackers: []tracker.Tracker{
+		{ID: uuid.New(), Owner: owner, Name: "mine"},
+		{ID: uuid.New(), Owner: other, Name: "theirs"},
+	}}}
+
+	w := serve(s.GetAllHandler, http.MethodGet, nil, owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	var got []tracker.Tracker
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Name != "mine" {
+		t.Fatalf("got %+v, want only the owner's tracker", got)
+	}
+}
+
+func TestNewHandlerRejectsOtherFamily(t *testing.T) {
+	userID := uuid.New()
+
+	s := &Service{
+		Trackers: &fakeTrackers{},
+		Users:    &fakeUsers{families: map[uuid.UUID]uuid.UUID{userID: uuid.New()}},
+	}
+
+	body := `{"name":"towels","interval":1,"intervalUnit":"day","familyId":"` + uuid.NewString() + `"}`
+
+	w := serve(s.NewHandler, http.MethodPost, strings.NewReader(body), userID)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403; body %s", w.Code, w.Body)
+	}
+}
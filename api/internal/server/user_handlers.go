@@ -5,10 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/zachczx/cubby/api/internal/response"
-	"github.com/zachczx/cubby/api/internal/user"
 )
 
 func (s *Service) GetUserIDFromContext(ctx context.Context) (uuid.UUID, error) {
@@ -28,9 +29,7 @@ func (s *Service) GetUserEmailFromContext(ctx context.Context) (string, error) {
 }
 
 func (s *Service) CheckHandler(w http.ResponseWriter, r *http.Request) {
-	u := s.GetAuthenticatedUser(w, r)
-
-	if u == nil || u.UserID == "" {
+	if _, err := s.authenticate(w, r); err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "not authenticated")
 		return
 	}
@@ -56,7 +55,7 @@ func (s *Service) ChangeTaskLookaheadDaysHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	if err := user.ChangeTaskLookaheadDays(s.DB, userID, days.TaskDays); err != nil {
+	if err := s.Users.ChangeTaskLookaheadDays(r.Context(), userID, days.TaskDays); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -82,7 +81,7 @@ func (s *Service) ChangePreferredCharacterHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	if err := user.ChangePreferredCharacter(s.DB, userID, char.PreferredCharacter); err != nil {
+	if err := s.Users.ChangePreferredCharacter(r.Context(), userID, char.PreferredCharacter); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -95,7 +94,7 @@ type SoundModeInput struct {
 	SoundModeProfile string `json:"soundModeProfile"`
 }
 
-var validSoundModes = map[string]bool{"off": true, "end": true, "full": true}
+var soundModes = []string{"off", "end", "full"}
 
 func (s *Service) UpdateSoundModeHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := s.GetUserIDFromContext(r.Context())
@@ -111,12 +110,16 @@ func (s *Service) UpdateSoundModeHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if !validSoundModes[input.SoundModeQuick] || !validSoundModes[input.SoundModeProfile] {
-		response.RespondWithError(w, http.StatusBadRequest, "invalid sound mode")
+	var v response.Validator
+	msg := "must be one of " + strings.Join(soundModes, ", ")
+	v.Check(slices.Contains(soundModes, input.SoundModeQuick), "soundModeQuick", response.CodeInvalidChoice, msg)
+	v.Check(slices.Contains(soundModes, input.SoundModeProfile), "soundModeProfile", response.CodeInvalidChoice, msg)
+	if err := v.Err(); err != nil {
+		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	if err := user.UpdateSoundMode(s.DB, userID, input.SoundModeQuick, input.SoundModeProfile); err != nil {
+	if err := s.Users.UpdateSoundMode(r.Context(), userID, input.SoundModeQuick, input.SoundModeProfile); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
@@ -143,20 +146,20 @@ func (s *Service) UpdateAccountInfoHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	if input.Name != "" {
-		if err := user.UpdateName(s.DB, userID, input.Name); err != nil {
+		if err := s.Users.UpdateName(r.Context(), userID, input.Name); err != nil {
 			response.WriteError(r.Context(), w, err)
 			return
 		}
 	}
 
 	if input.FamilyName != "" {
-		ownedFamilyID, err := user.GetUserFamilyID(s.DB, userID)
+		ownedFamilyID, err := s.Users.GetUserFamilyID(r.Context(), userID)
 		if err != nil {
 			response.WriteError(r.Context(), w, err)
 			return
 		}
 
-		if err := user.UpdateFamilyName(s.DB, ownedFamilyID, input.FamilyName); err != nil {
+		if err := s.Users.UpdateFamilyName(r.Context(), ownedFamilyID, input.FamilyName); err != nil {
 			response.WriteError(r.Context(), w, err)
 			return
 		}
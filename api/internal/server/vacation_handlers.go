@@ -2,10 +2,10 @@ package server
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/activity"
 	"github.com/zachczx/cubby/api/internal/response"
 	"github.com/zachczx/cubby/api/internal/user"
 )
@@ -17,12 +17,6 @@ func (s *Service) CreateVacationHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	ownedFamilyID, err := user.GetUserFamilyID(s.DB, userID)
-	if err != nil {
-		response.WriteError(r.Context(), w, err)
-		return
-	}
-
 	var input user.VacationRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -30,25 +24,34 @@ func (s *Service) CreateVacationHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := validateVacationInputDateTimes(input); err != nil {
-		response.WriteError(r.Context(), w, err)
+	familyID, err := s.Users.ResolveFamilyID(r.Context(), userID, input.FamilyID)
+	if err != nil {
+		writeFamilyError(r.Context(), w, err)
 		return
 	}
 
-	if err := user.CreateVacation(s.DB, userID, ownedFamilyID, input); err != nil {
+	if err := validateVacationInput(&input); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-}
-
-func validateVacationInputDateTimes(input user.VacationRequest) error {
-	if input.StartDateTime.After(input.EndDateTime) {
-		return errors.New("end time must be after start time")
+	if err := s.Users.CreateVacation(r.Context(), userID, familyID, input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
 	}
 
-	return nil
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: familyID,
+		ActorID:  userID,
+		Kind:     activity.VacationCreated,
+		Data: map[string]any{
+			"label":         input.Label,
+			"startDateTime": input.StartDateTime,
+			"endDateTime":   input.EndDateTime,
+		},
+	})
+
+	w.WriteHeader(http.StatusCreated)
 }
 
 func (s *Service) GetVacationsHandler(w http.ResponseWriter, r *http.Request) {
@@ -58,13 +61,13 @@ func (s *Service) GetVacationsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	families, err := user.GetUsersFamilies(s.DB, userID)
+	families, err := s.Users.GetUsersFamilies(r.Context(), userID)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
-	vacations, err := user.GetVacations(s.DB, families)
+	vacations, err := s.Users.GetVacations(r.Context(), families)
 	if err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
@@ -87,10 +90,33 @@ func (s *Service) DeleteVacationHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := user.DeleteVacation(s.DB, userID, vacationID); err != nil {
+	v, err := s.Users.GetOwnedVacation(r.Context(), userID, vacationID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	familyID, err := uuid.Parse(v.FamilyID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Users.DeleteVacation(r.Context(), userID, vacationID); err != nil {
 		response.WriteError(r.Context(), w, err)
 		return
 	}
 
+	s.recordActivity(r.Context(), activity.Event{
+		FamilyID: familyID,
+		ActorID:  userID,
+		Kind:     activity.VacationDeleted,
+		Data: map[string]any{
+			"label":         v.Label,
+			"startDateTime": v.StartDateTime,
+			"endDateTime":   v.EndDateTime,
+		},
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 }
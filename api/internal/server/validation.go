@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/gym"
+	"github.com/zachczx/cubby/api/internal/market"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/timer"
+	"github.com/zachczx/cubby/api/internal/tracker"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+// Each validator trims text fields in place before checking them, so handlers save what was validated. Field names
+// match the JSON the client sent.
+
+const (
+	maxTrackerInterval = 1000
+	maxRepsPerSet      = 1000
+	maxSetWeightKg     = 1000
+	maxSegmentSeconds  = 24 * 60 * 60
+	maxTimerSegments   = 50
+)
+
+func validateTrackerInput(in *tracker.Input) error {
+	var v response.Validator
+
+	in.Name = strings.TrimSpace(in.Name)
+	in.Display = strings.TrimSpace(in.Display)
+
+	v.Check(in.Name != "", "name", response.CodeRequired, "name is required")
+	v.MaxLength("name", in.Name, response.MaxCharLength)
+	v.MaxLength("display", in.Display, response.MaxCharLength)
+	v.MaxLength("category", in.Category, response.MaxCharLength)
+	v.MaxLength("kind", in.Kind, response.MaxCharLength)
+	v.MaxLength("actionLabel", in.ActionLabel, response.MaxCharLength)
+	v.MaxLength("icon", in.Icon, response.MaxCharLength)
+
+	v.Check(in.Interval >= 1 && in.Interval <= maxTrackerInterval, "interval", response.CodeOutOfRange,
+		fmt.Sprintf("must be between 1 and %d", maxTrackerInterval))
+	v.Check(slices.Contains(tracker.IntervalUnits, in.IntervalUnit), "intervalUnit", response.CodeInvalidChoice,
+		"must be one of "+strings.Join(tracker.IntervalUnits, ", "))
+
+	if in.Cost != nil {
+		v.Check(*in.Cost >= 0, "cost", response.CodeOutOfRange, "cannot be negative")
+	}
+
+	if in.StartDate != "" {
+		_, err := time.Parse(time.RFC3339, in.StartDate)
+		v.Check(err == nil, "startDate", response.CodeInvalidFormat, "must be an RFC 3339 timestamp")
+	}
+
+	return v.Err()
+}
+
+// validateBulkInput checks the row count and the optional interval override. The override only applies when both
+// parts are set, so either one alone is checked as if it were meant to.
+func validateBulkInput(in *entry.BulkInput) error {
+	var v response.Validator
+
+	v.Check(len(in.Entries) > 0, "entries", response.CodeRequired, "at least one entry is required")
+	v.Check(len(in.Entries) <= entry.MaxBulkRows, "entries", response.CodeOutOfRange,
+		fmt.Sprintf("at most %d entries per request", entry.MaxBulkRows))
+
+	in.IntervalUnit = strings.TrimSpace(in.IntervalUnit)
+
+	if in.Interval != 0 || in.IntervalUnit != "" {
+		v.Check(in.Interval >= 1 && in.Interval <= maxTrackerInterval, "interval", response.CodeOutOfRange,
+			fmt.Sprintf("must be between 1 and %d", maxTrackerInterval))
+		v.Check(slices.Contains(tracker.IntervalUnits, in.IntervalUnit), "intervalUnit", response.CodeInvalidChoice,
+			"must be one of "+strings.Join(tracker.IntervalUnits, ", "))
+	}
+
+	return v.Err()
+}
+
+// validateSetInput defaults a missing set type to a working set, as the database would.
+func validateSetInput(in *gym.SetInput) error {
+	var v response.Validator
+
+	in.ExerciseID = strings.TrimSpace(in.ExerciseID)
+	if in.SetType == "" {
+		in.SetType = gym.SetWorking
+	}
+
+	v.Check(in.ExerciseID != "", "exerciseId", response.CodeRequired, "exercise is required")
+	v.MaxLength("exerciseId", in.ExerciseID, response.MaxCharLength)
+	v.Check(slices.Contains(gym.SetTypes, in.SetType), "setType", response.CodeInvalidChoice,
+		"must be one of "+strings.Join(gym.SetTypes, ", "))
+
+	if in.WeightKg != nil {
+		v.Check(*in.WeightKg >= 0 && *in.WeightKg <= maxSetWeightKg, "weightKg", response.CodeOutOfRange,
+			fmt.Sprintf("must be between 0 and %d", maxSetWeightKg))
+	}
+
+	if in.Reps != nil {
+		v.Check(*in.Reps >= 0 && *in.Reps <= maxRepsPerSet, "reps", response.CodeOutOfRange,
+			fmt.Sprintf("must be between 0 and %d", maxRepsPerSet))
+	}
+
+	return v.Err()
+}
+
+func validateMarketInput(in *market.Input) error {
+	var v response.Validator
+
+	in.ItemName = strings.TrimSpace(in.ItemName)
+
+	v.Check(in.ItemName != "", "itemName", response.CodeRequired, "item name is required")
+	v.MaxLength("itemName", in.ItemName, response.MaxCharLength)
+	v.Check(in.Price >= 0, "price", response.CodeOutOfRange, "price cannot be negative")
+
+	if in.Quantity != nil {
+		v.Check(*in.Quantity >= 0, "quantity", response.CodeOutOfRange, "quantity cannot be negative")
+	}
+
+	for field, s := range map[string]*string{"category": in.Category, "country": in.Country, "store": in.Store, "unit": in.Unit} {
+		if s != nil {
+			v.MaxLength(field, *s, response.MaxCharLength)
+		}
+	}
+
+	if in.Remarks != nil {
+		v.MaxLength("remarks", *in.Remarks, response.LongTextLength)
+	}
+
+	for field, s := range map[string]*string{"createdAt": in.CreatedAt, "updatedAt": in.UpdatedAt} {
+		if s != nil {
+			_, err := time.Parse(time.RFC3339, *s)
+			v.Check(err == nil, field, response.CodeInvalidFormat, "must be an RFC 3339 timestamp")
+		}
+	}
+
+	return v.Err()
+}
+
+func validateTimerProfileInput(in *timer.ProfileInput) error {
+	var v response.Validator
+
+	in.Name = strings.TrimSpace(in.Name)
+
+	v.Check(in.Name != "", "name", response.CodeRequired, "name is required")
+	v.MaxLength("name", in.Name, response.MaxCharLength)
+	v.Check(len(in.Segments) > 0, "segments", response.CodeRequired, "at least one segment is required")
+	v.Check(len(in.Segments) <= maxTimerSegments, "segments", response.CodeTooLong,
+		fmt.Sprintf("at most %d segments", maxTimerSegments))
+
+	for i := range in.Segments {
+		seg := &in.Segments[i]
+		seg.Label = strings.TrimSpace(seg.Label)
+		prefix := fmt.Sprintf("segments[%d].", i)
+
+		v.Check(seg.Label != "", prefix+"label", response.CodeRequired, "label is required")
+		v.MaxLength(prefix+"label", seg.Label, response.MaxCharLength)
+		v.Check(seg.DefaultSeconds > 0 && seg.DefaultSeconds <= maxSegmentSeconds, prefix+"defaultSeconds",
+			response.CodeOutOfRange, fmt.Sprintf("must be between 1 and %d seconds", maxSegmentSeconds))
+	}
+
+	return v.Err()
+}
+
+func validateVacationInput(in *user.VacationRequest) error {
+	var v response.Validator
+
+	v.Check(!in.StartDateTime.IsZero(), "startDateTime", response.CodeRequired, "start time is required")
+	v.Check(!in.EndDateTime.IsZero(), "endDateTime", response.CodeRequired, "end time is required")
+	v.Check(in.EndDateTime.After(in.StartDateTime), "endDateTime", response.CodeOutOfRange, "end time must be after start time")
+
+	if in.Label != nil {
+		label := strings.TrimSpace(*in.Label)
+		in.Label = &label
+		v.MaxLength("label", label, response.MaxCharLength)
+	}
+
+	return v.Err()
+}
+
+// validateArchive checks the enum columns an archive writes straight to the database, since a hand-edited file can
+// hold values the create endpoints would refuse. A missing set type defaults to a working set, as in validateSetInput.
+func validateArchive(a *archive.Archive) error {
+	var v response.Validator
+
+	units := "must be one of " + strings.Join(tracker.IntervalUnits, ", ")
+
+	for i, t := range a.Trackers {
+		v.Check(slices.Contains(tracker.IntervalUnits, t.IntervalUnit), fmt.Sprintf("trackers[%d].intervalUnit", i),
+			response.CodeInvalidChoice, units)
+	}
+
+	for i, e := range a.Entries {
+		v.Check(slices.Contains(tracker.IntervalUnits, e.IntervalUnit), fmt.Sprintf("entries[%d].intervalUnit", i),
+			response.CodeInvalidChoice, units)
+	}
+
+	for i := range a.Gym.Workouts {
+		for j := range a.Gym.Workouts[i].Sets {
+			s := &a.Gym.Workouts[i].Sets[j]
+			if s.SetType == "" {
+				s.SetType = gym.SetWorking
+			}
+
+			v.Check(slices.Contains(gym.SetTypes, s.SetType), fmt.Sprintf("gym.workouts[%d].sets[%d].setType", i, j),
+				response.CodeInvalidChoice, "must be one of "+strings.Join(gym.SetTypes, ", "))
+		}
+	}
+
+	return v.Err()
+}
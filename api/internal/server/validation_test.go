@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/archive"
+	"github.com/zachczx/cubby/api/internal/entry"
+	"github.com/zachczx/cubby/api/internal/gym"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/tracker"
+)
+
+func TestValidateTrackerInputReportsEveryField(t *testing.T) {
+	in := tracker.Input{Name: "  ", Interval: -1, IntervalUnit: "fortnight", StartDate: "tomorrow"}
+
+	w := httptest.NewRecorder()
+	response.WriteError(t.Context(), w, validateTrackerInput(&in))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+
+	var got response.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"name":         response.CodeRequired,
+		"interval":     response.CodeOutOfRange,
+		"intervalUnit": response.CodeInvalidChoice,
+		"startDate":    response.CodeInvalidFormat,
+	}
+
+	if len(got.Errors) != len(want) {
+		t.Fatalf("errors = %v, want %d fields", got.Errors, len(want))
+	}
+
+	for field, code := range want {
+		if got.Errors[field].Code != code {
+			t.Errorf("%s: code = %q, want %q", field, got.Errors[field].Code, code)
+		}
+	}
+}
+
+func TestValidateTrackerInputAcceptsValid(t *testing.T) {
+	in := tracker.Input{Name: " towels ", Interval: 7, IntervalUnit: "day"}
+
+	if err := validateTrackerInput(&in); err != nil {
+		t.Fatal(err)
+	}
+
+	if in.Name != "towels" {
+		t.Fatalf("name = %q, want it trimmed", in.Name)
+	}
+}
+
+func TestValidateBulkInput(t *testing.T) {
+	rows := []entry.BulkRow{{PerformedAt: "2026-05-01T08:00:00Z"}}
+
+	tests := []struct {
+		name  string
+		in    entry.BulkInput
+		field string
+	}{
+		{"tracker interval", entry.BulkInput{Entries: rows}, ""},
+		{"override", entry.BulkInput{Entries: rows, Interval: 2, IntervalUnit: "month"}, ""},
+		{"no rows", entry.BulkInput{}, "entries"},
+		{"negative interval", entry.BulkInput{Entries: rows, Interval: -3, IntervalUnit: "day"}, "interval"},
+		{"unknown unit", entry.BulkInput{Entries: rows, Interval: 3, IntervalUnit: "fortnight"}, "intervalUnit"},
+		{"unit without interval", entry.BulkInput{Entries: rows, IntervalUnit: "day"}, "interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBulkInput(&tt.in)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			errs, ok := err.(response.FieldErrors)
+			if !ok {
+				t.Fatalf("err = %v, want FieldErrors", err)
+			}
+			if _, ok := errs[tt.field]; !ok {
+				t.Fatalf("errors = %v, want one for %s", errs, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateSetInput(t *testing.T) {
+	reps := int16(-2)
+
+	tests := []struct {
+		name  string
+		in    gym.SetInput
+		field string
+	}{
+		{"valid", gym.SetInput{ExerciseID: "bench", SetType: gym.SetFailure}, ""},
+		{"defaults type", gym.SetInput{ExerciseID: "bench"}, ""},
+		{"unknown type", gym.SetInput{ExerciseID: "bench", SetType: "superset"}, "setType"},
+		{"negative reps", gym.SetInput{ExerciseID: "bench", Reps: &reps}, "reps"},
+		{"no exercise", gym.SetInput{}, "exerciseId"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSetInput(&tt.in)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			errs, ok := err.(response.FieldErrors)
+			if !ok {
+				t.Fatalf("err = %v, want FieldErrors", err)
+			}
+			if _, ok := errs[tt.field]; !ok {
+				t.Fatalf("errors = %v, want one for %s", errs, tt.field)
+			}
+		})
+	}
+}
+
+func TestCreateVacationHandlerRejectsBackwardsDates(t *testing.T) {
+	userID := uuid.New()
+
+	s := &Service{Users: &fakeUsers{families: map[uuid.UUID]uuid.UUID{userID: uuid.New()}}}
+
+	body := `{"startDateTime":"2026-05-02T00:00:00Z","endDateTime":"2026-05-01T00:00:00Z"}`
+
+	w := serve(s.CreateVacationHandler, http.MethodPost, strings.NewReader(body), userID)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400; body %s", w.Code, w.Body)
+	}
+}
+
+func TestImportHandlerRejectsOversizedBody(t *testing.T) {
+	userID := uuid.New()
+
+	s := &Service{Users: &fakeUsers{families: map[uuid.UUID]uuid.UUID{userID: uuid.New()}}}
+
+	body := `{"version":1,"trackers":[{"name":"` + strings.Repeat("a", maxImportBodyBytes) + `"}]}`
+
+	w := serve(s.ImportHandler, http.MethodPost, strings.NewReader(body), userID)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413; body %s", w.Code, w.Body)
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	a := archive.Archive{
+		Trackers: []archive.Tracker{{IntervalUnit: "day"}, {IntervalUnit: "fortnight"}},
+		Entries:  []archive.Entry{{IntervalUnit: ""}},
+		Gym:      archive.Gym{Workouts: []archive.Workout{{Sets: []archive.Set{{}, {SetType: "superset"}}}}},
+	}
+
+	errs, ok := validateArchive(&a).(response.FieldErrors)
+	if !ok {
+		t.Fatalf("err = %v, want FieldErrors", errs)
+	}
+
+	for _, field := range []string{"trackers[1].intervalUnit", "entries[0].intervalUnit", "gym.workouts[0].sets[1].setType"} {
+		if _, ok := errs[field]; !ok {
+			t.Errorf("errors = %v, want one for %s", errs, field)
+		}
+	}
+
+	if len(errs) != 3 {
+		t.Errorf("errors = %v, want 3", errs)
+	}
+
+	if got := a.Gym.Workouts[0].Sets[0].SetType; got != gym.SetWorking {
+		t.Errorf("missing set type = %q, want %q", got, gym.SetWorking)
+	}
+}
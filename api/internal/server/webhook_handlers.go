@@ -0,0 +1,200 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/response"
+	"github.com/zachczx/cubby/api/internal/user"
+	"github.com/zachczx/cubby/api/internal/webhook"
+)
+
+// ownedFamily parses the family from the path and checks the caller owns it, writing the error response if not.
+// Webhooks carry family data to outside services, so only the owner can see or change them.
+func (s *Service) ownedFamily(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	familyID, err := uuid.Parse(r.PathValue("familyID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := s.GetUserIDFromContext(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	isOwner, err := s.Users.IsFamilyOwner(r.Context(), userID, familyID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !isOwner {
+		writeFamilyError(r.Context(), w, user.ErrNotFamilyOwner)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return familyID, userID, true
+}
+
+func (s *Service) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, _, ok := s.ownedFamily(w, r)
+	if !ok {
+		return
+	}
+
+	hooks, err := s.Webhooks.List(r.Context(), familyID)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, hooks)
+}
+
+func (s *Service) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, userID, ok := s.ownedFamily(w, r)
+	if !ok {
+		return
+	}
+
+	var input webhook.Request
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	input.URL = strings.TrimSpace(input.URL)
+	if err := webhook.ValidateURL(input.URL, s.Development); err != nil {
+		response.WriteError(r.Context(), w, response.ValErr("url", err.Error()))
+		return
+	}
+
+	events, err := webhook.ValidateEvents(input.Events)
+	if err != nil {
+		response.WriteError(r.Context(), w, response.ValErr("events", err.Error()))
+		return
+	}
+	if len(events) == 0 {
+		response.WriteError(r.Context(), w, response.ValErr("events", "at least one event is required"))
+		return
+	}
+
+	hook, err := s.Webhooks.Create(r.Context(), familyID, userID, input.URL, events)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSONStatus(r.Context(), w, http.StatusCreated, hook)
+}
+
+func (s *Service) EditWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, _, ok := s.ownedFamily(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	var input webhook.Update
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if input.URL != nil {
+		u := strings.TrimSpace(*input.URL)
+		if err := webhook.ValidateURL(u, s.Development); err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("url", err.Error()))
+			return
+		}
+		input.URL = &u
+	}
+
+	if input.Events != nil {
+		events, err := webhook.ValidateEvents(input.Events)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("events", err.Error()))
+			return
+		}
+		if len(events) == 0 {
+			response.WriteError(r.Context(), w, response.ValErr("events", "at least one event is required"))
+			return
+		}
+		input.Events = events
+	}
+
+	hook, err := s.Webhooks.Edit(r.Context(), familyID, webhookID, input)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, hook)
+}
+
+func (s *Service) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, _, ok := s.ownedFamily(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	if err := s.Webhooks.Delete(r.Context(), familyID, webhookID); err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	familyID, _, ok := s.ownedFamily(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	var before *uuid.UUID
+	if c := r.URL.Query().Get("before"); c != "" {
+		id, err := uuid.Parse(c)
+		if err != nil {
+			response.WriteError(r.Context(), w, response.ValErr("before", "invalid cursor"))
+			return
+		}
+		before = &id
+	}
+
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	page, err := s.Webhooks.ListDeliveries(r.Context(), familyID, webhookID, before, limit)
+	if err != nil {
+		response.WriteError(r.Context(), w, err)
+		return
+	}
+
+	response.WriteJSON(r.Context(), w, page)
+}
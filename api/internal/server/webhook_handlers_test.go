@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/webhook"
+)
+
+func TestGetWebhooksHandlerOwnerOnly(t *testing.T) {
+	owner, member := uuid.New(), uuid.New()
+	familyID := uuid.New()
+
+	s := &Service{
+		Users: &fakeUsers{
+			families: map[uuid.UUID]uuid.UUID{member: familyID},
+			owners:   map[uuid.UUID]uuid.UUID{familyID: owner},
+		},
+		Webhooks: &fakeWebhooks{hooks: []webhook.Webhook{
+			{ID: uuid.New(), FamilyID: familyID, URL: "https://hooks.example.com/cubby"},
+			{ID: uuid.New(), FamilyID: uuid.New(), URL: "https://hooks.example.com/other"},
+		}},
+	}
+
+	w := serve(s.GetWebhooksHandler, http.MethodGet, nil, owner, "familyID", familyID.String())
+	if w.Code != http.StatusOK {
+		t.Fatalf("owner: status = %d, want 200", w.Code)
+	}
+
+	var hooks []webhook.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&hooks); err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || hooks[0].FamilyID != familyID {
+		t.Fatalf("owner got %+v, want only their family's webhook", hooks)
+	}
+
+	if w := serve(s.GetWebhooksHandler, http.MethodGet, nil, member, "familyID", familyID.String()); w.Code != http.StatusForbidden {
+		t.Fatalf("member: status = %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer times every query pgx runs, which covers all sqlx calls since they go through the pgx driver. Queries
+// are labelled by statement type rather than text to keep the number of series small; spans carry the full SQL.
+type QueryTracer struct{}
+
+type queryKey struct{}
+
+type queryStart struct {
+	at        time.Time
+	operation string
+	span      trace.Span
+}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := operation(data.SQL)
+
+	ctx, span := tracer().Start(ctx, "db "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", op),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+
+	return context.WithValue(ctx, queryKey{}, queryStart{at: time.Now(), operation: op, span: span})
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	DBQueryDuration.WithLabelValues(start.operation).Observe(time.Since(start.at).Seconds())
+
+	if data.Err != nil {
+		DBQueryErrors.WithLabelValues(start.operation).Inc()
+		start.span.RecordError(data.Err)
+		start.span.SetStatus(codes.Error, data.Err.Error())
+	}
+
+	start.span.End()
+}
+
+// operation is the statement's first keyword, e.g. "select" or "with".
+func operation(sql string) string {
+	sql = strings.TrimSpace(sql)
+
+	// Skip leading "-- comment" lines.
+	for strings.HasPrefix(sql, "--") {
+		_, rest, ok := strings.Cut(sql, "\n")
+		if !ok {
+			return "other"
+		}
+		sql = strings.TrimSpace(rest)
+	}
+
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "other"
+	}
+
+	switch word := strings.ToLower(strings.TrimRight(fields[0], "(")); word {
+	case "select", "insert", "update", "delete", "with":
+		return word
+	}
+
+	return "other"
+}
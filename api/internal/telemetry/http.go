@@ -0,0 +1,43 @@
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zachczx/cubby/api/internal/response"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// unmatched labels requests no route matched, so probes for random paths can't create new series.
+const unmatched = "unmatched"
+
+// Middleware records request metrics and, when tracing is on, a server span per request. It must wrap the ServeMux
+// directly: the mux sets r.Pattern on the request it's handed, and both read it after the handler returns.
+func Middleware(mux http.Handler) http.Handler {
+	return otelhttp.NewHandler(metricsHandler(mux), "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if r.Pattern == "" {
+				return r.Method
+			}
+			return r.Pattern
+		}),
+	)
+}
+
+func metricsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &response.StatusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(sw, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = unmatched
+		}
+
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(sw.Status())).Inc()
+		HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,44 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByPattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /trackers/{trackerID}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	h := Middleware(mux)
+
+	for _, path := range []string{"/trackers/a", "/trackers/b", "/nope"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "GET /trackers/{trackerID}", "404")); got != 2 {
+		t.Fatalf("pattern count = %v, want 2", got)
+	}
+
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatched, "404")); got != 1 {
+		t.Fatalf("unmatched count = %v, want 1", got)
+	}
+}
+
+func TestOperation(t *testing.T) {
+	for sql, want := range map[string]string{
+		"SELECT 1":                         "select",
+		"\n\t\tUPDATE trackers SET x = 1":  "update",
+		"-- comment\nINSERT INTO t VALUES": "insert",
+		"WITH due AS (SELECT 1) SELECT 1":  "with",
+		"(SELECT 1) UNION (SELECT 2)":      "other",
+		"TRUNCATE t":                       "other",
+	} {
+		if got := operation(sql); got != want {
+			t.Errorf("operation(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
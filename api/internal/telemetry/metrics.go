@@ -0,0 +1,105 @@
+// Package telemetry holds the API's Prometheus metrics and OpenTelemetry tracing. Metrics are always collected and
+// served from the metrics address; tracing is off unless an OTLP endpoint is configured.
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cubby"
+
+// Registry is separate from the Prometheus default so only the collectors below (and Go runtime stats) are
+// exported, not whatever a dependency registers.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to serve HTTP requests by route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time spent in database queries by statement type.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Database queries that returned an error, by statement type.",
+	}, []string{"operation"})
+
+	AuthDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "auth_duration_seconds",
+		Help:      "Time to authenticate a request, by how it was authenticated: cache, jwt, session, token or failed.",
+		Buckets:   []float64{.001, .005, .025, .1, .5},
+	}, []string{"source"})
+
+	NotificationCycles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_cycles_total",
+		Help:      "Runs of the notification worker, by result (ok or error).",
+	}, []string{"result"})
+
+	NotificationCycleDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "notification_cycle_duration_seconds",
+		Help:      "Time taken by one run of the notification worker.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	NotificationDueTrackers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "notification_due_trackers",
+		Help:      "Trackers found due in the last notification run.",
+	})
+
+	NotificationsSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_sent_total",
+		Help:      "Push notifications accepted by FCM.",
+	})
+
+	NotificationFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_failures_total",
+		Help:      "Push notifications FCM rejected or that couldn't be sent.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		DBQueryDuration,
+		DBQueryErrors,
+		AuthDuration,
+		NotificationCycles,
+		NotificationCycleDuration,
+		NotificationDueTrackers,
+		NotificationsSent,
+		NotificationFailures,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
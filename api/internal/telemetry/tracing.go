@@ -0,0 +1,94 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zachczx/cubby/api/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/zachczx/cubby/api"
+
+// tracer follows the global provider, so spans started before InitTracing (or without it) are no-ops.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// InitTracing exports spans over OTLP/HTTP when an endpoint is configured, and does nothing otherwise. Settings come
+// from the config rather than the exporter's own environment lookup so that flags and the .env file apply; other
+// OTEL_* variables (sampling, resource attributes) are still read by the SDK. The returned function flushes pending
+// spans on shutdown.
+func InitTracing(ctx context.Context, c config.Tracing) (func(context.Context) error, error) {
+	if !c.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// The traces endpoint is a full URL; the base endpoint gets the signal path appended, as the spec describes.
+	endpoint := c.TracesEndpoint
+	if endpoint == "" {
+		endpoint = strings.TrimRight(c.Endpoint, "/") + "/v1/traces"
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+	if c.Headers != "" {
+		headers, err := parseHeaders(c.Headers)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlptracehttp.WithHeaders(headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+
+	// Detectors later in the list win, so OTEL_RESOURCE_ATTRIBUTES can still add to or override the name.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", c.ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("otel resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp.Shutdown, nil
+}
+
+// parseHeaders reads the OTEL_EXPORTER_OTLP_HEADERS format: comma-separated key=value pairs with URL-encoded values.
+func parseHeaders(raw string) (map[string]string, error) {
+	headers := map[string]string{}
+
+	for pair := range strings.SplitSeq(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("otlp headers: %q is not key=value", pair)
+		}
+
+		value, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("otlp headers: %w", err)
+		}
+		headers[strings.TrimSpace(k)] = value
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,58 @@
+package testdb
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// The fixtures insert the bare minimum with plain SQL rather than the domain packages, so those packages can use
+// them in their own tests without an import cycle.
+
+// User inserts a user with a unique email.
+func User(t testing.TB, db *sqlx.DB) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	if err := db.Get(&id, `INSERT INTO users (email) VALUES ($1) RETURNING id`, uuid.NewString()+"@example.com"); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	return id
+}
+
+// Family inserts a family owned by ownerID. Owners aren't rows in families_users; see AddMember.
+func Family(t testing.TB, db *sqlx.DB, ownerID uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	if err := db.Get(&id, `INSERT INTO families (name, owner_id) VALUES ('Family', $1) RETURNING id`, ownerID); err != nil {
+		t.Fatalf("insert family: %v", err)
+	}
+
+	return id
+}
+
+func AddMember(t testing.TB, db *sqlx.DB, familyID uuid.UUID, userID uuid.UUID) {
+	t.Helper()
+
+	if _, err := db.Exec(`INSERT INTO families_users (family_id, user_id) VALUES ($1, $2)`, familyID, userID); err != nil {
+		t.Fatalf("add family member: %v", err)
+	}
+}
+
+// Tracker inserts a daily tracker with a unique name.
+func Tracker(t testing.TB, db *sqlx.DB, ownerID uuid.UUID, familyID uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	q := `INSERT INTO trackers (owner_id, family_id, name, display, interval, interval_unit)
+			VALUES ($1, $2, $3, 'Tracker', 1, 'day') RETURNING id`
+
+	if err := db.Get(&id, q, ownerID, familyID, uuid.NewString()); err != nil {
+		t.Fatalf("insert tracker: %v", err)
+	}
+
+	return id
+}
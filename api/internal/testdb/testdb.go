@@ -0,0 +1,160 @@
+// Package testdb gives integration tests a migrated Postgres database of their own. It connects to DATABASE_URL when
+// set, otherwise starts a throwaway server with initdb and pg_ctl from PATH. With neither available, or with -short,
+// tests that ask for a database are skipped.
+package testdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib" // Register pgx driver.
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/migration"
+)
+
+var errNoPostgres = errors.New("set DATABASE_URL or put initdb and pg_ctl on PATH")
+
+var (
+	once     sync.Once
+	baseURL  string
+	stop     func()
+	setupErr error
+)
+
+// Main runs the package's tests and then shuts down the throwaway server, if one was started. Call it from TestMain.
+func Main(m *testing.M) {
+	code := m.Run()
+
+	if stop != nil {
+		stop()
+	}
+
+	os.Exit(code)
+}
+
+// New returns a connection to a freshly created and migrated database, dropped again when the test ends. Each test
+// gets its own database, so tests can run in parallel without seeing each other's rows.
+func New(t testing.TB) *sqlx.DB {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping database test in short mode")
+	}
+
+	once.Do(func() { baseURL, stop, setupErr = start() })
+
+	if errors.Is(setupErr, errNoPostgres) {
+		t.Skipf("no Postgres for integration tests: %v", setupErr)
+	}
+	if setupErr != nil {
+		t.Fatalf("start postgres: %v", setupErr)
+	}
+
+	admin, err := sqlx.Connect("pgx", baseURL)
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+
+	name := "cubby_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	if _, err := admin.Exec(`CREATE DATABASE ` + name); err != nil {
+		admin.Close()
+		t.Fatalf("create database: %v", err)
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatalf("parse database url: %v", err)
+	}
+	u.Path = "/" + name
+
+	db, err := sqlx.Connect("pgx", u.String())
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+
+		if _, err := admin.Exec(`DROP DATABASE IF EXISTS ` + name + ` WITH (FORCE)`); err != nil {
+			t.Logf("drop test database %s: %v", name, err)
+		}
+		admin.Close()
+	})
+
+	if err := migration.Up(context.Background(), db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	return db
+}
+
+// start picks the server the tests will use. The throwaway one trusts local connections, skips fsync and lives in
+// a temp directory removed by stop.
+func start() (string, func(), error) {
+	if u := os.Getenv("DATABASE_URL"); u != "" {
+		return u, nil, nil
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		return "", nil, errNoPostgres
+	}
+
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		return "", nil, errNoPostgres
+	}
+
+	dir, err := os.MkdirTemp("", "cubby-pg-")
+	if err != nil {
+		return "", nil, fmt.Errorf("temp dir: %w", err)
+	}
+
+	data := filepath.Join(dir, "data")
+
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c full_page_writes=off", port, dir)
+
+	if out, err := exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-o", opts, "-w", "start").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+
+	stop := func() {
+		_ = exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port), stop, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("find free port: %w", err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
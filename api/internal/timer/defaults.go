@@ -1,6 +1,7 @@
 package timer
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -19,9 +20,9 @@ var profileDefaults = []ProfileInput{
 	},
 }
 
-func CreateDefaults(db *sqlx.DB, userID uuid.UUID) error {
+func CreateDefaults(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error {
 	for _, d := range profileDefaults {
-		if _, err := NewProfile(db, userID, d); err != nil {
+		if _, err := NewProfile(ctx, db, userID, d); err != nil {
 			return fmt.Errorf("creating default timer profile %s: %w", d.Name, err)
 		}
 	}
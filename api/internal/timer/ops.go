@@ -1,20 +1,22 @@
 package timer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
-func GetAllProfiles(db *sqlx.DB, userID uuid.UUID) ([]Profile, error) {
+func GetAllProfiles(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Profile, error) {
 	q := `SELECT * FROM timer_profiles
 			WHERE user_id = $1
 			ORDER BY created_at ASC`
 
 	var profiles []Profile
-	if err := db.Select(&profiles, q, userID); err != nil {
+	if err := db.SelectContext(ctx, &profiles, q, userID); err != nil {
 		return nil, fmt.Errorf("get all timer profiles: %w", err)
 	}
 
@@ -25,14 +27,14 @@ func GetAllProfiles(db *sqlx.DB, userID uuid.UUID) ([]Profile, error) {
 	return profiles, nil
 }
 
-func NewProfile(db *sqlx.DB, userID uuid.UUID, input ProfileInput) (Profile, error) {
+func NewProfile(ctx context.Context, db *sqlx.DB, userID uuid.UUID, input ProfileInput) (Profile, error) {
 	segJSON, err := json.Marshal(input.Segments)
 	if err != nil {
 		return Profile{}, fmt.Errorf("marshal segments: %w", err)
 	}
 
 	if input.IsDefault {
-		if err := clearDefault(db, userID); err != nil {
+		if err := clearDefault(ctx, db, userID); err != nil {
 			return Profile{}, err
 		}
 	}
@@ -42,7 +44,7 @@ func NewProfile(db *sqlx.DB, userID uuid.UUID, input ProfileInput) (Profile, err
 			RETURNING id, user_id, name, segments, is_default, created_at, updated_at`
 
 	var p Profile
-	err = db.QueryRow(q, userID, input.Name, segJSON, input.IsDefault).Scan(
+	err = db.QueryRowContext(ctx, q, userID, input.Name, segJSON, input.IsDefault).Scan(
 		&p.ID, &p.UserID, &p.Name, &p.Segments, &p.IsDefault, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
@@ -52,14 +54,14 @@ func NewProfile(db *sqlx.DB, userID uuid.UUID, input ProfileInput) (Profile, err
 	return p, nil
 }
 
-func EditProfile(db *sqlx.DB, userID uuid.UUID, profileID uuid.UUID, input ProfileInput) error {
+func EditProfile(ctx context.Context, db *sqlx.DB, userID uuid.UUID, profileID uuid.UUID, input ProfileInput) error {
 	segJSON, err := json.Marshal(input.Segments)
 	if err != nil {
 		return fmt.Errorf("marshal segments: %w", err)
 	}
 
 	if input.IsDefault {
-		if err := clearDefault(db, userID); err != nil {
+		if err := clearDefault(ctx, db, userID); err != nil {
 			return err
 		}
 	}
@@ -68,27 +70,29 @@ func EditProfile(db *sqlx.DB, userID uuid.UUID, profileID uuid.UUID, input Profi
 			SET name = $1, segments = $2, is_default = $3, updated_at = NOW()
 			WHERE id = $4 AND user_id = $5`
 
-	if _, err := db.Exec(q, input.Name, segJSON, input.IsDefault, profileID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, input.Name, segJSON, input.IsDefault, profileID, userID)
+	if err != nil {
 		return fmt.Errorf("edit timer profile: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "timer profile")
 }
 
-func clearDefault(db *sqlx.DB, userID uuid.UUID) error {
+func clearDefault(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error {
 	q := `UPDATE timer_profiles SET is_default = FALSE WHERE user_id = $1 AND is_default = TRUE`
-	if _, err := db.Exec(q, userID); err != nil {
+	if _, err := db.ExecContext(ctx, q, userID); err != nil {
 		return fmt.Errorf("clear default timer profile: %w", err)
 	}
 	return nil
 }
 
-func DeleteProfile(db *sqlx.DB, userID uuid.UUID, profileID uuid.UUID) error {
+func DeleteProfile(ctx context.Context, db *sqlx.DB, userID uuid.UUID, profileID uuid.UUID) error {
 	q := `DELETE FROM timer_profiles WHERE id = $1 AND user_id = $2`
 
-	if _, err := db.Exec(q, profileID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, profileID, userID)
+	if err != nil {
 		return fmt.Errorf("delete timer profile: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "timer profile")
 }
@@ -0,0 +1,36 @@
+package timer
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs timer profile queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) CreateDefaults(ctx context.Context, userID uuid.UUID) error {
+	return CreateDefaults(ctx, r.db, userID)
+}
+
+func (r *Repository) DeleteProfile(ctx context.Context, userID uuid.UUID, profileID uuid.UUID) error {
+	return DeleteProfile(ctx, r.db, userID, profileID)
+}
+
+func (r *Repository) EditProfile(ctx context.Context, userID uuid.UUID, profileID uuid.UUID, input ProfileInput) error {
+	return EditProfile(ctx, r.db, userID, profileID, input)
+}
+
+func (r *Repository) GetAllProfiles(ctx context.Context, userID uuid.UUID) ([]Profile, error) {
+	return GetAllProfiles(ctx, r.db, userID)
+}
+
+func (r *Repository) NewProfile(ctx context.Context, userID uuid.UUID, input ProfileInput) (Profile, error) {
+	return NewProfile(ctx, r.db, userID, input)
+}
@@ -0,0 +1,119 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/database"
+)
+
+type ChecklistItem struct {
+	ID        uuid.UUID `db:"id"         json:"id"`
+	TrackerID uuid.UUID `db:"tracker_id" json:"trackerId"`
+	Label     string    `db:"label"      json:"label"`
+	Position  int16     `db:"position"   json:"position"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+type ChecklistItemInput struct {
+	Label string `json:"label"`
+}
+
+func GetChecklist(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID) ([]ChecklistItem, error) {
+	q := `SELECT tci.* FROM tracker_checklist_items tci
+			JOIN trackers t ON tci.tracker_id = t.id
+			WHERE t.id = $1
+			AND (t.owner_id = $2 OR t.family_id IN (` + database.VisibleFamilies("$2") + `))
+			ORDER BY tci.position ASC`
+
+	items := []ChecklistItem{}
+	if err := db.SelectContext(ctx, &items, q, trackerID, userID); err != nil {
+		return nil, fmt.Errorf("get checklist: %w", err)
+	}
+
+	return items, nil
+}
+
+func getChecklists(ctx context.Context, db *sqlx.DB, trackerIDs []uuid.UUID) (map[uuid.UUID][]ChecklistItem, error) {
+	byTracker := make(map[uuid.UUID][]ChecklistItem)
+
+	if len(trackerIDs) == 0 {
+		return byTracker, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT * FROM tracker_checklist_items
+									WHERE tracker_id IN (?)
+									ORDER BY position ASC`, trackerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get checklists in: %w", err)
+	}
+	query = db.Rebind(query)
+
+	var items []ChecklistItem
+	if err := db.SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, fmt.Errorf("get checklists: %w", err)
+	}
+
+	for _, i := range items {
+		byTracker[i.TrackerID] = append(byTracker[i.TrackerID], i)
+	}
+
+	return byTracker, nil
+}
+
+func AddChecklistItem(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, input ChecklistItemInput) (ChecklistItem, error) {
+	q := `INSERT INTO tracker_checklist_items (tracker_id, label, position)
+			SELECT $1, $2,
+				COALESCE((SELECT MAX(position) + 1 FROM tracker_checklist_items WHERE tracker_id = $1), 0)
+			FROM trackers
+			WHERE id = $1 AND owner_id = $3
+			RETURNING id, tracker_id, label, position, created_at, updated_at`
+
+	var i ChecklistItem
+	err := db.QueryRowContext(ctx, q, trackerID, input.Label, userID).Scan(
+		&i.ID, &i.TrackerID, &i.Label, &i.Position, &i.CreatedAt, &i.UpdatedAt,
+	)
+	if err != nil {
+		return ChecklistItem{}, fmt.Errorf("add checklist item: %w", err)
+	}
+
+	return i, nil
+}
+
+func EditChecklistItem(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, itemID uuid.UUID, input ChecklistItemInput) error {
+	q := `UPDATE tracker_checklist_items
+			SET label = $1, updated_at = NOW()
+			FROM trackers
+			WHERE tracker_checklist_items.id = $2
+			AND tracker_checklist_items.tracker_id = trackers.id
+			AND trackers.id = $3
+			AND trackers.owner_id = $4`
+
+	res, err := db.ExecContext(ctx, q, input.Label, itemID, trackerID, userID)
+	if err != nil {
+		return fmt.Errorf("edit checklist item: %w", err)
+	}
+
+	return apperr.Affected(res, "checklist item")
+}
+
+func RemoveChecklistItem(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, itemID uuid.UUID) error {
+	q := `DELETE FROM tracker_checklist_items
+			USING trackers
+			WHERE tracker_checklist_items.id = $1
+			AND tracker_checklist_items.tracker_id = trackers.id
+			AND trackers.id = $2
+			AND trackers.owner_id = $3`
+
+	res, err := db.ExecContext(ctx, q, itemID, trackerID, userID)
+	if err != nil {
+		return fmt.Errorf("remove checklist item: %w", err)
+	}
+
+	return apperr.Affected(res, "checklist item")
+}
@@ -1,6 +1,7 @@
 package tracker
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -110,8 +111,8 @@ var trackerDefaults = []Input{
 
 type DefaultService struct{}
 
-func (DefaultService) CreateDefaults(db *sqlx.DB, userID uuid.UUID) error {
-	familyID, err := user.GetUserFamilyID(db, userID)
+func (DefaultService) CreateDefaults(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error {
+	familyID, err := user.GetUserFamilyID(ctx, db, userID)
 	if err != nil {
 		return fmt.Errorf("getting user family id: %w", err)
 	}
@@ -134,7 +135,7 @@ func (DefaultService) CreateDefaults(db *sqlx.DB, userID uuid.UUID) error {
 			UpdatedAt:    time.Now(),
 		}
 
-		if _, err := New(db, t); err != nil {
+		if _, err := New(ctx, db, t); err != nil {
 			return fmt.Errorf("creating default tracker %s: %w", d.Name, err)
 		}
 	}
@@ -0,0 +1,207 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/database"
+)
+
+var (
+	ErrDependencyCycle      = errors.New("dependency would create a cycle")
+	ErrPrerequisiteNotFound = apperr.NotFound("prerequisite tracker not found")
+)
+
+type Dependency struct {
+	TrackerID   uuid.UUID `db:"tracker_id"    json:"trackerId"`
+	DependsOnID uuid.UUID `db:"depends_on_id" json:"dependsOnId"`
+	CreatedAt   time.Time `db:"created_at"    json:"createdAt"`
+}
+
+type DependencyInput struct {
+	DependsOnID uuid.UUID `json:"dependsOnId"`
+}
+
+func GetDependencies(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID) ([]Dependency, error) {
+	q := `SELECT td.* FROM tracker_dependencies td
+			JOIN trackers t ON td.tracker_id = t.id
+			WHERE t.id = $1
+			AND (t.owner_id = $2 OR t.family_id IN (` + database.VisibleFamilies("$2") + `))
+			ORDER BY td.created_at ASC`
+
+	deps := []Dependency{}
+	if err := db.SelectContext(ctx, &deps, q, trackerID, userID); err != nil {
+		return nil, fmt.Errorf("get dependencies: %w", err)
+	}
+
+	return deps, nil
+}
+
+func getDependsOn(ctx context.Context, db *sqlx.DB, trackerIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	byTracker := make(map[uuid.UUID][]uuid.UUID)
+
+	if len(trackerIDs) == 0 {
+		return byTracker, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT * FROM tracker_dependencies WHERE tracker_id IN (?)`, trackerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get depends on in: %w", err)
+	}
+	query = db.Rebind(query)
+
+	var deps []Dependency
+	if err := db.SelectContext(ctx, &deps, query, args...); err != nil {
+		return nil, fmt.Errorf("get depends on: %w", err)
+	}
+
+	for _, d := range deps {
+		byTracker[d.TrackerID] = append(byTracker[d.TrackerID], d.DependsOnID)
+	}
+
+	return byTracker, nil
+}
+
+// AddDependency links trackerID to a prerequisite in the same family. Only the tracker owner may add links, and
+// that is checked before the family is locked or its links walked.
+func AddDependency(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, dependsOnID uuid.UUID) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("add dependency begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var familyID uuid.UUID
+
+	oQ := `SELECT family_id FROM trackers WHERE id = $1 AND owner_id = $2`
+
+	if err := tx.GetContext(ctx, &familyID, oQ, trackerID, userID); err != nil {
+		return notOwned(ctx, db, trackerID, userID, fmt.Errorf("add dependency get tracker: %w", err))
+	}
+
+	var sameFamily bool
+
+	fQ := `SELECT EXISTS(SELECT 1 FROM trackers WHERE id = $1 AND family_id = $2)`
+
+	if err := tx.GetContext(ctx, &sameFamily, fQ, dependsOnID, familyID); err != nil {
+		return fmt.Errorf("add dependency get prerequisite: %w", err)
+	}
+
+	if !sameFamily {
+		return ErrPrerequisiteNotFound
+	}
+
+	if trackerID == dependsOnID {
+		return ErrDependencyCycle
+	}
+
+	// Links only join trackers of one family, so a per-family lock held until commit stops two concurrent requests
+	// (A→B and B→A) from both passing the cycle check below.
+	lQ := `SELECT pg_advisory_xact_lock(hashtextextended('tracker_dependencies:' || $1::text, 0))`
+
+	if _, err := tx.ExecContext(ctx, lQ, familyID); err != nil {
+		return fmt.Errorf("add dependency lock: %w", err)
+	}
+
+	// Walk the prerequisite's own dependencies; reaching trackerID means the new link closes a loop.
+	var hasCycle bool
+	cQ := `WITH RECURSIVE chain(id) AS (
+				SELECT depends_on_id FROM tracker_dependencies WHERE tracker_id = $1
+				UNION
+				SELECT td.depends_on_id FROM tracker_dependencies td
+				JOIN chain ON td.tracker_id = chain.id
+			)
+			SELECT EXISTS(SELECT 1 FROM chain WHERE id = $2)`
+
+	if err := tx.GetContext(ctx, &hasCycle, cQ, dependsOnID, trackerID); err != nil {
+		return fmt.Errorf("add dependency cycle check: %w", err)
+	}
+
+	if hasCycle {
+		return ErrDependencyCycle
+	}
+
+	q := `INSERT INTO tracker_dependencies (tracker_id, depends_on_id) VALUES ($1, $2)`
+
+	if _, err := tx.ExecContext(ctx, q, trackerID, dependsOnID); err != nil {
+		return fmt.Errorf("add dependency: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("add dependency commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func RemoveDependency(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, dependsOnID uuid.UUID) error {
+	q := `DELETE FROM tracker_dependencies
+			USING trackers
+			WHERE tracker_dependencies.tracker_id = trackers.id
+			AND trackers.id = $1
+			AND trackers.owner_id = $2
+			AND tracker_dependencies.depends_on_id = $3`
+
+	res, err := db.ExecContext(ctx, q, trackerID, userID, dependsOnID)
+	if err != nil {
+		return fmt.Errorf("remove dependency: %w", err)
+	}
+
+	return apperr.Affected(res, "dependency")
+}
+
+// HoldBlockedTrackers marks due trackers as "held" while any prerequisite has not been logged
+// since the dependent tracker's last entry, so reminders wait for the prerequisite.
+func HoldBlockedTrackers(ctx context.Context, db *sqlx.DB, trackers []LatestEntry) ([]LatestEntry, error) {
+	var due []uuid.UUID
+	for _, t := range trackers {
+		if t.DueStatus != nil && *t.DueStatus == "due" {
+			due = append(due, t.ID)
+		}
+	}
+
+	if len(due) == 0 {
+		return trackers, nil
+	}
+
+	q := `SELECT DISTINCT td.tracker_id
+			FROM tracker_dependencies td
+			LEFT JOIN (
+				SELECT tracker_id, MAX(performed_at) AS last_entry FROM entries GROUP BY tracker_id
+			) AS dep ON dep.tracker_id = td.tracker_id
+			LEFT JOIN (
+				SELECT tracker_id, MAX(performed_at) AS last_entry FROM entries GROUP BY tracker_id
+			) AS pre ON pre.tracker_id = td.depends_on_id
+			WHERE td.tracker_id IN (?)
+			AND (pre.last_entry IS NULL OR (dep.last_entry IS NOT NULL AND pre.last_entry <= dep.last_entry))`
+
+	query, args, err := sqlx.In(q, due)
+	if err != nil {
+		return nil, fmt.Errorf("hold blocked trackers in: %w", err)
+	}
+	query = db.Rebind(query)
+
+	var blocked []uuid.UUID
+	if err := db.SelectContext(ctx, &blocked, query, args...); err != nil {
+		return nil, fmt.Errorf("hold blocked trackers: %w", err)
+	}
+
+	isBlocked := make(map[uuid.UUID]bool, len(blocked))
+	for _, id := range blocked {
+		isBlocked[id] = true
+	}
+
+	for i := range trackers {
+		if isBlocked[trackers[i].ID] && trackers[i].DueStatus != nil && *trackers[i].DueStatus == "due" {
+			h := "held"
+			trackers[i].DueStatus = &h
+		}
+	}
+
+	return trackers, nil
+}
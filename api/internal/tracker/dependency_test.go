@@ -0,0 +1,41 @@
+package tracker_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/zachczx/cubby/api/internal/testdb"
+	"github.com/zachczx/cubby/api/internal/tracker"
+)
+
+func TestAddDependencyConcurrentCycle(t *testing.T) {
+	db := testdb.New(t)
+
+	owner := testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+
+	for range 20 {
+		a, b := testdb.Tracker(t, db, owner, familyID), testdb.Tracker(t, db, owner, familyID)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		wg.Go(func() { errs[0] = tracker.AddDependency(t.Context(), db, owner, a, b) })
+		wg.Go(func() { errs[1] = tracker.AddDependency(t.Context(), db, owner, b, a) })
+		wg.Wait()
+
+		var cycles int
+		for _, err := range errs {
+			switch {
+			case errors.Is(err, tracker.ErrDependencyCycle):
+				cycles++
+			case err != nil:
+				t.Fatal(err)
+			}
+		}
+
+		if cycles != 1 {
+			t.Fatalf("%d of 2 opposite links refused, want exactly 1", cycles)
+		}
+	}
+}
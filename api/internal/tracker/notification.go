@@ -2,16 +2,24 @@ package tracker
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/event"
+	"github.com/zachczx/cubby/api/internal/health"
 	"github.com/zachczx/cubby/api/internal/notifier"
+	"github.com/zachczx/cubby/api/internal/telemetry"
 )
 
-func StartNotifications(ctx context.Context, db *sqlx.DB, fcm *notifier.FCMClient) error {
+// StartNotifications runs CheckAndNotify every minute until ctx is done, beating heartbeat after each run so the
+// health checks can tell a stuck worker from a quiet one.
+func StartNotifications(ctx context.Context, db *sqlx.DB, fcm *notifier.FCMClient, events *event.Bus, heartbeat *health.Heartbeat) error {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
@@ -29,10 +37,19 @@ func StartNotifications(ctx context.Context, db *sqlx.DB, fcm *notifier.FCMClien
 
 			go func() {
 				defer wg.Done()
-				if err := CheckAndNotify(db, fcm); err != nil {
-					// return fmt.Errorf("start notif: %w", err)
+
+				start := time.Now()
+				err := CheckAndNotify(ctx, db, fcm, events)
+				telemetry.NotificationCycleDuration.Observe(time.Since(start).Seconds())
+				heartbeat.Beat()
+
+				if err != nil {
+					telemetry.NotificationCycles.WithLabelValues("error").Inc()
 					log.Println("notification worker error:", err)
+					return
 				}
+
+				telemetry.NotificationCycles.WithLabelValues("ok").Inc()
 			}()
 		}
 	}
@@ -40,11 +57,11 @@ func StartNotifications(ctx context.Context, db *sqlx.DB, fcm *notifier.FCMClien
 
 var ctxTimeout time.Duration = 10
 
-func CheckAndNotify(db *sqlx.DB, fcm *notifier.FCMClient) error {
-	ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout*time.Second)
+func CheckAndNotify(ctx context.Context, db *sqlx.DB, fcm *notifier.FCMClient, events *event.Bus) error {
+	ctx, cancel := context.WithTimeout(ctx, ctxTimeout*time.Second)
 	defer cancel()
 
-	t, err := GetTrackersLast(db)
+	t, err := GetTrackersLast(ctx, db)
 	if err != nil {
 		return fmt.Errorf("get tracker last: %w", err)
 	}
@@ -54,12 +71,24 @@ func CheckAndNotify(db *sqlx.DB, fcm *notifier.FCMClient) error {
 		return fmt.Errorf("calculateTrackersLastDue: %w", err)
 	}
 
+	lastDueTrackers, err = HoldBlockedTrackers(ctx, db, lastDueTrackers)
+	if err != nil {
+		return fmt.Errorf("holdBlockedTrackers: %w", err)
+	}
+
+	// A failed event write shouldn't hold back push notifications.
+	if err := publishDue(ctx, db, events, lastDueTrackers); err != nil {
+		log.Println("tracker due events error:", err)
+	}
+
 	dueTrackers, err := GetDueTrackerID(lastDueTrackers)
 	if err != nil {
 		return fmt.Errorf("getDueTrackerID: %w", err)
 	}
 
-	userTokens, err := notifier.GetUsersWithTokens(db, dueTrackers)
+	telemetry.NotificationDueTrackers.Set(float64(len(dueTrackers)))
+
+	userTokens, err := notifier.GetUsersWithTokens(ctx, db, dueTrackers)
 	if err != nil {
 		return fmt.Errorf("getUsersWithTokens: %w", err)
 	}
@@ -70,3 +99,45 @@ func CheckAndNotify(db *sqlx.DB, fcm *notifier.FCMClient) error {
 
 	return nil
 }
+
+// publishDue emits tracker.due once per due cycle. The last entry a tracker was due from is remembered, so the
+// event fires again only after someone logs an entry and the tracker falls due a second time.
+func publishDue(ctx context.Context, db *sqlx.DB, events *event.Bus, trackers []LatestEntry) error {
+	if events == nil {
+		return nil
+	}
+
+	q := `INSERT INTO tracker_due_events (tracker_id, last_entry) VALUES ($1, $2)
+			ON CONFLICT (tracker_id) DO UPDATE SET last_entry = EXCLUDED.last_entry, created_at = NOW()
+			WHERE tracker_due_events.last_entry <> EXCLUDED.last_entry
+			RETURNING tracker_id`
+
+	for _, t := range trackers {
+		if t.DueStatus == nil || *t.DueStatus != "due" || t.LastEntry == nil {
+			continue
+		}
+
+		var id uuid.UUID
+		if err := db.QueryRowContext(ctx, q, t.ID, *t.LastEntry).Scan(&id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return fmt.Errorf("mark tracker due: %w", err)
+		}
+
+		events.Publish(ctx, event.Event{
+			Type:     event.TrackerDue,
+			FamilyID: t.Family,
+			Data: map[string]any{
+				"trackerId": t.ID,
+				"name":      t.Name,
+				"display":   t.Display,
+				"lastEntry": t.LastEntry,
+				"dueAt":     NextDue(*t.LastEntry, t.Interval, t.IntervalUnit),
+			},
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package tracker
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository is the Postgres-backed set of tracker queries the handlers use.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) AddChecklistItem(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, input ChecklistItemInput) (ChecklistItem, error) {
+	return AddChecklistItem(ctx, r.db, userID, trackerID, input)
+}
+
+func (r *Repository) AddDependency(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, dependsOnID uuid.UUID) error {
+	return AddDependency(ctx, r.db, userID, trackerID, dependsOnID)
+}
+
+func (r *Repository) Delete(ctx context.Context, trackerID uuid.UUID, userID uuid.UUID) error {
+	return Delete(ctx, r.db, trackerID, userID)
+}
+
+func (r *Repository) Edit(ctx context.Context, t Tracker) error {
+	return Edit(ctx, r.db, t)
+}
+
+func (r *Repository) EditChecklistItem(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, itemID uuid.UUID, input ChecklistItemInput) error {
+	return EditChecklistItem(ctx, r.db, userID, trackerID, itemID, input)
+}
+
+func (r *Repository) Get(ctx context.Context, trackerID uuid.UUID, userID uuid.UUID) (Tracker, error) {
+	return Get(ctx, r.db, trackerID, userID)
+}
+
+func (r *Repository) GetAll(ctx context.Context, userID uuid.UUID) ([]Tracker, error) {
+	return GetAll(ctx, r.db, userID)
+}
+
+func (r *Repository) GetChecklist(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID) ([]ChecklistItem, error) {
+	return GetChecklist(ctx, r.db, userID, trackerID)
+}
+
+func (r *Repository) GetDependencies(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID) ([]Dependency, error) {
+	return GetDependencies(ctx, r.db, userID, trackerID)
+}
+
+func (r *Repository) GetTrackersLast(ctx context.Context) ([]LatestEntry, error) {
+	return GetTrackersLast(ctx, r.db)
+}
+
+func (r *Repository) HoldBlockedTrackers(ctx context.Context, trackers []LatestEntry) ([]LatestEntry, error) {
+	return HoldBlockedTrackers(ctx, r.db, trackers)
+}
+
+func (r *Repository) MuteTracker(ctx context.Context, trackerID uuid.UUID, userID uuid.UUID, isMuted bool) error {
+	return MuteTracker(ctx, r.db, trackerID, userID, isMuted)
+}
+
+func (r *Repository) New(ctx context.Context, t Tracker) (uuid.UUID, error) {
+	return New(ctx, r.db, t)
+}
+
+func (r *Repository) RemoveChecklistItem(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, itemID uuid.UUID) error {
+	return RemoveChecklistItem(ctx, r.db, userID, trackerID, itemID)
+}
+
+func (r *Repository) RemoveDependency(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, dependsOnID uuid.UUID) error {
+	return RemoveDependency(ctx, r.db, userID, trackerID, dependsOnID)
+}
+
+func (r *Repository) TogglePin(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, isPinned bool) error {
+	return TogglePin(ctx, r.db, userID, trackerID, isPinned)
+}
+
+func (r *Repository) ToggleShow(ctx context.Context, userID uuid.UUID, trackerID uuid.UUID, show bool) error {
+	return ToggleShow(ctx, r.db, userID, trackerID, show)
+}
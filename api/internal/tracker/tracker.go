@@ -1,13 +1,24 @@
 package tracker
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/database"
 )
 
+// IntervalUnits are the units NextDue knows how to add.
+var IntervalUnits = []string{"day", "month", "year"}
+
+// ErrNotTrackerOwner is for family members who can see a tracker but not change it.
+var ErrNotTrackerOwner = apperr.Forbidden("only the tracker owner can do this")
+
 type Tracker struct {
 	ID           uuid.UUID  `json:"id" db:"id"`
 	Owner        uuid.UUID  `json:"-" db:"owner_id"`
@@ -28,8 +39,10 @@ type Tracker struct {
 	UpdatedAt    time.Time  `json:"updatedAt" db:"updated_at"`
 	IsMuted      bool       `json:"isMuted" db:"is_muted"`
 
-	FamilyName string `json:"familyName" db:"family_name"`
-	IsOwner    bool   `json:"isOwner" db:"-"`
+	FamilyName string          `json:"familyName" db:"family_name"`
+	IsOwner    bool            `json:"isOwner" db:"-"`
+	Checklist  []ChecklistItem `json:"checklist" db:"-"`
+	DependsOn  []uuid.UUID     `json:"dependsOn" db:"-"`
 }
 
 type Input struct {
@@ -45,9 +58,11 @@ type Input struct {
 	Icon         string   `json:"icon"`
 	StartDate    string   `json:"startDate"`
 	Cost         *float64 `json:"cost"`
+
+	FamilyID *uuid.UUID `json:"familyId"`
 }
 
-func New(db *sqlx.DB, t Tracker) (uuid.UUID, error) {
+func New(ctx context.Context, db *sqlx.DB, t Tracker) (uuid.UUID, error) {
 	var newID uuid.UUID
 
 	q := `INSERT INTO trackers (
@@ -60,7 +75,7 @@ func New(db *sqlx.DB, t Tracker) (uuid.UUID, error) {
 				NOW(), NOW()
 			) RETURNING id`
 
-	rows, err := db.NamedQuery(q, t)
+	rows, err := db.NamedQueryContext(ctx, q, t)
 	if err != nil {
 		return newID, fmt.Errorf("new tracker NamedQuery: %w", err)
 	}
@@ -75,7 +90,7 @@ func New(db *sqlx.DB, t Tracker) (uuid.UUID, error) {
 	return newID, nil
 }
 
-func Edit(db *sqlx.DB, t Tracker) error {
+func Edit(ctx context.Context, db *sqlx.DB, t Tracker) error {
 	q := `UPDATE trackers 
 			SET name = :name, 
 				display = :display, 
@@ -90,81 +105,161 @@ func Edit(db *sqlx.DB, t Tracker) error {
 				start_date = :start_date, 
 				cost = :cost, 
 				updated_at = NOW()
-			FROM families
-			WHERE trackers.owner_id = families.owner_id
-			AND trackers.id = :id`
+			WHERE id = :id AND owner_id = :owner_id`
 
-	if _, err := db.NamedExec(q, t); err != nil {
+	res, err := db.NamedExecContext(ctx, q, t)
+	if err != nil {
 		return fmt.Errorf("edit tracker: %w", err)
 	}
 
-	return nil
+	return notOwned(ctx, db, t.ID, t.Owner, apperr.Affected(res, "tracker"))
 }
 
-func Delete(db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID) error {
+func Delete(ctx context.Context, db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID) error {
 	q := `DELETE FROM trackers WHERE id = $1 AND owner_id = $2`
 
-	if _, err := db.Exec(q, trackerID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, trackerID, userID)
+	if err != nil {
 		return fmt.Errorf("delete tracker: %w", err)
 	}
 
-	return nil
+	return notOwned(ctx, db, trackerID, userID, apperr.Affected(res, "tracker"))
 }
 
-func Get(db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID) (Tracker, error) {
+func Get(ctx context.Context, db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID) (Tracker, error) {
 	var t Tracker
 	q := `SELECT * FROM trackers WHERE id=$1 AND owner_id=$2`
-	if err := db.Get(&t, q, trackerID, userID); err != nil {
-		return Tracker{}, fmt.Errorf("select tracker: %w", err)
+	if err := db.GetContext(ctx, &t, q, trackerID, userID); err != nil {
+		return Tracker{}, notOwned(ctx, db, trackerID, userID, fmt.Errorf("select tracker: %w", err))
 	}
 	return t, nil
 }
 
-func GetAll(db *sqlx.DB, userID uuid.UUID) ([]Tracker, error) {
+// notOwned turns a miss from an owner-only query into ErrNotTrackerOwner when the user can still see the tracker
+// through a family, so only trackers hidden from them read as missing. Other errors pass through.
+func notOwned(ctx context.Context, db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID, err error) error {
+	if !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, apperr.ErrNotFound) {
+		return err
+	}
+
+	var visible bool
+
+	q := `SELECT EXISTS(SELECT 1 FROM trackers
+			WHERE id = $1
+			AND family_id IN (` + database.VisibleFamilies("$2") + `))`
+
+	if vErr := db.GetContext(ctx, &visible, q, trackerID, userID); vErr != nil {
+		return fmt.Errorf("check tracker visible: %w", vErr)
+	}
+
+	if visible {
+		return ErrNotTrackerOwner
+	}
+
+	return err
+}
+
+func GetAll(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Tracker, error) {
 	var t []Tracker
 	q := `SELECT t.*, f.name AS family_name, COALESCE(tus.is_muted, false) AS is_muted FROM trackers t
 			JOIN families f ON t.family_id = f.id   
 			LEFT JOIN tracker_user_settings tus ON tus.user_id = $1 AND tus.tracker_id = t.id
-			WHERE t.owner_id = $1 OR t.family_id IN (
-				SELECT family_id FROM families_users WHERE user_id = $1
-			)
+			WHERE t.owner_id = $1 OR t.family_id IN (` + database.VisibleFamilies("$1") + `)
 			ORDER BY t.pinned DESC, t.name ASC`
 
-	if err := db.Select(&t, q, userID); err != nil {
+	if err := db.SelectContext(ctx, &t, q, userID); err != nil {
 		return nil, fmt.Errorf("select trackers: %w", err)
 	}
 
+	ids := make([]uuid.UUID, len(t))
+	for i := range t {
+		ids[i] = t[i].ID
+	}
+
+	checklists, err := getChecklists(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	dependsOn, err := getDependsOn(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	for i := range t {
 		if userID == t[i].Owner {
 			t[i].IsOwner = true
 		}
+
+		t[i].Checklist = checklists[t[i].ID]
+		if t[i].Checklist == nil {
+			t[i].Checklist = []ChecklistItem{}
+		}
+
+		t[i].DependsOn = dependsOn[t[i].ID]
+		if t[i].DependsOn == nil {
+			t[i].DependsOn = []uuid.UUID{}
+		}
 	}
 
 	return t, nil
 }
 
-func TogglePin(db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, isPinned bool) error {
+// GetLastEntryTimes returns the most recent performed_at for each tracker that has entries.
+func GetLastEntryTimes(ctx context.Context, db *sqlx.DB, trackerIDs []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	last := make(map[uuid.UUID]time.Time)
+
+	if len(trackerIDs) == 0 {
+		return last, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT tracker_id, MAX(performed_at) AS last_entry FROM entries
+									WHERE tracker_id IN (?)
+									GROUP BY tracker_id`, trackerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("last entry times in: %w", err)
+	}
+	query = db.Rebind(query)
+
+	var rows []struct {
+		TrackerID uuid.UUID `db:"tracker_id"`
+		LastEntry time.Time `db:"last_entry"`
+	}
+	if err := db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("last entry times: %w", err)
+	}
+
+	for _, r := range rows {
+		last[r.TrackerID] = r.LastEntry
+	}
+
+	return last, nil
+}
+
+func TogglePin(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, isPinned bool) error {
 	q := `UPDATE trackers 
 			SET pinned = $1
 			WHERE id = $2 AND owner_id = $3`
 
-	if _, err := db.Exec(q, isPinned, trackerID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, isPinned, trackerID, userID)
+	if err != nil {
 		return fmt.Errorf("toggle pin: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "tracker")
 }
 
-func ToggleShow(db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, show bool) error {
+func ToggleShow(ctx context.Context, db *sqlx.DB, userID uuid.UUID, trackerID uuid.UUID, show bool) error {
 	q := `UPDATE trackers 
 			SET show = $1
 			WHERE id = $2 AND owner_id = $3`
 
-	if _, err := db.Exec(q, show, trackerID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, show, trackerID, userID)
+	if err != nil {
 		return fmt.Errorf("toggle pin: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "tracker")
 }
 
 type LatestEntry struct {
@@ -176,7 +271,7 @@ type LatestEntry struct {
 	DueStatus        *string    `json:"dueStatus" db:"-"`
 }
 
-func GetTrackersLast(db *sqlx.DB) ([]LatestEntry, error) {
+func GetTrackersLast(ctx context.Context, db *sqlx.DB) ([]LatestEntry, error) {
 	q := `SELECT t.*, e.last_entry, e.last_interval, e.last_interval_unit, f.name AS family_name FROM trackers t
 			JOIN (
 				SELECT tracker_id, MAX(performed_at) AS last_entry, interval AS last_interval, interval_unit AS last_interval_unit FROM entries GROUP BY tracker_id, last_interval, last_interval_unit
@@ -185,38 +280,43 @@ func GetTrackersLast(db *sqlx.DB) ([]LatestEntry, error) {
 
 	var t []LatestEntry
 
-	if err := db.Select(&t, q); err != nil {
+	if err := db.SelectContext(ctx, &t, q); err != nil {
 		return nil, fmt.Errorf("get trackers list: %w", err)
 	}
 
 	return t, nil
 }
 
+// NextDue returns when a tracker last done at last is due again.
+func NextDue(last time.Time, interval int, intervalUnit string) time.Time {
+	switch intervalUnit {
+	case "day":
+		return last.Add(time.Duration(interval) * 24 * time.Hour)
+	case "month":
+		return last.AddDate(0, interval, 0)
+	case "year":
+		return last.AddDate(interval, 0, 0)
+	}
+
+	return last
+}
+
+// Grace period after the due date before a notification is sent.
+var dueGrace = map[string]time.Duration{
+	"day":   6 * time.Hour,
+	"month": 12 * time.Hour,
+	"year":  24 * time.Hour,
+}
+
 func CalculateTrackersLastDue(tDB []LatestEntry) ([]LatestEntry, error) {
 	newT := tDB
 
 	for i := range tDB {
-		var threshold time.Time
-
 		if tDB[i].LastEntry == nil || tDB[i].LastInterval == nil || tDB[i].LastIntervalUnit == nil {
 			continue
 		}
 
-		itv := &tDB[i].Interval
-
-		switch tDB[i].IntervalUnit {
-		case "day":
-			threshold = tDB[i].LastEntry.Add(time.Duration(*itv) * 24 * time.Hour)
-			threshold = threshold.Add(time.Hour * 6)
-
-		case "month":
-			threshold = tDB[i].LastEntry.AddDate(0, int(*itv), 0)
-			threshold = threshold.Add(time.Hour * 12)
-
-		case "year":
-			threshold = tDB[i].LastEntry.AddDate(int(*itv), 0, 0)
-			threshold = threshold.Add(time.Hour * 24)
-		}
+		threshold := NextDue(*tDB[i].LastEntry, tDB[i].Interval, tDB[i].IntervalUnit).Add(dueGrace[tDB[i].IntervalUnit])
 
 		if time.Now().After(threshold) {
 			n := "due"
@@ -241,7 +341,7 @@ func GetDueTrackerID(trackers []LatestEntry) ([]uuid.UUID, error) {
 	return due, nil
 }
 
-func MuteTracker(db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID, isMuted bool) error {
+func MuteTracker(ctx context.Context, db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID, isMuted bool) error {
 	var q string
 
 	if isMuted {
@@ -253,7 +353,7 @@ func MuteTracker(db *sqlx.DB, trackerID uuid.UUID, userID uuid.UUID, isMuted boo
 		q = `DELETE FROM tracker_user_settings WHERE tracker_id = $1 AND user_id = $2`
 	}
 
-	if _, err := db.Exec(q, trackerID, userID); err != nil {
+	if _, err := db.ExecContext(ctx, q, trackerID, userID); err != nil {
 		return fmt.Errorf("mute tracker exec: %w", err)
 	}
 
@@ -0,0 +1,48 @@
+package tracker_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/testdb"
+	"github.com/zachczx/cubby/api/internal/tracker"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestGetAllScopedToFamilies(t *testing.T) {
+	db := testdb.New(t)
+
+	owner, member, outsider := testdb.User(t, db), testdb.User(t, db), testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+	testdb.AddMember(t, db, familyID, member)
+	trackerID := testdb.Tracker(t, db, owner, familyID)
+	testdb.Tracker(t, db, outsider, testdb.Family(t, db, outsider))
+
+	for _, tt := range []struct {
+		name    string
+		userID  uuid.UUID
+		visible bool
+	}{
+		{"owner", owner, true},
+		{"member", member, true},
+		{"outsider", outsider, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tracker.GetAll(t.Context(), db, tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != 1 {
+				t.Fatalf("got %d trackers, want 1", len(got))
+			}
+
+			if visible := got[0].ID == trackerID; visible != tt.visible {
+				t.Fatalf("family tracker visible = %v, want %v", visible, tt.visible)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+)
+
+// AccountDeletionGrace is how long a deletion request can still be cancelled before the account is purged.
+const AccountDeletionGrace = 30 * 24 * time.Hour
+
+var ErrNoAccountDeletion = apperr.NotFound("no account deletion scheduled")
+
+// AccountDeletionRequest says what happens to the families the user owns. Families listed in Transfers go to the
+// given member; any other owned family is deleted along with its trackers.
+type AccountDeletionRequest struct {
+	Transfers map[uuid.UUID]uuid.UUID `json:"transfers"`
+}
+
+type AccountDeletion struct {
+	UserID       uuid.UUID               `json:"-" db:"user_id"`
+	ScheduledFor time.Time               `json:"scheduledFor" db:"scheduled_for"`
+	Transfers    map[uuid.UUID]uuid.UUID `json:"transfers" db:"-"`
+	CreatedAt    time.Time               `json:"createdAt" db:"created_at"`
+}
+
+// ScheduleAccountDeletion records the request and its family plan. The identityID is the auth provider's user ID,
+// kept so the provider account can be removed at purge time.
+func ScheduleAccountDeletion(ctx context.Context, db *sqlx.DB, userID uuid.UUID, identityID string, transfers map[uuid.UUID]uuid.UUID) (AccountDeletion, error) {
+	if transfers == nil {
+		transfers = map[uuid.UUID]uuid.UUID{}
+	}
+
+	for familyID, newOwnerID := range transfers {
+		isOwner, err := IsFamilyOwner(ctx, db, userID, familyID)
+		if err != nil {
+			return AccountDeletion{}, err
+		}
+
+		if !isOwner {
+			return AccountDeletion{}, ErrNotFamilyOwner
+		}
+
+		if newOwnerID == userID {
+			return AccountDeletion{}, ErrTransferToSelf
+		}
+
+		isMember, err := IsFamilyMember(ctx, db, newOwnerID, familyID)
+		if err != nil {
+			return AccountDeletion{}, err
+		}
+
+		if !isMember {
+			return AccountDeletion{}, ErrNewOwnerNotMember
+		}
+	}
+
+	plan, err := json.Marshal(transfers)
+	if err != nil {
+		return AccountDeletion{}, fmt.Errorf("schedule account deletion marshal: %w", err)
+	}
+
+	d := AccountDeletion{UserID: userID, Transfers: transfers}
+
+	q := `INSERT INTO account_deletions (user_id, identity_id, transfers, scheduled_for)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO UPDATE
+			SET identity_id = EXCLUDED.identity_id, transfers = EXCLUDED.transfers
+			RETURNING scheduled_for, created_at`
+
+	if err := db.QueryRowContext(ctx, q, userID, identityID, plan, time.Now().Add(AccountDeletionGrace)).Scan(&d.ScheduledFor, &d.CreatedAt); err != nil {
+		return AccountDeletion{}, fmt.Errorf("schedule account deletion: %w", err)
+	}
+
+	return d, nil
+}
+
+func GetAccountDeletion(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (AccountDeletion, error) {
+	var d AccountDeletion
+	var plan []byte
+
+	q := `SELECT user_id, scheduled_for, transfers, created_at FROM account_deletions WHERE user_id = $1`
+
+	if err := db.QueryRowContext(ctx, q, userID).Scan(&d.UserID, &d.ScheduledFor, &plan, &d.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return d, ErrNoAccountDeletion
+		}
+
+		return d, fmt.Errorf("get account deletion: %w", err)
+	}
+
+	if err := json.Unmarshal(plan, &d.Transfers); err != nil {
+		return d, fmt.Errorf("get account deletion unmarshal: %w", err)
+	}
+
+	return d, nil
+}
+
+func CancelAccountDeletion(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("cancel account deletion: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cancel account deletion rows: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoAccountDeletion
+	}
+
+	return nil
+}
+
+// StartAccountPurge deletes accounts whose grace period has run out. deleteIdentity removes the matching account at
+// the auth provider and is called after the local data is gone.
+func StartAccountPurge(ctx context.Context, db *sqlx.DB, deleteIdentity func(ctx context.Context, identityID string) error) error {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("shutting down account purge worker...")
+			return nil
+
+		case <-ticker.C:
+			if err := PurgeDueAccounts(ctx, db, deleteIdentity); err != nil {
+				log.Println("account purge worker error:", err)
+			}
+		}
+	}
+}
+
+func PurgeDueAccounts(ctx context.Context, db *sqlx.DB, deleteIdentity func(ctx context.Context, identityID string) error) error {
+	type due struct {
+		UserID     uuid.UUID `db:"user_id"`
+		IdentityID string    `db:"identity_id"`
+	}
+
+	var accounts []due
+
+	q := `SELECT user_id, identity_id FROM account_deletions WHERE scheduled_for <= NOW()`
+
+	if err := db.SelectContext(ctx, &accounts, q); err != nil {
+		return fmt.Errorf("purge due accounts: %w", err)
+	}
+
+	for _, a := range accounts {
+		if err := purgeAccount(ctx, db, a.UserID); err != nil {
+			log.Println("account purge failed:", a.UserID, err)
+			continue
+		}
+
+		if a.IdentityID != "" {
+			if err := deleteIdentity(ctx, a.IdentityID); err != nil {
+				log.Println("account purge identity delete failed:", a.UserID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// purgeAccount hands over or deletes the user's families, leaves the trackers they created in other people's
+// families with those families, and then deletes the user. Gym data, timer profiles and push tokens cascade with the
+// user row; entries, comments, activity and logged prices keep their content with the author cleared.
+func purgeAccount(ctx context.Context, db *sqlx.DB, userID uuid.UUID) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("purge account begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var plan []byte
+
+	if err := tx.GetContext(ctx, &plan, `SELECT transfers FROM account_deletions WHERE user_id = $1 FOR UPDATE`, userID); err != nil {
+		return fmt.Errorf("purge account get plan: %w", err)
+	}
+
+	transfers := map[uuid.UUID]uuid.UUID{}
+	if err := json.Unmarshal(plan, &transfers); err != nil {
+		return fmt.Errorf("purge account unmarshal plan: %w", err)
+	}
+
+	var owned []uuid.UUID
+
+	if err := tx.SelectContext(ctx, &owned, `SELECT id FROM families WHERE owner_id = $1`, userID); err != nil {
+		return fmt.Errorf("purge account owned families: %w", err)
+	}
+
+	for _, familyID := range owned {
+		newOwnerID, ok := transfers[familyID]
+		if !ok {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM families WHERE id = $1`, familyID); err != nil {
+				return fmt.Errorf("purge account delete family: %w", err)
+			}
+
+			continue
+		}
+
+		// The chosen member may have left during the grace period; the longest-standing member takes over instead.
+		q := `SELECT user_id FROM families_users
+				WHERE family_id = $1
+				ORDER BY (user_id = $2) DESC, created_at ASC
+				LIMIT 1`
+
+		if err := tx.GetContext(ctx, &newOwnerID, q, familyID, newOwnerID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("purge account pick owner: %w", err)
+			}
+
+			if _, err := tx.ExecContext(ctx, `DELETE FROM families WHERE id = $1`, familyID); err != nil {
+				return fmt.Errorf("purge account delete family: %w", err)
+			}
+
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM families_users WHERE family_id = $1 AND user_id = $2`, familyID, newOwnerID); err != nil {
+			return fmt.Errorf("purge account remove new owner: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE families SET owner_id = $1, updated_at = NOW() WHERE id = $2`, newOwnerID, familyID); err != nil {
+			return fmt.Errorf("purge account transfer family: %w", err)
+		}
+
+		if err := reassignTrackers(ctx, tx, familyID, userID, newOwnerID); err != nil {
+			return err
+		}
+	}
+
+	rQ := `UPDATE trackers t SET owner_id = f.owner_id, updated_at = NOW()
+			FROM families f
+			WHERE t.family_id = f.id AND t.owner_id = $1 AND f.owner_id <> $1`
+
+	if _, err := tx.ExecContext(ctx, rQ, userID); err != nil {
+		return fmt.Errorf("purge account reassign trackers: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("purge account delete user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("purge account commit tx: %w", err)
+	}
+
+	return nil
+}
@@ -1,13 +1,16 @@
 package user
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
 type Family struct {
@@ -18,17 +21,27 @@ type Family struct {
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 }
 
+var (
+	ErrNotFamilyMember = apperr.Forbidden("not a member of this family")
+	ErrNotFamilyOwner  = apperr.Forbidden("only the family owner can do this")
+)
+
+type FamilyRequest struct {
+	Name string `json:"name"`
+}
+
 type FamilyResponse struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
 	IsOwner   bool      `json:"isOwner" db:"-"`
+	IsActive  bool      `json:"isActive" db:"-"`
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 	Owner     User      `json:"owner" db:"owner"`
 	Members   []User    `json:"members" db:"members"`
 }
 
-func NewFamily(db *sqlx.DB, f Family) (uuid.UUID, error) {
+func NewFamily(ctx context.Context, db *sqlx.DB, f Family) (uuid.UUID, error) {
 	var createdID uuid.UUID
 
 	newFamily := Family{
@@ -38,7 +51,7 @@ func NewFamily(db *sqlx.DB, f Family) (uuid.UUID, error) {
 
 	query := `INSERT INTO families (name, owner_id) VALUES (:name, :owner_id) RETURNING id`
 
-	rows, err := db.NamedQuery(query, newFamily)
+	rows, err := db.NamedQueryContext(ctx, query, newFamily)
 	if err != nil {
 		return createdID, err
 	}
@@ -53,12 +66,13 @@ func NewFamily(db *sqlx.DB, f Family) (uuid.UUID, error) {
 	return createdID, nil
 }
 
-func GetUserFamilyID(db *sqlx.DB, userID uuid.UUID) (uuid.UUID, error) {
+// GetUserFamilyID returns the user's home family: the first one they own, created at sign-up.
+func GetUserFamilyID(ctx context.Context, db *sqlx.DB, userID uuid.UUID) (uuid.UUID, error) {
 	var familyID uuid.UUID
 
-	q := `SELECT id FROM families WHERE owner_id=$1`
+	q := `SELECT id FROM families WHERE owner_id=$1 ORDER BY created_at ASC LIMIT 1`
 
-	if err := db.QueryRow(q, userID).Scan(&familyID); err != nil {
+	if err := db.QueryRowContext(ctx, q, userID).Scan(&familyID); err != nil {
 		if err == sql.ErrNoRows {
 			return familyID, fmt.Errorf("no family: %w", err)
 		}
@@ -69,7 +83,115 @@ func GetUserFamilyID(db *sqlx.DB, userID uuid.UUID) (uuid.UUID, error) {
 	return familyID, nil
 }
 
-func GetUsersFamilies(db *sqlx.DB, userID uuid.UUID) ([]FamilyResponse, error) {
+// ResolveFamilyID picks the family a new record belongs to: the requested one if the user is in it,
+// otherwise their active family, falling back to their home family.
+func ResolveFamilyID(ctx context.Context, db *sqlx.DB, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error) {
+	if requested != nil && *requested != uuid.Nil {
+		isMember, err := IsFamilyMember(ctx, db, userID, *requested)
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		if !isMember {
+			return uuid.Nil, ErrNotFamilyMember
+		}
+
+		return *requested, nil
+	}
+
+	// The active family is ignored once the user has left it.
+	var active uuid.UUID
+
+	q := `SELECT u.active_family_id FROM users u
+			WHERE u.id = $1
+			AND u.active_family_id IS NOT NULL
+			AND (
+				EXISTS(SELECT 1 FROM families f WHERE f.id = u.active_family_id AND f.owner_id = u.id)
+				OR EXISTS(SELECT 1 FROM families_users fu WHERE fu.family_id = u.active_family_id AND fu.user_id = u.id)
+			)`
+
+	err := db.GetContext(ctx, &active, q, userID)
+	if err == nil {
+		return active, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("get active family: %w", err)
+	}
+
+	return GetUserFamilyID(ctx, db, userID)
+}
+
+// ResolveOwnedFamilyID is ResolveFamilyID for owner-only actions such as inviting: the requested family
+// must be owned by the user, and without one the home family is used.
+func ResolveOwnedFamilyID(ctx context.Context, db *sqlx.DB, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error) {
+	if requested == nil || *requested == uuid.Nil {
+		return GetUserFamilyID(ctx, db, userID)
+	}
+
+	isOwner, err := IsFamilyOwner(ctx, db, userID, *requested)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if !isOwner {
+		return uuid.Nil, ErrNotFamilyOwner
+	}
+
+	return *requested, nil
+}
+
+// SetActiveFamily changes the default family for new records. A nil familyID clears it.
+func SetActiveFamily(ctx context.Context, db *sqlx.DB, userID uuid.UUID, familyID *uuid.UUID) error {
+	if familyID != nil {
+		isMember, err := IsFamilyMember(ctx, db, userID, *familyID)
+		if err != nil {
+			return err
+		}
+
+		if !isMember {
+			return ErrNotFamilyMember
+		}
+	}
+
+	q := `UPDATE users SET active_family_id = $1, updated_at = NOW() WHERE id = $2`
+
+	if _, err := db.ExecContext(ctx, q, familyID, userID); err != nil {
+		return fmt.Errorf("set active family: %w", err)
+	}
+
+	return nil
+}
+
+// IsFamilyMember reports whether the user owns or belongs to the family.
+func IsFamilyMember(ctx context.Context, db *sqlx.DB, userID uuid.UUID, familyID uuid.UUID) (bool, error) {
+	var isMember bool
+
+	q := `SELECT EXISTS(
+				SELECT 1 FROM families WHERE id = $1 AND owner_id = $2
+				UNION
+				SELECT 1 FROM families_users WHERE family_id = $1 AND user_id = $2
+			)`
+
+	if err := db.GetContext(ctx, &isMember, q, familyID, userID); err != nil {
+		return false, fmt.Errorf("check family member: %w", err)
+	}
+
+	return isMember, nil
+}
+
+func IsFamilyOwner(ctx context.Context, db *sqlx.DB, userID uuid.UUID, familyID uuid.UUID) (bool, error) {
+	var isOwner bool
+
+	q := `SELECT EXISTS(SELECT 1 FROM families WHERE id = $1 AND owner_id = $2)`
+
+	if err := db.GetContext(ctx, &isOwner, q, familyID, userID); err != nil {
+		return false, fmt.Errorf("check family owner: %w", err)
+	}
+
+	return isOwner, nil
+}
+
+func GetUsersFamilies(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]FamilyResponse, error) {
 	var families []FamilyResponse
 
 	q := `SELECT 
@@ -89,7 +211,7 @@ func GetUsersFamilies(db *sqlx.DB, userID uuid.UUID) ([]FamilyResponse, error) {
 				WHERE fu_check.family_id = f.id AND fu_check.user_id = $1
 			)`
 
-	fRows, err := db.Query(q, userID)
+	fRows, err := db.QueryContext(ctx, q, userID)
 	if err != nil {
 		return nil, fmt.Errorf("fetch families err: %w", err)
 	}
@@ -133,7 +255,7 @@ func GetUsersFamilies(db *sqlx.DB, userID uuid.UUID) ([]FamilyResponse, error) {
 		return nil, fmt.Errorf("fetch members In: %w", err)
 	}
 	query = db.Rebind(query)
-	mRows, err := db.Query(query, args...)
+	mRows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("fetch members: %w", err)
 	}
@@ -152,12 +274,19 @@ func GetUsersFamilies(db *sqlx.DB, userID uuid.UUID) ([]FamilyResponse, error) {
 		membersByFamily[familyID] = append(membersByFamily[familyID], user)
 	}
 
+	activeID, err := ResolveFamilyID(ctx, db, userID, nil)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
 	for i := range families {
 		families[i].Members = membersByFamily[families[i].ID]
 
 		if families[i].Members == nil {
 			families[i].Members = []User{}
 		}
+
+		families[i].IsActive = families[i].ID == activeID
 	}
 
 	return families, nil
@@ -186,35 +315,81 @@ func ToPtr[T any](v T) *T {
 	return &v
 }
 
-func DeleteMember(db *sqlx.DB, familyID uuid.UUID, ownerID uuid.UUID, memberID uuid.UUID) error {
+// DeleteMember removes a member from a family the caller owns. Trackers the member created there stay with the
+// family under the owner.
+func DeleteMember(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, ownerID uuid.UUID, memberID uuid.UUID) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("delete member begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
 	q := `DELETE FROM families_users
 			WHERE family_id IN (SELECT id FROM families WHERE owner_id = $1)
 			AND family_id = $2
 			AND user_id = $3`
 
-	if _, err := db.Exec(q, ownerID, familyID, memberID); err != nil {
+	res, err := tx.ExecContext(ctx, q, ownerID, familyID, memberID)
+	if err != nil {
 		return fmt.Errorf("delete member err: %w", err)
 	}
 
+	if err := apperr.Affected(res, "family member"); err != nil {
+		return err
+	}
+
+	if err := reassignTrackers(ctx, tx, familyID, memberID, ownerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete member commit tx: %w", err)
+	}
+
 	return nil
 }
 
-func LeaveFamily(db *sqlx.DB, familyID uuid.UUID, memberID uuid.UUID) error {
-	q := `DELETE FROM families_users WHERE family_id = $1 AND user_id = $2`
+// LeaveFamily removes the member and hands the trackers they created in the family to its owner. The owner has to
+// transfer the family first.
+func LeaveFamily(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, memberID uuid.UUID) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("leave family begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
 
-	if _, err := db.Exec(q, familyID, memberID); err != nil {
+	var ownerID uuid.UUID
+
+	if err := tx.GetContext(ctx, &ownerID, `SELECT owner_id FROM families WHERE id = $1`, familyID); err != nil {
+		return fmt.Errorf("leave family get owner: %w", err)
+	}
+
+	if ownerID == memberID {
+		return ErrOwnerCannotLeaveFamily
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM families_users WHERE family_id = $1 AND user_id = $2`, familyID, memberID); err != nil {
 		return fmt.Errorf("leave family: %w", err)
 	}
 
+	if err := reassignTrackers(ctx, tx, familyID, memberID, ownerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("leave family commit tx: %w", err)
+	}
+
 	return nil
 }
 
-func UpdateFamilyName(db *sqlx.DB, familyID uuid.UUID, name string) error {
+func UpdateFamilyName(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, name string) error {
 	q := `UPDATE families SET name = $1 WHERE id = $2`
 
-	if _, err := db.Exec(q, name, familyID); err != nil {
+	res, err := db.ExecContext(ctx, q, name, familyID)
+	if err != nil {
 		return fmt.Errorf("update family name err: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "family")
 }
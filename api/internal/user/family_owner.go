@@ -0,0 +1,228 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/secret"
+)
+
+const FamilyDeletionTTL = 10 * time.Minute
+
+var (
+	ErrNewOwnerNotMember      = errors.New("new owner must be a member of the family")
+	ErrInvalidDeletionToken   = errors.New("confirmation token is invalid or has expired")
+	ErrTransferToSelf         = errors.New("already the owner of this family")
+	ErrOwnerCannotLeaveFamily = apperr.Conflict("transfer ownership before leaving the family")
+)
+
+type TransferRequest struct {
+	NewOwnerID uuid.UUID `json:"newOwnerId"`
+	// Leave drops the previous owner from the family instead of keeping them as a member.
+	Leave bool `json:"leave"`
+}
+
+type DeleteFamilyRequest struct {
+	ConfirmationToken string `json:"confirmationToken"`
+}
+
+// FamilyDeletion is returned once when deletion is requested; the token has to be sent back to confirm.
+type FamilyDeletion struct {
+	FamilyID  uuid.UUID `json:"familyId"`
+	Token     string    `json:"confirmationToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Trackers  int       `json:"trackers"`
+	Members   int       `json:"members"`
+}
+
+// TransferFamily hands the family to an existing member. The previous owner stays on as a member unless leave is set,
+// in which case the trackers they created in the family move to the new owner so nothing is lost with them. An owner
+// giving up their only family gets a new empty one, since their defaults fall back to a family they own.
+func TransferFamily(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, familyID uuid.UUID, newOwnerID uuid.UUID, leave bool) error {
+	if newOwnerID == ownerID {
+		return ErrTransferToSelf
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transfer family begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var currentOwner uuid.UUID
+
+	if err := tx.GetContext(ctx, &currentOwner, `SELECT owner_id FROM families WHERE id = $1 FOR UPDATE`, familyID); err != nil {
+		return fmt.Errorf("transfer family get: %w", err)
+	}
+
+	if currentOwner != ownerID {
+		return ErrNotFamilyOwner
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM families_users WHERE family_id = $1 AND user_id = $2`, familyID, newOwnerID)
+	if err != nil {
+		return fmt.Errorf("transfer family remove member: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("transfer family remove member: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNewOwnerNotMember
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE families SET owner_id = $1, updated_at = NOW() WHERE id = $2`, newOwnerID, familyID); err != nil {
+		return fmt.Errorf("transfer family set owner: %w", err)
+	}
+
+	if leave {
+		if err := reassignTrackers(ctx, tx, familyID, ownerID, newOwnerID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET active_family_id = NULL WHERE id = $1 AND active_family_id = $2`, ownerID, familyID); err != nil {
+			return fmt.Errorf("transfer family clear active family: %w", err)
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO families_users (family_id, user_id) VALUES ($1, $2)`, familyID, ownerID); err != nil {
+			return fmt.Errorf("transfer family add previous owner: %w", err)
+		}
+	}
+
+	if err := ensureHomeFamily(ctx, tx, ownerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transfer family commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// reassignTrackers gives the trackers a departing user created in the family to whoever is staying behind.
+func reassignTrackers(ctx context.Context, tx *sqlx.Tx, familyID uuid.UUID, fromID uuid.UUID, toID uuid.UUID) error {
+	q := `UPDATE trackers SET owner_id = $1, updated_at = NOW() WHERE family_id = $2 AND owner_id = $3`
+
+	if _, err := tx.ExecContext(ctx, q, toID, familyID, fromID); err != nil {
+		return fmt.Errorf("reassign trackers: %w", err)
+	}
+
+	return nil
+}
+
+// RequestFamilyDeletion issues the token DeleteFamily needs, replacing any earlier one. The counts tell the owner what
+// will be removed.
+func RequestFamilyDeletion(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, familyID uuid.UUID) (FamilyDeletion, error) {
+	if err := checkDeletableFamily(ctx, db, ownerID, familyID); err != nil {
+		return FamilyDeletion{}, err
+	}
+
+	token, err := secret.New()
+	if err != nil {
+		return FamilyDeletion{}, fmt.Errorf("family deletion: %w", err)
+	}
+
+	d := FamilyDeletion{FamilyID: familyID, Token: token, ExpiresAt: time.Now().Add(FamilyDeletionTTL)}
+
+	q := `INSERT INTO family_deletions (family_id, requested_by, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (family_id) DO UPDATE
+			SET requested_by = EXCLUDED.requested_by, token_hash = EXCLUDED.token_hash,
+				expires_at = EXCLUDED.expires_at, created_at = NOW()`
+
+	if _, err := db.ExecContext(ctx, q, familyID, ownerID, secret.Hash(token), d.ExpiresAt); err != nil {
+		return FamilyDeletion{}, fmt.Errorf("request family deletion: %w", err)
+	}
+
+	cQ := `SELECT
+				(SELECT COUNT(*) FROM trackers WHERE family_id = $1),
+				(SELECT COUNT(*) FROM families_users WHERE family_id = $1)`
+
+	if err := db.QueryRowContext(ctx, cQ, familyID).Scan(&d.Trackers, &d.Members); err != nil {
+		return FamilyDeletion{}, fmt.Errorf("request family deletion count: %w", err)
+	}
+
+	return d, nil
+}
+
+// DeleteFamily removes the family and everything in it once the owner confirms with a token from RequestFamilyDeletion.
+func DeleteFamily(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, familyID uuid.UUID, token string) error {
+	if err := checkDeletableFamily(ctx, db, ownerID, familyID); err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("delete family begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var expiresAt time.Time
+
+	tQ := `DELETE FROM family_deletions
+			WHERE family_id = $1 AND requested_by = $2 AND token_hash = $3
+			RETURNING expires_at`
+
+	if err := tx.GetContext(ctx, &expiresAt, tQ, familyID, ownerID, secret.Hash(token)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidDeletionToken
+		}
+
+		return fmt.Errorf("delete family check token: %w", err)
+	}
+
+	if time.Now().After(expiresAt) {
+		return ErrInvalidDeletionToken
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM families WHERE id = $1 AND owner_id = $2`, familyID, ownerID); err != nil {
+		return fmt.Errorf("delete family: %w", err)
+	}
+
+	if err := ensureHomeFamily(ctx, tx, ownerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete family commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// checkDeletableFamily requires ownership; DeleteFamily replaces the owner's last family itself.
+func checkDeletableFamily(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, familyID uuid.UUID) error {
+	isOwner, err := IsFamilyOwner(ctx, db, ownerID, familyID)
+	if err != nil {
+		return err
+	}
+
+	if !isOwner {
+		return ErrNotFamilyOwner
+	}
+
+	return nil
+}
+
+// ensureHomeFamily gives a user who no longer owns any family a fresh one, like the family created at sign-up, so
+// GetUserFamilyID keeps working for them.
+func ensureHomeFamily(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error {
+	q := `INSERT INTO families (name, owner_id)
+			SELECT 'Family', $1
+			WHERE NOT EXISTS (SELECT 1 FROM families WHERE owner_id = $1)`
+
+	if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+		return fmt.Errorf("ensure home family: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package user_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/testdb"
+	"github.com/zachczx/cubby/api/internal/tracker"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestFamilyMembership(t *testing.T) {
+	db := testdb.New(t)
+
+	owner, member, outsider := testdb.User(t, db), testdb.User(t, db), testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+	testdb.AddMember(t, db, familyID, member)
+
+	for _, tt := range []struct {
+		name     string
+		userID   uuid.UUID
+		isMember bool
+		isOwner  bool
+	}{
+		{"owner", owner, true, true},
+		{"member", member, true, false},
+		{"outsider", outsider, false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			isMember, err := user.IsFamilyMember(t.Context(), db, tt.userID, familyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isMember != tt.isMember {
+				t.Errorf("IsFamilyMember = %v, want %v", isMember, tt.isMember)
+			}
+
+			isOwner, err := user.IsFamilyOwner(t.Context(), db, tt.userID, familyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isOwner != tt.isOwner {
+				t.Errorf("IsFamilyOwner = %v, want %v", isOwner, tt.isOwner)
+			}
+
+			_, err = user.ResolveFamilyID(t.Context(), db, tt.userID, &familyID)
+			if tt.isMember && err != nil {
+				t.Errorf("ResolveFamilyID: %v", err)
+			}
+			if !tt.isMember && !errors.Is(err, user.ErrNotFamilyMember) {
+				t.Errorf("ResolveFamilyID: err = %v, want ErrNotFamilyMember", err)
+			}
+		})
+	}
+}
+
+func TestGivingUpOnlyFamilyCreatesNewHome(t *testing.T) {
+	db := testdb.New(t)
+
+	t.Run("transfer", func(t *testing.T) {
+		owner, member := testdb.User(t, db), testdb.User(t, db)
+		familyID := testdb.Family(t, db, owner)
+		testdb.AddMember(t, db, familyID, member)
+
+		if err := user.TransferFamily(t.Context(), db, owner, familyID, member, true); err != nil {
+			t.Fatal(err)
+		}
+
+		assertNewHome(t, db, owner, familyID)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		owner := testdb.User(t, db)
+		familyID := testdb.Family(t, db, owner)
+
+		d, err := user.RequestFamilyDeletion(t.Context(), db, owner, familyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := user.DeleteFamily(t.Context(), db, owner, familyID, d.Token); err != nil {
+			t.Fatal(err)
+		}
+
+		assertNewHome(t, db, owner, familyID)
+	})
+}
+
+func TestTransferKeepsFamilyTrackersVisible(t *testing.T) {
+	db := testdb.New(t)
+
+	owner, member := testdb.User(t, db), testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+	testdb.AddMember(t, db, familyID, member)
+	ownersTracker := testdb.Tracker(t, db, owner, familyID)
+
+	if err := user.TransferFamily(t.Context(), db, owner, familyID, member, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new owner's families_users row is gone, so only the owner half of the visibility check finds the family.
+	for _, who := range []uuid.UUID{member, owner} {
+		trackers, err := tracker.GetAll(t.Context(), db, who)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.ContainsFunc(trackers, func(tr tracker.Tracker) bool { return tr.ID == ownersTracker }) {
+			t.Errorf("user %s lost the previous owner's tracker after the transfer", who)
+		}
+	}
+}
+
+func assertNewHome(t *testing.T, db *sqlx.DB, userID uuid.UUID, oldFamilyID uuid.UUID) {
+	t.Helper()
+
+	home, err := user.GetUserFamilyID(t.Context(), db, userID)
+	if err != nil {
+		t.Fatalf("home family: %v", err)
+	}
+
+	if home == oldFamilyID {
+		t.Fatal("home family is still the one given up")
+	}
+}
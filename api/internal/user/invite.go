@@ -1,21 +1,29 @@
 package user
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
+// Invite is addressed to an existing user, or only to an email address until someone signs up with it.
 type Invite struct {
-	ID        uuid.UUID    `json:"id" db:"id"`
-	FamilyID  uuid.UUID    `json:"familyId" db:"family_id"`
-	InviteeID uuid.UUID    `json:"inviteeId" db:"invitee_id"`
-	Status    InviteStatus `json:"status" db:"status"`
-	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time    `json:"updatedAt" db:"updated_at"`
+	ID           uuid.UUID    `json:"id" db:"id"`
+	FamilyID     uuid.UUID    `json:"familyId" db:"family_id"`
+	InviteeID    *uuid.UUID   `json:"inviteeId" db:"invitee_id"`
+	InviteeEmail *string      `json:"inviteeEmail" db:"invitee_email"`
+	InvitedBy    *uuid.UUID   `json:"invitedBy" db:"invited_by"`
+	Status       InviteStatus `json:"status" db:"status"`
+	ExpiresAt    time.Time    `json:"expiresAt" db:"expires_at"`
+	CreatedAt    time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time    `json:"updatedAt" db:"updated_at"`
 
 	FamilyName string `json:"familyName" db:"family_name"`
 }
@@ -37,89 +45,251 @@ const (
 	StatusPending  InviteStatus = "pending"
 	StatusAccepted InviteStatus = "accepted"
 	StatusDeclined InviteStatus = "declined"
+	// StatusExpired is reported for pending invites past expires_at, and stored once a re-invite replaces them.
+	StatusExpired InviteStatus = "expired"
 )
 
 func (s InviteStatus) IsValid() bool {
 	switch s {
-	case StatusPending, StatusAccepted, StatusDeclined:
+	case StatusPending, StatusAccepted, StatusDeclined, StatusExpired:
 		return true
 	}
 	return false
 }
 
-func CreateFamilyInvite(db *sqlx.DB, familyID uuid.UUID, inviteeEmail string) error {
-	var inviteeID uuid.UUID
+const InviteTTL = 14 * 24 * time.Hour
 
-	sQ := `SELECT id FROM users WHERE email = $1`
+var (
+	ErrInviteExpired    = errors.New("invite has expired")
+	ErrInviteNotPending = apperr.Conflict("invite is no longer pending")
+	ErrAlreadyInvited   = apperr.Conflict("invite already sent to this user")
+)
+
+// inviteColumns reads an invite with pending-but-lapsed rows reported as expired. Queries using it join
+// families and the invitee's user row as "invitee".
+const inviteColumns = `i.id, i.family_id, i.invitee_id, COALESCE(invitee.email, i.invitee_email) AS invitee_email,
+			i.invited_by, i.expires_at, i.created_at, i.updated_at,
+			CASE WHEN i.status = 'pending' AND i.expires_at <= NOW() THEN 'expired' ELSE i.status END AS status,
+			families.name AS family_name`
+
+// CreateFamilyInvite invites an email address to the family. If nobody has signed up with it yet the invite
+// waits for them and is claimed by claimEmailInvites on first sign-in. Earlier declined or expired invites
+// don't block a new one, but the owner and existing members can't be invited.
+func CreateFamilyInvite(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, invitedBy uuid.UUID, inviteeEmail string) (Invite, error) {
+	var invite Invite
+
+	email := strings.ToLower(strings.TrimSpace(inviteeEmail))
+
+	var inviteeID *uuid.UUID
+
+	sQ := `SELECT id FROM users WHERE LOWER(email) = $1`
+
+	var id uuid.UUID
+	if err := db.QueryRowContext(ctx, sQ, email).Scan(&id); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return invite, fmt.Errorf("invite get userID: %w", err)
+		}
+	} else {
+		inviteeID = &id
+	}
+
+	if inviteeID != nil {
+		isMember, err := IsFamilyMember(ctx, db, *inviteeID, familyID)
+		if err != nil {
+			return invite, err
+		}
 
-	if err := db.QueryRow(sQ, inviteeEmail).Scan(&inviteeID); err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("invite no such user: %w", err)
+		if isMember {
+			return invite, ErrAlreadyInFamily
 		}
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return invite, fmt.Errorf("create invite begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
 
-		return fmt.Errorf("invite get userID: %w", err)
+	// Lapsed invites still hold the pending slot in the unique index, so retire them first.
+	eQ := `UPDATE invites SET status = $1, updated_at = NOW()
+			WHERE family_id = $2 AND status = $3 AND expires_at <= NOW()
+			AND (invitee_id = $4 OR LOWER(invitee_email) = $5)`
+
+	if _, err := tx.ExecContext(ctx, eQ, StatusExpired, familyID, StatusPending, inviteeID, email); err != nil {
+		return invite, fmt.Errorf("invite expire stale: %w", err)
 	}
 
 	var hasPendingInvite bool
 
-	cQ := `SELECT EXISTS(SELECT 1 FROM invites WHERE invitee_id= $1 AND family_id = $2 AND status = $3)`
+	cQ := `SELECT EXISTS(
+				SELECT 1 FROM invites
+				WHERE family_id = $1 AND status = $2
+				AND (invitee_id = $3 OR LOWER(invitee_email) = $4)
+			)`
 
-	if err := db.QueryRow(cQ, inviteeID, familyID, StatusPending).Scan(&hasPendingInvite); err != nil {
-		return fmt.Errorf("invite check already invited: %w", err)
+	if err := tx.QueryRowContext(ctx, cQ, familyID, StatusPending, inviteeID, email).Scan(&hasPendingInvite); err != nil {
+		return invite, fmt.Errorf("invite check already invited: %w", err)
 	}
 
 	if hasPendingInvite {
-		return fmt.Errorf("invite already sent to this user")
+		return invite, ErrAlreadyInvited
+	}
+
+	iQ := `INSERT INTO invites (family_id, invitee_id, invitee_email, invited_by, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, family_id, invitee_id, invitee_email, invited_by, status, expires_at, created_at, updated_at`
+
+	if err := tx.GetContext(ctx, &invite, iQ, familyID, inviteeID, email, invitedBy, time.Now().Add(InviteTTL)); err != nil {
+		return invite, fmt.Errorf("create invite: %w", err)
 	}
 
-	iQ := `INSERT INTO invites (family_id, invitee_id)
-			VALUES ($1, $2)`
+	if err := tx.Commit(); err != nil {
+		return invite, fmt.Errorf("create invite commit tx: %w", err)
+	}
+
+	return invite, nil
+}
+
+// claimEmailInvites attaches invites sent to an email before the account existed, so they show up as normal pending invites.
+func claimEmailInvites(ctx context.Context, db *sqlx.DB, userID uuid.UUID, email string) error {
+	q := `UPDATE invites SET invitee_id = $1, updated_at = NOW()
+			WHERE invitee_id IS NULL
+			AND LOWER(invitee_email) = LOWER($2)
+			AND status = $3
+			AND expires_at > NOW()`
 
-	if _, err := db.Exec(iQ, familyID, inviteeID); err != nil {
-		return fmt.Errorf("create invite: %w", err)
+	if _, err := db.ExecContext(ctx, q, userID, email, StatusPending); err != nil {
+		return fmt.Errorf("claim email invites: %w", err)
 	}
 
 	return nil
 }
 
-func GetFamilyInvites(db *sqlx.DB, userID uuid.UUID) ([]Invite, error) {
+func GetFamilyInvites(ctx context.Context, db *sqlx.DB, userID uuid.UUID) ([]Invite, error) {
 	var invites []Invite
 
-	q := `SELECT i.id, i.family_id, i.invitee_id, i.status, i.created_at, i.updated_at, families.name AS family_name 
+	q := `SELECT ` + inviteColumns + `
 			FROM invites i
 			LEFT JOIN families ON i.family_id = families.id
-			WHERE invitee_id = $1 AND status = $2`
+			LEFT JOIN users invitee ON i.invitee_id = invitee.id
+			WHERE i.invitee_id = $1 AND i.status = $2 AND i.expires_at > NOW()`
 
-	if err := db.Select(&invites, q, userID, StatusPending); err != nil {
+	if err := db.SelectContext(ctx, &invites, q, userID, StatusPending); err != nil {
 		return invites, fmt.Errorf("get invite: %w", err)
 	}
 
 	return invites, nil
 }
 
-func GetFamilyInvite(db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
+// GetSentInvites lists every invite the family has sent, newest first, for the owner to review.
+func GetSentInvites(ctx context.Context, db *sqlx.DB, familyID uuid.UUID) ([]Invite, error) {
+	invites := []Invite{}
+
+	q := `SELECT ` + inviteColumns + `
+			FROM invites i
+			LEFT JOIN families ON i.family_id = families.id
+			LEFT JOIN users invitee ON i.invitee_id = invitee.id
+			WHERE i.family_id = $1
+			ORDER BY i.created_at DESC`
+
+	if err := db.SelectContext(ctx, &invites, q, familyID); err != nil {
+		return nil, fmt.Errorf("get sent invites: %w", err)
+	}
+
+	return invites, nil
+}
+
+// GetOwnedInvite fetches an invite sent by a family the user owns.
+func GetOwnedInvite(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
+	var invite Invite
+
+	q := `SELECT ` + inviteColumns + `
+			FROM invites i
+			JOIN families ON i.family_id = families.id
+			LEFT JOIN users invitee ON i.invitee_id = invitee.id
+			WHERE i.id = $1 AND families.owner_id = $2`
+
+	if err := db.GetContext(ctx, &invite, q, inviteID, ownerID); err != nil {
+		return invite, fmt.Errorf("get owned invite: %w", err)
+	}
+
+	return invite, nil
+}
+
+// RevokeInvite withdraws an invite that hasn't been answered yet.
+func RevokeInvite(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, inviteID uuid.UUID) error {
+	q := `DELETE FROM invites
+			USING families
+			WHERE invites.id = $1
+			AND invites.family_id = families.id
+			AND families.owner_id = $2
+			AND invites.status = $3`
+
+	res, err := db.ExecContext(ctx, q, inviteID, ownerID, StatusPending)
+	if err != nil {
+		return fmt.Errorf("revoke invite: %w", err)
+	}
+
+	return apperr.Affected(res, "invite")
+}
+
+// ResendInvite restarts the expiry window of a pending or lapsed invite. An email-only invite is attached to the
+// account if the invitee signed up in the meantime, since claimEmailInvites skipped it while it was expired.
+func ResendInvite(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
+	current, err := GetOwnedInvite(ctx, db, ownerID, inviteID)
+	if err != nil {
+		return current, err
+	}
+
+	if current.Status != StatusPending && current.Status != StatusExpired {
+		return current, ErrInviteNotPending
+	}
+
+	q := `UPDATE invites SET status = $1, expires_at = $2, updated_at = NOW(),
+				invitee_id = COALESCE(invitee_id, (
+					SELECT id FROM users WHERE LOWER(email) = LOWER(invites.invitee_email)
+				))
+			WHERE id = $3`
+
+	if _, err := db.ExecContext(ctx, q, StatusPending, time.Now().Add(InviteTTL), inviteID); err != nil {
+		return current, fmt.Errorf("resend invite: %w", err)
+	}
+
+	return GetOwnedInvite(ctx, db, ownerID, inviteID)
+}
+
+func GetFamilyInvite(ctx context.Context, db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
 	var invite Invite
 
-	q := `SELECT i.id, i.family_id, i.invitee_id, i.status, i.created_at, i.updated_at, families.name AS family_name 
+	q := `SELECT ` + inviteColumns + `
 			FROM invites i
 			LEFT JOIN families ON i.family_id = families.id
+			LEFT JOIN users invitee ON i.invitee_id = invitee.id
 			WHERE i.id = $1 AND i.invitee_id = $2`
 
-	if err := db.Get(&invite, q, inviteID, userID); err != nil {
+	if err := db.GetContext(ctx, &invite, q, inviteID, userID); err != nil {
 		return invite, fmt.Errorf("get invite: %w", err)
 	}
 
 	return invite, nil
 }
 
-func AcceptFamilyInvite(db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) error {
+func AcceptFamilyInvite(ctx context.Context, db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) error {
 	// Get family ID
-	currentInvite, err := GetFamilyInvite(db, userID, inviteID)
+	currentInvite, err := GetFamilyInvite(ctx, db, userID, inviteID)
 	if err != nil {
 		return fmt.Errorf("get family invite: %w", err)
 	}
 
-	tx, err := db.Beginx()
+	switch currentInvite.Status {
+	case StatusPending:
+	case StatusExpired:
+		return ErrInviteExpired
+	default:
+		return ErrInviteNotPending
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("accept family begin tx: %w", err)
 	}
@@ -127,14 +297,14 @@ func AcceptFamilyInvite(db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) error
 
 	insertQ := `INSERT INTO families_users (family_id, user_id) VALUES ($1, $2)`
 
-	if _, err := tx.Exec(insertQ, currentInvite.FamilyID, userID); err != nil {
+	if _, err := tx.ExecContext(ctx, insertQ, currentInvite.FamilyID, userID); err != nil {
 		return fmt.Errorf("insert families_users: %w", err)
 	}
 
 	// Modify invite to mark completed
-	updateQ := `UPDATE invites SET status = $1 WHERE invitee_id = $2 AND id = $3`
+	updateQ := `UPDATE invites SET status = $1, updated_at = NOW() WHERE invitee_id = $2 AND id = $3`
 
-	if _, err := tx.Exec(updateQ, StatusAccepted, userID, inviteID); err != nil {
+	if _, err := tx.ExecContext(ctx, updateQ, StatusAccepted, userID, inviteID); err != nil {
 		return fmt.Errorf("update invites: %w", err)
 	}
 
@@ -145,12 +315,13 @@ func AcceptFamilyInvite(db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) error
 	return nil
 }
 
-func DeclineFamilyInvite(db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) error {
-	q := `UPDATE invites SET status = $1 WHERE invitee_id = $2 AND id = $3`
+func DeclineFamilyInvite(ctx context.Context, db *sqlx.DB, userID uuid.UUID, inviteID uuid.UUID) error {
+	q := `UPDATE invites SET status = $1, updated_at = NOW() WHERE invitee_id = $2 AND id = $3 AND status = $4`
 
-	if _, err := db.Exec(q, StatusDeclined, userID, inviteID); err != nil {
+	res, err := db.ExecContext(ctx, q, StatusDeclined, userID, inviteID, StatusPending)
+	if err != nil {
 		return fmt.Errorf("update invites: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "invite")
 }
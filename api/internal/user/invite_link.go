@@ -0,0 +1,179 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/secret"
+)
+
+const (
+	DefaultInviteLinkTTL = 7 * 24 * time.Hour
+	MaxInviteLinkTTL     = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInviteLinkExpired = errors.New("invite link has expired")
+	ErrAlreadyInFamily   = apperr.Conflict("already a member of this family")
+)
+
+// InviteLink lets anyone holding the token join the family until it expires. Only the token's hash is stored.
+type InviteLink struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	FamilyID   uuid.UUID  `json:"familyId" db:"family_id"`
+	CreatedBy  *uuid.UUID `json:"createdBy" db:"created_by"`
+	Uses       int        `json:"uses" db:"uses"`
+	ExpiresAt  time.Time  `json:"expiresAt" db:"expires_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	FamilyName string     `json:"familyName" db:"family_name"`
+}
+
+// NewInviteLink is returned once on creation; the token can't be recovered afterwards.
+type NewInviteLink struct {
+	InviteLink
+	Token string `json:"token"`
+}
+
+type InviteLinkRequest struct {
+	FamilyID       *uuid.UUID `json:"familyId"`
+	ExpiresInHours int        `json:"expiresInHours"`
+}
+
+func CreateInviteLink(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, userID uuid.UUID, ttl time.Duration) (NewInviteLink, error) {
+	token, err := secret.New()
+	if err != nil {
+		return NewInviteLink{}, fmt.Errorf("invite link: %w", err)
+	}
+
+	q := `INSERT INTO invite_links (family_id, created_by, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, family_id, created_by, uses, expires_at, created_at,
+				(SELECT name FROM families WHERE id = $1) AS family_name`
+
+	link := NewInviteLink{Token: token}
+	if err := db.GetContext(ctx, &link.InviteLink, q, familyID, userID, secret.Hash(token), time.Now().Add(ttl)); err != nil {
+		return NewInviteLink{}, fmt.Errorf("create invite link: %w", err)
+	}
+
+	return link, nil
+}
+
+// GetInviteLinks lists the family's links that can still be used.
+func GetInviteLinks(ctx context.Context, db *sqlx.DB, familyID uuid.UUID) ([]InviteLink, error) {
+	q := `SELECT l.id, l.family_id, l.created_by, l.uses, l.expires_at, l.created_at, f.name AS family_name
+			FROM invite_links l
+			JOIN families f ON l.family_id = f.id
+			WHERE l.family_id = $1 AND l.expires_at > NOW()
+			ORDER BY l.created_at DESC`
+
+	links := []InviteLink{}
+	if err := db.SelectContext(ctx, &links, q, familyID); err != nil {
+		return nil, fmt.Errorf("get invite links: %w", err)
+	}
+
+	return links, nil
+}
+
+func DeleteInviteLink(ctx context.Context, db *sqlx.DB, ownerID uuid.UUID, linkID uuid.UUID) error {
+	q := `DELETE FROM invite_links
+			USING families
+			WHERE invite_links.id = $1
+			AND invite_links.family_id = families.id
+			AND families.owner_id = $2`
+
+	res, err := db.ExecContext(ctx, q, linkID, ownerID)
+	if err != nil {
+		return fmt.Errorf("delete invite link: %w", err)
+	}
+
+	return apperr.Affected(res, "invite link")
+}
+
+// GetInviteLinkByToken is the preview shown before joining.
+func GetInviteLinkByToken(ctx context.Context, db *sqlx.DB, token string) (InviteLink, error) {
+	var link InviteLink
+
+	q := `SELECT l.id, l.family_id, l.created_by, l.uses, l.expires_at, l.created_at, f.name AS family_name
+			FROM invite_links l
+			JOIN families f ON l.family_id = f.id
+			WHERE l.token_hash = $1`
+
+	if err := db.GetContext(ctx, &link, q, secret.Hash(token)); err != nil {
+		return link, fmt.Errorf("get invite link: %w", err)
+	}
+
+	if time.Now().After(link.ExpiresAt) {
+		return link, ErrInviteLinkExpired
+	}
+
+	return link, nil
+}
+
+// AcceptInviteLink adds the user to the link's family and settles any pending invite they had for it.
+func AcceptInviteLink(ctx context.Context, db *sqlx.DB, userID uuid.UUID, token string) (InviteLink, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return InviteLink{}, fmt.Errorf("accept invite link begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var link InviteLink
+
+	q := `SELECT l.id, l.family_id, l.created_by, l.uses, l.expires_at, l.created_at, f.name AS family_name
+			FROM invite_links l
+			JOIN families f ON l.family_id = f.id
+			WHERE l.token_hash = $1
+			FOR UPDATE OF l`
+
+	if err := tx.GetContext(ctx, &link, q, secret.Hash(token)); err != nil {
+		return link, fmt.Errorf("accept invite link get: %w", err)
+	}
+
+	if time.Now().After(link.ExpiresAt) {
+		return link, ErrInviteLinkExpired
+	}
+
+	var isMember bool
+
+	mQ := `SELECT EXISTS(
+				SELECT 1 FROM families WHERE id = $1 AND owner_id = $2
+				UNION
+				SELECT 1 FROM families_users WHERE family_id = $1 AND user_id = $2
+			)`
+
+	if err := tx.GetContext(ctx, &isMember, mQ, link.FamilyID, userID); err != nil {
+		return link, fmt.Errorf("accept invite link check member: %w", err)
+	}
+
+	if isMember {
+		return link, ErrAlreadyInFamily
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO families_users (family_id, user_id) VALUES ($1, $2)`, link.FamilyID, userID); err != nil {
+		return link, fmt.Errorf("accept invite link insert families_users: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE invite_links SET uses = uses + 1 WHERE id = $1`, link.ID); err != nil {
+		return link, fmt.Errorf("accept invite link count use: %w", err)
+	}
+
+	iQ := `UPDATE invites SET status = $1, updated_at = NOW()
+			WHERE family_id = $2 AND invitee_id = $3 AND status = $4`
+
+	if _, err := tx.ExecContext(ctx, iQ, StatusAccepted, link.FamilyID, userID, StatusPending); err != nil {
+		return link, fmt.Errorf("accept invite link settle invites: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return link, fmt.Errorf("accept invite link commit tx: %w", err)
+	}
+
+	link.Uses++
+
+	return link, nil
+}
@@ -0,0 +1,93 @@
+package user_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/testdb"
+	"github.com/zachczx/cubby/api/internal/user"
+)
+
+func TestResendInviteClaimsLateSignup(t *testing.T) {
+	db := testdb.New(t)
+
+	owner := testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+	email := uuid.NewString() + "@example.com"
+
+	invite, err := user.CreateFamilyInvite(t.Context(), db, familyID, owner, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The invitee signs up while the invite has lapsed, so sign-up doesn't claim it.
+	if _, err := db.Exec(`UPDATE invites SET expires_at = NOW() - INTERVAL '1 day' WHERE id = $1`, invite.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var inviteeID uuid.UUID
+	if err := db.Get(&inviteeID, `INSERT INTO users (email) VALUES (UPPER($1)) RETURNING id`, email); err != nil {
+		t.Fatal(err)
+	}
+
+	resent, err := user.ResendInvite(t.Context(), db, owner, invite.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resent.InviteeID == nil || *resent.InviteeID != inviteeID {
+		t.Fatalf("invitee = %v, want %v", resent.InviteeID, inviteeID)
+	}
+
+	pending, err := user.GetFamilyInvites(t.Context(), db, inviteeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 1 || pending[0].ID != invite.ID {
+		t.Fatalf("pending invites = %+v, want the resent one", pending)
+	}
+}
+
+func TestCreateFamilyInviteConflicts(t *testing.T) {
+	db := testdb.New(t)
+
+	owner, member := testdb.User(t, db), testdb.User(t, db)
+	familyID := testdb.Family(t, db, owner)
+	testdb.AddMember(t, db, familyID, member)
+	invited := uuid.NewString() + "@example.com"
+
+	emailOf := func(id uuid.UUID) string {
+		var email string
+		if err := db.GetContext(t.Context(), &email, `SELECT email FROM users WHERE id = $1`, id); err != nil {
+			t.Fatal(err)
+		}
+		return strings.ToUpper(email)
+	}
+
+	if _, err := user.CreateFamilyInvite(t.Context(), db, familyID, owner, invited); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		email string
+		want  error
+	}{
+		{"pending invite", invited, user.ErrAlreadyInvited},
+		{"owner", emailOf(owner), user.ErrAlreadyInFamily},
+		{"member", emailOf(member), user.ErrAlreadyInFamily},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := user.CreateFamilyInvite(t.Context(), db, familyID, owner, tt.email)
+			if !errors.Is(err, tt.want) || !errors.Is(err, apperr.ErrConflict) {
+				t.Fatalf("err = %v, want %v as a conflict", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository wraps the user, family and invite queries the HTTP handlers make.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) AcceptFamilyInvite(ctx context.Context, userID uuid.UUID, inviteID uuid.UUID) error {
+	return AcceptFamilyInvite(ctx, r.db, userID, inviteID)
+}
+
+func (r *Repository) AcceptInviteLink(ctx context.Context, userID uuid.UUID, token string) (InviteLink, error) {
+	return AcceptInviteLink(ctx, r.db, userID, token)
+}
+
+func (r *Repository) CancelAccountDeletion(ctx context.Context, userID uuid.UUID) error {
+	return CancelAccountDeletion(ctx, r.db, userID)
+}
+
+func (r *Repository) ChangePreferredCharacter(ctx context.Context, userID uuid.UUID, char string) error {
+	return ChangePreferredCharacter(ctx, r.db, userID, char)
+}
+
+func (r *Repository) ChangeTaskLookaheadDays(ctx context.Context, userID uuid.UUID, days int) error {
+	return ChangeTaskLookaheadDays(ctx, r.db, userID, days)
+}
+
+func (r *Repository) CreateFamilyInvite(ctx context.Context, familyID uuid.UUID, invitedBy uuid.UUID, inviteeEmail string) (Invite, error) {
+	return CreateFamilyInvite(ctx, r.db, familyID, invitedBy, inviteeEmail)
+}
+
+func (r *Repository) CreateInviteLink(ctx context.Context, familyID uuid.UUID, userID uuid.UUID, ttl time.Duration) (NewInviteLink, error) {
+	return CreateInviteLink(ctx, r.db, familyID, userID, ttl)
+}
+
+func (r *Repository) CreateVacation(ctx context.Context, userID uuid.UUID, familyID uuid.UUID, v VacationRequest) error {
+	return CreateVacation(ctx, r.db, userID, familyID, v)
+}
+
+func (r *Repository) DeclineFamilyInvite(ctx context.Context, userID uuid.UUID, inviteID uuid.UUID) error {
+	return DeclineFamilyInvite(ctx, r.db, userID, inviteID)
+}
+
+func (r *Repository) DeleteFamily(ctx context.Context, ownerID uuid.UUID, familyID uuid.UUID, token string) error {
+	return DeleteFamily(ctx, r.db, ownerID, familyID, token)
+}
+
+func (r *Repository) DeleteInviteLink(ctx context.Context, ownerID uuid.UUID, linkID uuid.UUID) error {
+	return DeleteInviteLink(ctx, r.db, ownerID, linkID)
+}
+
+func (r *Repository) DeleteMember(ctx context.Context, familyID uuid.UUID, ownerID uuid.UUID, memberID uuid.UUID) error {
+	return DeleteMember(ctx, r.db, familyID, ownerID, memberID)
+}
+
+func (r *Repository) DeleteVacation(ctx context.Context, userID uuid.UUID, vacationID uuid.UUID) error {
+	return DeleteVacation(ctx, r.db, userID, vacationID)
+}
+
+func (r *Repository) GetAccountDeletion(ctx context.Context, userID uuid.UUID) (AccountDeletion, error) {
+	return GetAccountDeletion(ctx, r.db, userID)
+}
+
+func (r *Repository) GetFamilyInvite(ctx context.Context, userID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
+	return GetFamilyInvite(ctx, r.db, userID, inviteID)
+}
+
+func (r *Repository) GetFamilyInvites(ctx context.Context, userID uuid.UUID) ([]Invite, error) {
+	return GetFamilyInvites(ctx, r.db, userID)
+}
+
+func (r *Repository) GetInviteLinkByToken(ctx context.Context, token string) (InviteLink, error) {
+	return GetInviteLinkByToken(ctx, r.db, token)
+}
+
+func (r *Repository) GetInviteLinks(ctx context.Context, familyID uuid.UUID) ([]InviteLink, error) {
+	return GetInviteLinks(ctx, r.db, familyID)
+}
+
+func (r *Repository) GetOwnedInvite(ctx context.Context, ownerID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
+	return GetOwnedInvite(ctx, r.db, ownerID, inviteID)
+}
+
+func (r *Repository) GetOwnedVacation(ctx context.Context, userID uuid.UUID, vacationID uuid.UUID) (Vacation, error) {
+	return GetOwnedVacation(ctx, r.db, userID, vacationID)
+}
+
+func (r *Repository) GetSentInvites(ctx context.Context, familyID uuid.UUID) ([]Invite, error) {
+	return GetSentInvites(ctx, r.db, familyID)
+}
+
+func (r *Repository) GetUserFamilyID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	return GetUserFamilyID(ctx, r.db, userID)
+}
+
+func (r *Repository) GetUsersFamilies(ctx context.Context, userID uuid.UUID) ([]FamilyResponse, error) {
+	return GetUsersFamilies(ctx, r.db, userID)
+}
+
+func (r *Repository) GetVacations(ctx context.Context, families []FamilyResponse) ([]Vacation, error) {
+	return GetVacations(ctx, r.db, families)
+}
+
+func (r *Repository) IsFamilyMember(ctx context.Context, userID uuid.UUID, familyID uuid.UUID) (bool, error) {
+	return IsFamilyMember(ctx, r.db, userID, familyID)
+}
+
+func (r *Repository) IsFamilyOwner(ctx context.Context, userID uuid.UUID, familyID uuid.UUID) (bool, error) {
+	return IsFamilyOwner(ctx, r.db, userID, familyID)
+}
+
+func (r *Repository) LeaveFamily(ctx context.Context, familyID uuid.UUID, memberID uuid.UUID) error {
+	return LeaveFamily(ctx, r.db, familyID, memberID)
+}
+
+func (r *Repository) NewFamily(ctx context.Context, f Family) (uuid.UUID, error) {
+	return NewFamily(ctx, r.db, f)
+}
+
+func (r *Repository) RequestFamilyDeletion(ctx context.Context, ownerID uuid.UUID, familyID uuid.UUID) (FamilyDeletion, error) {
+	return RequestFamilyDeletion(ctx, r.db, ownerID, familyID)
+}
+
+func (r *Repository) ResendInvite(ctx context.Context, ownerID uuid.UUID, inviteID uuid.UUID) (Invite, error) {
+	return ResendInvite(ctx, r.db, ownerID, inviteID)
+}
+
+func (r *Repository) ResolveFamilyID(ctx context.Context, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error) {
+	return ResolveFamilyID(ctx, r.db, userID, requested)
+}
+
+func (r *Repository) ResolveOwnedFamilyID(ctx context.Context, userID uuid.UUID, requested *uuid.UUID) (uuid.UUID, error) {
+	return ResolveOwnedFamilyID(ctx, r.db, userID, requested)
+}
+
+func (r *Repository) RevokeInvite(ctx context.Context, ownerID uuid.UUID, inviteID uuid.UUID) error {
+	return RevokeInvite(ctx, r.db, ownerID, inviteID)
+}
+
+func (r *Repository) ScheduleAccountDeletion(ctx context.Context, userID uuid.UUID, identityID string, transfers map[uuid.UUID]uuid.UUID) (AccountDeletion, error) {
+	return ScheduleAccountDeletion(ctx, r.db, userID, identityID, transfers)
+}
+
+func (r *Repository) SetActiveFamily(ctx context.Context, userID uuid.UUID, familyID *uuid.UUID) error {
+	return SetActiveFamily(ctx, r.db, userID, familyID)
+}
+
+func (r *Repository) TransferFamily(ctx context.Context, ownerID uuid.UUID, familyID uuid.UUID, newOwnerID uuid.UUID, leave bool) error {
+	return TransferFamily(ctx, r.db, ownerID, familyID, newOwnerID, leave)
+}
+
+func (r *Repository) UpdateFamilyName(ctx context.Context, familyID uuid.UUID, name string) error {
+	return UpdateFamilyName(ctx, r.db, familyID, name)
+}
+
+func (r *Repository) UpdateName(ctx context.Context, userID uuid.UUID, name string) error {
+	return UpdateName(ctx, r.db, userID, name)
+}
+
+func (r *Repository) UpdateSoundMode(ctx context.Context, userID uuid.UUID, modeQuick, modeProfile string) error {
+	return UpdateSoundMode(ctx, r.db, userID, modeQuick, modeProfile)
+}
@@ -1,34 +1,37 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
 type User struct {
-	ID                 uuid.UUID `db:"id"         json:"id"`
-	Email              string    `db:"email"      json:"email"`
-	Name               *string   `db:"name"       json:"name"`
-	SoundModeQuick     string    `db:"sound_mode_quick"   json:"soundModeQuick"`
-	SoundModeProfile   string    `db:"sound_mode_profile" json:"soundModeProfile"`
-	TaskLookAheadDays  int       `db:"task_lookahead_days" json:"taskLookaheadDays"`
-	PreferredCharacter string    `db:"preferred_character" json:"preferredCharacter"`
-	CreatedAt          time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt          time.Time `db:"updated_at" json:"updatedAt"`
+	ID                 uuid.UUID  `db:"id"         json:"id"`
+	Email              string     `db:"email"      json:"email"`
+	Name               *string    `db:"name"       json:"name"`
+	SoundModeQuick     string     `db:"sound_mode_quick"   json:"soundModeQuick"`
+	SoundModeProfile   string     `db:"sound_mode_profile" json:"soundModeProfile"`
+	TaskLookAheadDays  int        `db:"task_lookahead_days" json:"taskLookaheadDays"`
+	PreferredCharacter string     `db:"preferred_character" json:"preferredCharacter"`
+	ActiveFamilyID     *uuid.UUID `db:"active_family_id" json:"activeFamilyId"`
+	CreatedAt          time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt          time.Time  `db:"updated_at" json:"updatedAt"`
 }
 
 type UserManager struct{}
 
-func (UserManager) SyncUserInternal(db *sqlx.DB, email string, createdAt time.Time) (bool, uuid.UUID, error) {
+func (UserManager) SyncUserInternal(ctx context.Context, db *sqlx.DB, email string, createdAt time.Time) (bool, uuid.UUID, error) {
 	var userID uuid.UUID
 
 	q := `SELECT id FROM users WHERE email=$1`
 
-	err := db.QueryRow(q, email).Scan(&userID)
+	err := db.QueryRowContext(ctx, q, email).Scan(&userID)
 	if err == nil {
 		return false, userID, nil
 	}
@@ -38,24 +41,28 @@ func (UserManager) SyncUserInternal(db *sqlx.DB, email string, createdAt time.Ti
 
 	qy := `INSERT INTO users (email, created_at) VALUES ($1, $2) RETURNING id`
 
-	if err := db.QueryRow(qy, email, createdAt).Scan(&userID); err != nil {
+	if err := db.QueryRowContext(ctx, qy, email, createdAt).Scan(&userID); err != nil {
 		return false, userID, fmt.Errorf("insert user: %w", err)
 	}
 
 	f := Family{Name: "Family", OwnerID: userID}
-	if _, err := NewFamily(db, f); err != nil {
+	if _, err := NewFamily(ctx, db, f); err != nil {
 		return true, userID, fmt.Errorf("create family: %w", err)
 	}
 
+	if err := claimEmailInvites(ctx, db, userID, email); err != nil {
+		return true, userID, err
+	}
+
 	return true, userID, nil
 }
 
-func (UserManager) GetInternalUserID(db *sqlx.DB, email string) (uuid.UUID, error) {
+func (UserManager) GetInternalUserID(ctx context.Context, db *sqlx.DB, email string) (uuid.UUID, error) {
 	var userID uuid.UUID
 
 	q := `SELECT id FROM users WHERE email=$1`
 
-	if err := db.QueryRow(q, email).Scan(&userID); err != nil {
+	if err := db.QueryRowContext(ctx, q, email).Scan(&userID); err != nil {
 		if err == sql.ErrNoRows {
 			return userID, fmt.Errorf("user not found: %w", err)
 		}
@@ -65,54 +72,58 @@ func (UserManager) GetInternalUserID(db *sqlx.DB, email string) (uuid.UUID, erro
 	return userID, nil
 }
 
-func (UserManager) Get(db *sqlx.DB, email string) (User, error) {
+func (UserManager) Get(ctx context.Context, db *sqlx.DB, email string) (User, error) {
 	var user User
 
 	q := `SELECT * FROM users WHERE email=$1`
 
-	if err := db.Get(&user, q, email); err != nil {
+	if err := db.GetContext(ctx, &user, q, email); err != nil {
 		return User{}, fmt.Errorf("error fetch user: %w", err)
 	}
 
 	return user, nil
 }
 
-func UpdateSoundMode(db *sqlx.DB, userID uuid.UUID, modeQuick, modeProfile string) error {
+func UpdateSoundMode(ctx context.Context, db *sqlx.DB, userID uuid.UUID, modeQuick, modeProfile string) error {
 	q := `UPDATE users SET sound_mode_quick = $1, sound_mode_profile = $2 WHERE id = $3`
 
-	if _, err := db.Exec(q, modeQuick, modeProfile, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, modeQuick, modeProfile, userID)
+	if err != nil {
 		return fmt.Errorf("update sound mode err: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "user")
 }
 
-func ChangeTaskLookaheadDays(db *sqlx.DB, userID uuid.UUID, days int) error {
+func ChangeTaskLookaheadDays(ctx context.Context, db *sqlx.DB, userID uuid.UUID, days int) error {
 	q := `UPDATE users SET task_lookahead_days = $1 WHERE id = $2`
 
-	if _, err := db.Exec(q, days, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, days, userID)
+	if err != nil {
 		return fmt.Errorf("change taskLookaheadDays err: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "user")
 }
 
-func ChangePreferredCharacter(db *sqlx.DB, userID uuid.UUID, char string) error {
+func ChangePreferredCharacter(ctx context.Context, db *sqlx.DB, userID uuid.UUID, char string) error {
 	q := `UPDATE users SET preferred_character = $1 WHERE id = $2`
 
-	if _, err := db.Exec(q, char, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, char, userID)
+	if err != nil {
 		return fmt.Errorf("err ChangePreferredCharacter: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "user")
 }
 
-func UpdateName(db *sqlx.DB, userID uuid.UUID, name string) error {
+func UpdateName(ctx context.Context, db *sqlx.DB, userID uuid.UUID, name string) error {
 	q := `UPDATE users SET name = $1 WHERE id = $2`
 
-	if _, err := db.Exec(q, name, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, name, userID)
+	if err != nil {
 		return fmt.Errorf("update user name err: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "user")
 }
@@ -1,11 +1,13 @@
 package user
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/apperr"
 )
 
 type Vacation struct {
@@ -20,23 +22,24 @@ type Vacation struct {
 }
 
 type VacationRequest struct {
-	StartDateTime time.Time `json:"startDateTime"`
-	EndDateTime   time.Time `json:"endDateTime"`
-	Label         *string   `json:"label"`
+	StartDateTime time.Time  `json:"startDateTime"`
+	EndDateTime   time.Time  `json:"endDateTime"`
+	Label         *string    `json:"label"`
+	FamilyID      *uuid.UUID `json:"familyId"`
 }
 
-func CreateVacation(db *sqlx.DB, userID uuid.UUID, familyID uuid.UUID, v VacationRequest) error {
+func CreateVacation(ctx context.Context, db *sqlx.DB, userID uuid.UUID, familyID uuid.UUID, v VacationRequest) error {
 	q := `INSERT INTO vacations (family_id, created_by, start_date_time, end_date_time, label) 
 			VALUES ($1, $2, $3, $4, $5)`
 
-	if _, err := db.Exec(q, familyID, userID, v.StartDateTime, v.EndDateTime, v.Label); err != nil {
+	if _, err := db.ExecContext(ctx, q, familyID, userID, v.StartDateTime, v.EndDateTime, v.Label); err != nil {
 		return fmt.Errorf("create vacation: %w", err)
 	}
 
 	return nil
 }
 
-func GetVacations(db *sqlx.DB, families []FamilyResponse) ([]Vacation, error) {
+func GetVacations(ctx context.Context, db *sqlx.DB, families []FamilyResponse) ([]Vacation, error) {
 	var familyIDs []uuid.UUID
 
 	for _, f := range families {
@@ -52,23 +55,39 @@ func GetVacations(db *sqlx.DB, families []FamilyResponse) ([]Vacation, error) {
 	}
 
 	query = db.Rebind(query)
-	if err = db.Select(&vacations, query, args...); err != nil {
+	if err = db.SelectContext(ctx, &vacations, query, args...); err != nil {
 		return nil, fmt.Errorf("select vacations: %w", err)
 	}
 
 	return vacations, nil
 }
 
-func DeleteVacation(db *sqlx.DB, userID uuid.UUID, vacationID uuid.UUID) error {
+// GetOwnedVacation returns a vacation the user may delete: one they created, or any in a family they own.
+func GetOwnedVacation(ctx context.Context, db *sqlx.DB, userID uuid.UUID, vacationID uuid.UUID) (Vacation, error) {
+	var v Vacation
+
+	q := `SELECT v.* FROM vacations v
+			JOIN families f ON v.family_id = f.id
+			WHERE v.id = $1 AND (f.owner_id = $2 OR v.created_by = $2)`
+
+	if err := db.GetContext(ctx, &v, q, vacationID, userID); err != nil {
+		return v, fmt.Errorf("get vacation: %w", err)
+	}
+
+	return v, nil
+}
+
+func DeleteVacation(ctx context.Context, db *sqlx.DB, userID uuid.UUID, vacationID uuid.UUID) error {
 	q := `DELETE FROM vacations
 			USING families 
 			WHERE vacations.id = $1
 			AND vacations.family_id = families.id
-			AND families.owner_id = $2`
+			AND (families.owner_id = $2 OR vacations.created_by = $2)`
 
-	if _, err := db.Exec(q, vacationID, userID); err != nil {
+	res, err := db.ExecContext(ctx, q, vacationID, userID)
+	if err != nil {
 		return fmt.Errorf("delete entry: %w", err)
 	}
 
-	return nil
+	return apperr.Affected(res, "vacation")
 }
@@ -0,0 +1,357 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/zachczx/cubby/api/internal/event"
+	"github.com/zachczx/cubby/api/internal/logging"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+
+	SignatureHeader = "X-Cubby-Signature"
+	EventHeader     = "X-Cubby-Event"
+	DeliveryHeader  = "X-Cubby-Delivery"
+
+	dispatchInterval = 10 * time.Second
+	dispatchBatch    = 20
+	// A claimed delivery is pushed this far into the future so another worker won't pick it up mid-request.
+	claimLease       = 2 * time.Minute
+	requestTimeout   = 10 * time.Second
+	deliveryRetained = 30 * 24 * time.Hour
+	maxErrorLength   = 500
+
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// retryBackoff is the wait after each failed attempt. A delivery that fails once more after the last step is
+// marked failed, roughly ten hours after the event.
+var retryBackoff = []time.Duration{
+	30 * time.Second,
+	2 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	1 * time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+}
+
+var errBlockedAddress = errors.New("webhook address is not public")
+
+type Delivery struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	WebhookID      uuid.UUID  `json:"webhookId" db:"webhook_id"`
+	EventID        uuid.UUID  `json:"eventId" db:"event_id"`
+	EventType      string     `json:"eventType" db:"event_type"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastStatusCode *int       `json:"lastStatusCode" db:"last_status_code"`
+	LastError      *string    `json:"lastError" db:"last_error"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+	DeliveredAt    *time.Time `json:"deliveredAt" db:"delivered_at"`
+}
+
+type DeliveryPage struct {
+	Items      []Delivery `json:"items"`
+	NextCursor *uuid.UUID `json:"nextCursor"`
+}
+
+// pending is a claimed delivery with what's needed to send it.
+type pending struct {
+	ID        uuid.UUID       `db:"id"`
+	EventType string          `db:"event_type"`
+	Payload   json.RawMessage `db:"payload"`
+	Attempts  int             `db:"attempts"`
+	URL       string          `db:"url"`
+	Secret    string          `db:"secret"`
+}
+
+// Subscriber queues a delivery for every active webhook in the event's family that listens for its type.
+func Subscriber(db *sqlx.DB) event.Handler {
+	return func(ctx context.Context, e event.Event) {
+		if err := Enqueue(ctx, db, e); err != nil {
+			logging.Error(ctx, "failed to queue webhook deliveries", "type", e.Type, "error", err)
+		}
+	}
+}
+
+func Enqueue(ctx context.Context, db *sqlx.DB, e event.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	q := `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+			SELECT id, $2, $3, $4 FROM webhooks
+			WHERE family_id = $1 AND active AND $3 = ANY(events)`
+
+	if _, err := db.ExecContext(ctx, q, e.FamilyID, e.ID, string(e.Type), payload); err != nil {
+		return fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns a webhook's delivery log newest first, paged by the previous page's last ID.
+func ListDeliveries(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, webhookID uuid.UUID, before *uuid.UUID, limit int) (DeliveryPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	var exists bool
+	if err := db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM webhooks WHERE id = $1 AND family_id = $2)`, webhookID, familyID); err != nil {
+		return DeliveryPage{}, fmt.Errorf("check webhook: %w", err)
+	}
+
+	if !exists {
+		return DeliveryPage{}, ErrWebhookNotFound
+	}
+
+	q := `SELECT id, webhook_id, event_id, event_type, status, attempts, next_attempt_at, last_status_code, last_error,
+				created_at, delivered_at
+			FROM webhook_deliveries
+			WHERE webhook_id = $1
+			AND ($2::uuid IS NULL OR id < $2)
+			ORDER BY id DESC
+			LIMIT $3`
+
+	items := []Delivery{}
+	if err := db.SelectContext(ctx, &items, q, webhookID, before, limit+1); err != nil {
+		return DeliveryPage{}, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	page := DeliveryPage{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+		next := page.Items[limit-1].ID
+		page.NextCursor = &next
+	}
+
+	return page, nil
+}
+
+// Sign returns the signature header value for body: a unix timestamp and an HMAC-SHA256 of "timestamp.body".
+// Receivers recompute it with their secret and should reject old timestamps to stop replays.
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func StartDispatcher(ctx context.Context, db *sqlx.DB, allowPrivate bool) error {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(time.Hour)
+	defer pruneTicker.Stop()
+
+	client := newClient(allowPrivate)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("shutting down webhook dispatcher...")
+			return nil
+
+		case <-ticker.C:
+			if err := Dispatch(ctx, db, client); err != nil {
+				log.Println("webhook dispatcher error:", err)
+			}
+
+		case <-pruneTicker.C:
+			if err := pruneDeliveries(ctx, db); err != nil {
+				log.Println("webhook prune error:", err)
+			}
+		}
+	}
+}
+
+// Dispatch sends one batch of due deliveries. Rows are claimed with SKIP LOCKED and a lease, so several API
+// instances can run the dispatcher without sending the same delivery twice.
+func Dispatch(ctx context.Context, db *sqlx.DB, client *http.Client) error {
+	q := `WITH due AS (
+				SELECT d.id FROM webhook_deliveries d
+				JOIN webhooks w ON d.webhook_id = w.id
+				WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+				ORDER BY d.next_attempt_at
+				LIMIT $1
+				FOR UPDATE OF d SKIP LOCKED
+			)
+			UPDATE webhook_deliveries d
+			SET attempts = d.attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+			FROM due, webhooks w
+			WHERE d.id = due.id AND w.id = d.webhook_id
+			RETURNING d.id, d.event_type, d.payload, d.attempts, w.url, w.secret`
+
+	var batch []pending
+	if err := db.SelectContext(ctx, &batch, q, dispatchBatch, claimLease.Seconds()); err != nil {
+		return fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	for _, p := range batch {
+		code, err := send(ctx, client, p)
+		if err := recordAttempt(ctx, db, p, code, err); err != nil {
+			log.Println("webhook delivery record error:", err)
+		}
+	}
+
+	return nil
+}
+
+func send(ctx context.Context, client *http.Client, p pending) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Cubby-Webhooks/1.0")
+	req.Header.Set(EventHeader, p.EventType)
+	req.Header.Set(DeliveryHeader, p.ID.String())
+	req.Header.Set(SignatureHeader, Sign(p.Secret, time.Now(), p.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a little so the connection can be reused, but don't let a receiver stream at us.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func recordAttempt(ctx context.Context, db *sqlx.DB, p pending, code int, sendErr error) error {
+	var statusCode *int
+	if code != 0 {
+		statusCode = &code
+	}
+
+	if sendErr == nil {
+		q := `UPDATE webhook_deliveries
+				SET status = 'delivered', delivered_at = NOW(), next_attempt_at = NULL, last_status_code = $2, last_error = NULL
+				WHERE id = $1`
+
+		if _, err := db.ExecContext(ctx, q, p.ID, statusCode); err != nil {
+			return fmt.Errorf("mark webhook delivered: %w", err)
+		}
+
+		return nil
+	}
+
+	msg := sendErr.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+
+	status, next := retryAfter(p.Attempts, time.Now())
+
+	q := `UPDATE webhook_deliveries
+			SET status = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5
+			WHERE id = $1`
+
+	if _, err := db.ExecContext(ctx, q, p.ID, status, next, statusCode, msg); err != nil {
+		return fmt.Errorf("record webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// retryAfter schedules the next try after the given number of failed attempts, or gives up with StatusFailed and no
+// time once retryBackoff is used up.
+func retryAfter(attempts int, now time.Time) (string, *time.Time) {
+	if attempts > len(retryBackoff) {
+		return StatusFailed, nil
+	}
+
+	next := now.Add(retryBackoff[attempts-1])
+
+	return StatusPending, &next
+}
+
+func pruneDeliveries(ctx context.Context, db *sqlx.DB) error {
+	q := `DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1`
+
+	if _, err := db.ExecContext(ctx, q, time.Now().Add(-deliveryRetained)); err != nil {
+		return fmt.Errorf("prune webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// newClient refuses to connect to private, loopback and link-local addresses (unless allowPrivate), checked on the
+// resolved IP at dial time so a hostname can't be pointed at the internal network after it was validated. Proxies
+// from the environment are ignored for the same reason, and redirects are reported as failures, not followed.
+func newClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_ string, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublic(ip) {
+				return errBlockedAddress
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: requestTimeout,
+			MaxIdleConnsPerHost:   2,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository runs webhook and delivery queries against Postgres.
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, familyID uuid.UUID, userID uuid.UUID, rawURL string, events []string) (NewWebhook, error) {
+	return Create(ctx, r.db, familyID, userID, rawURL, events)
+}
+
+func (r *Repository) Delete(ctx context.Context, familyID uuid.UUID, webhookID uuid.UUID) error {
+	return Delete(ctx, r.db, familyID, webhookID)
+}
+
+func (r *Repository) Edit(ctx context.Context, familyID uuid.UUID, webhookID uuid.UUID, u Update) (Webhook, error) {
+	return Edit(ctx, r.db, familyID, webhookID, u)
+}
+
+func (r *Repository) List(ctx context.Context, familyID uuid.UUID) ([]Webhook, error) {
+	return List(ctx, r.db, familyID)
+}
+
+func (r *Repository) ListDeliveries(ctx context.Context, familyID uuid.UUID, webhookID uuid.UUID, before *uuid.UUID, limit int) (DeliveryPage, error) {
+	return ListDeliveries(ctx, r.db, familyID, webhookID, before, limit)
+}
@@ -0,0 +1,242 @@
+// Package webhook lets a family owner subscribe an HTTPS endpoint to the family's events. Deliveries are queued in
+// the database when an event is published and sent by a background dispatcher, signed with the webhook's secret.
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/zachczx/cubby/api/internal/activity"
+	"github.com/zachczx/cubby/api/internal/apperr"
+	"github.com/zachczx/cubby/api/internal/event"
+	"github.com/zachczx/cubby/api/internal/secret"
+)
+
+const (
+	MaxPerFamily = 10
+	maxURLLength = 2048
+	secretPrefix = "whsec_"
+)
+
+// Events lists everything a webhook can subscribe to: the activity feed kinds plus the events that only exist on
+// the bus.
+var Events = []event.Type{
+	event.Type(activity.TrackerCreated),
+	event.Type(activity.TrackerUpdated),
+	event.Type(activity.TrackerDeleted),
+	event.Type(activity.EntryCreated),
+	event.Type(activity.EntryBulkCreated),
+	event.Type(activity.EntryDeleted),
+	event.Type(activity.CommentCreated),
+	event.Type(activity.InviteCreated),
+	event.Type(activity.InviteLinkCreated),
+	event.Type(activity.InviteAccepted),
+	event.Type(activity.InviteDeclined),
+	event.Type(activity.InviteRevoked),
+	event.Type(activity.InviteResent),
+	event.Type(activity.MemberLeft),
+	event.Type(activity.MemberRemoved),
+	event.Type(activity.FamilyRenamed),
+	event.Type(activity.FamilyTransferred),
+	event.Type(activity.VacationCreated),
+	event.Type(activity.VacationDeleted),
+	event.TrackerDue,
+	event.WorkoutCompleted,
+	event.MarketPriceLogged,
+}
+
+var (
+	ErrWebhookNotFound = apperr.NotFound("webhook not found")
+	ErrTooManyWebhooks = apperr.Conflict("too many webhooks for this family")
+	ErrUnknownEvent    = errors.New("unknown event")
+	ErrInvalidURL      = errors.New("invalid webhook url")
+)
+
+type Webhook struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	FamilyID  uuid.UUID      `json:"familyId" db:"family_id"`
+	CreatedBy *uuid.UUID     `json:"createdBy" db:"created_by"`
+	URL       string         `json:"url" db:"url"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	Active    bool           `json:"active" db:"active"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+}
+
+// NewWebhook is returned once on creation; receivers need the secret to verify signatures and it isn't shown again.
+type NewWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type Request struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// Update leaves nil fields unchanged.
+type Update struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// ValidateEvents rejects anything not in Events and drops duplicates.
+func ValidateEvents(events []string) ([]string, error) {
+	out := make([]string, 0, len(events))
+
+	for _, e := range events {
+		if !slices.Contains(Events, event.Type(e)) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownEvent, e)
+		}
+
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+
+	return out, nil
+}
+
+// ValidateURL requires an absolute https URL that doesn't point at a private address. Plain http and local
+// addresses are allowed in development so a receiver can run on the same machine. Hostnames are checked again when
+// dialing, since DNS can change after the webhook is saved.
+func ValidateURL(raw string, allowPrivate bool) error {
+	if len(raw) > maxURLLength {
+		return fmt.Errorf("%w: url cannot exceed %d characters", ErrInvalidURL, maxURLLength)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: must be an absolute url", ErrInvalidURL)
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials are not allowed in the url", ErrInvalidURL)
+	}
+
+	dev := allowPrivate
+
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && dev:
+	default:
+		return fmt.Errorf("%w: must use https", ErrInvalidURL)
+	}
+
+	if dev {
+		return nil
+	}
+
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return fmt.Errorf("%w: must be a public address", ErrInvalidURL)
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !isPublic(ip) {
+		return fmt.Errorf("%w: must be a public address", ErrInvalidURL)
+	}
+
+	return nil
+}
+
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+func List(ctx context.Context, db *sqlx.DB, familyID uuid.UUID) ([]Webhook, error) {
+	q := `SELECT id, family_id, created_by, url, events, active, created_at
+			FROM webhooks
+			WHERE family_id = $1
+			ORDER BY created_at ASC`
+
+	hooks := []Webhook{}
+	if err := db.SelectContext(ctx, &hooks, q, familyID); err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func Create(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, userID uuid.UUID, rawURL string, events []string) (NewWebhook, error) {
+	var count int
+
+	if err := db.GetContext(ctx, &count, `SELECT COUNT(*) FROM webhooks WHERE family_id = $1`, familyID); err != nil {
+		return NewWebhook{}, fmt.Errorf("count webhooks: %w", err)
+	}
+
+	if count >= MaxPerFamily {
+		return NewWebhook{}, ErrTooManyWebhooks
+	}
+
+	raw, err := secret.New()
+	if err != nil {
+		return NewWebhook{}, fmt.Errorf("webhook secret: %w", err)
+	}
+
+	// The secret is stored as-is rather than hashed: every delivery has to be signed with it.
+	h := NewWebhook{Secret: secretPrefix + raw}
+
+	q := `INSERT INTO webhooks (family_id, created_by, url, secret, events)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, family_id, created_by, url, events, active, created_at`
+
+	if err := db.GetContext(ctx, &h.Webhook, q, familyID, userID, rawURL, h.Secret, pq.StringArray(events)); err != nil {
+		return NewWebhook{}, fmt.Errorf("create webhook: %w", err)
+	}
+
+	return h, nil
+}
+
+func Edit(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, webhookID uuid.UUID, u Update) (Webhook, error) {
+	var events any
+	if u.Events != nil {
+		events = pq.StringArray(u.Events)
+	}
+
+	q := `UPDATE webhooks SET
+				url = COALESCE($3, url),
+				events = COALESCE($4, events),
+				active = COALESCE($5, active)
+			WHERE id = $1 AND family_id = $2
+			RETURNING id, family_id, created_by, url, events, active, created_at`
+
+	var h Webhook
+	if err := db.GetContext(ctx, &h, q, webhookID, familyID, u.URL, events, u.Active); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Webhook{}, ErrWebhookNotFound
+		}
+
+		return Webhook{}, fmt.Errorf("edit webhook: %w", err)
+	}
+
+	return h, nil
+}
+
+func Delete(ctx context.Context, db *sqlx.DB, familyID uuid.UUID, webhookID uuid.UUID) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND family_id = $2`, webhookID, familyID)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete webhook rows: %w", err)
+	}
+
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
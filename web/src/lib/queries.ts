@@ -91,7 +91,7 @@ export const inviteRefetchOptions = inviteQuery.refetch;
 export const singleInviteQueryOptions = (inviteId: string) => () =>
 	queryOptions<InviteDB>({
 		queryKey: [...rootKey, 'invites', inviteId],
-		queryFn: async () => await api.get(`families/invites/${inviteId}`).json(),
+		queryFn: async () => await api.get(`invites/${inviteId}`).json(),
 		staleTime: staleTime
 	});
 